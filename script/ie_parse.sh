@@ -7,7 +7,10 @@
 
 set -eu
 
+# NER engine: pytext (bin/ner.c2) or go (a model trained with src/cmd/ner/ner.go)
+NER_ENGINE="${NER_ENGINE:-pytext}"
 NER_MODEL="bin/ner.c2"
+GO_NER_MODEL="bin/ner.json"
 
 CLINICAL_TRIAL_FILE="data/input/clinical_trials.csv"
 EXTRACTED_FILE="data/output/ie_extracted_clinical_trials.tsv"
@@ -16,6 +19,8 @@ PARSED_FILE="data/output/ie_parsed_clinical_trials.tsv"
 
 EXTRACT_CMD="src/cmd/extract/extract.go"
 NER_CMD="src/ie/ner.py"
+GO_NER_CMD="src/cmd/ner/ner.go"
+GO_NER_CONFIG="src/resources/config/ner.conf"
 NEL_CMD="src/cmd/nel/nel.go"
 NEL_CONFIG="src/resources/config/nel.conf"
 
@@ -29,11 +34,20 @@ then
 fi
 
 echo "Run NER on extracted criteria..."
-export PYTHONPATH="$(pwd)/src"
-if ! python "$NER_CMD" -m "$NER_MODEL" -i "$EXTRACTED_FILE" -o "$NER_FILE"
+if [ "$NER_ENGINE" = "go" ]
 then
-  echo "NER failed."
-  exit 1
+  if ! go run "$GO_NER_CMD" -conf "$GO_NER_CONFIG" -m "$GO_NER_MODEL" -i "$EXTRACTED_FILE" -o "$NER_FILE" -logtostderr
+  then
+    echo "NER failed."
+    exit 1
+  fi
+else
+  export PYTHONPATH="$(pwd)/src"
+  if ! python "$NER_CMD" -m "$NER_MODEL" -i "$EXTRACTED_FILE" -o "$NER_FILE"
+  then
+    echo "NER failed."
+    exit 1
+  fi
 fi
 
 echo "Run NEL to map NER terms to MESH concepts..."
@@ -4,12 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/conf"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/timer"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/catalog"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/parser"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/studies"
-	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
-	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
 
 	"github.com/golang/glog"
 )
@@ -18,6 +19,8 @@ import (
 type Parser struct {
 	parameters conf.Config
 	registry   studies.Studies
+	catalogs   *catalog.Manager
+	grammar    parser.Grammar
 	clock      timer.Timer
 }
 
@@ -60,22 +63,39 @@ func (p *Parser) LoadParameters(configFname string) error {
 }
 
 // InitParameters initializes the parser by loading the resource data.
+// If 'catalog_reload_interval' (seconds) is set, the variable and unit
+// files are watched and reloaded when they change. If 'grammar_file' is set,
+// criteria are parsed with its grammar instead of the built-in grammar, using
+// the parsing algorithm 'grammar_algorithm' (cyk or earley, cyk by default).
 func (p *Parser) InitParameters() error {
-	fname := p.parameters.GetResourcePath("variable_file")
-	log.Printf("variable file path: %v", fname)
-	variableDictionary, err := variables.Load(fname)
-	if err != nil {
-		return err
-	}
-	variables.Set(variableDictionary)
+	variableFname := p.parameters.GetResourcePath("variable_file")
+	log.Printf("variable file path: %v", variableFname)
+	unitFname := p.parameters.GetResourcePath("unit_file")
+	log.Printf("unit file path: %v", unitFname)
 
-	fname = p.parameters.GetResourcePath("unit_file")
-	log.Printf("unit file path: %v", fname)
-	unitDictionary, err := units.Load(fname)
-	if err != nil {
+	p.catalogs = catalog.NewManager(variableFname, unitFname)
+	if err := p.catalogs.Load(); err != nil {
 		return err
 	}
-	units.Set(unitDictionary)
+	p.grammar = parser.DefaultCatalogs().Grammar
+	if p.parameters.Exists("grammar_file") {
+		grammarFname := p.parameters.GetResourcePath("grammar_file")
+		log.Printf("grammar file path: %v", grammarFname)
+		algorithm := "cyk"
+		if p.parameters.Exists("grammar_algorithm") {
+			algorithm = p.parameters.Get("grammar_algorithm")
+		}
+		grammar, err := parser.LoadGrammar(grammarFname, algorithm)
+		if err != nil {
+			return err
+		}
+		p.grammar = grammar
+	}
+	if p.parameters.Exists("catalog_reload_interval") {
+		if interval := p.parameters.GetInt("catalog_reload_interval"); interval > 0 {
+			p.catalogs.Watch(time.Duration(interval) * time.Second)
+		}
+	}
 
 	return nil
 }
@@ -108,8 +128,14 @@ func (p *Parser) Parse() string {
 	var ps studies.ParsedStudies
 
 	for _, study := range p.registry {
-		r := study.Parse().Relations()
+		// Each study is parsed with one snapshot of the catalogs, even if they are reloaded meanwhile:
+		snapshot := p.catalogs.Snapshot()
+		catalogs := &parser.Catalogs{Variables: snapshot.Variables, Units: snapshot.Units, Grammar: p.grammar}
+		r := study.ParseWith(catalogs).Relations()
 		s := studies.NewParsedStudy(study.Id, study.CriteriaCnt, r)
+		s.SectionStrategy = study.SectionStrategy().String()
+		s.Cohorts = study.Cohorts()
+		s.CatalogVersion = snapshot.Version
 		ps = append(ps, s)
 
 		relationCnt += study.RelationCount()
@@ -130,6 +156,9 @@ func (p *Parser) Parse() string {
 
 // Close closes the parser.
 func (p *Parser) Close() {
+	if p.catalogs != nil {
+		p.catalogs.Stop()
+	}
 	glog.Info(p.clock.Elapsed())
 	glog.Flush()
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/facebookresearch/clinical-trial-parser/src/common/param"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/fio"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/timer"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/criteria"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/studies"
 
 	"github.com/golang/glog"
@@ -112,9 +113,11 @@ func (p *Extractor) Extract() error {
 	defer writer.Close()
 
 	criteriaCnt := 0
+	strategyCnt := make(map[criteria.Strategy]int)
 	writer.WriteString(header)
 	for _, study := range p.registry {
 		inclusions, exclusions := study.Criteria()
+		strategyCnt[study.SectionStrategy()]++
 		for _, criterion := range inclusions {
 			if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\n", study.GetId(), "inclusion", criterion); err != nil {
 				return err
@@ -128,6 +131,9 @@ func (p *Extractor) Extract() error {
 		criteriaCnt += len(inclusions) + len(exclusions)
 	}
 	glog.Infof("Ingested studies: %d, Extracted criteria: %d\n", p.registry.Len(), criteriaCnt)
+	for _, s := range []criteria.Strategy{criteria.StandardHeaders, criteria.HeaderVocabulary, criteria.Headerless, criteria.NoStrategy} {
+		glog.Infof("Section strategy %s: %d studies\n", s, strategyCnt[s])
+	}
 	return nil
 }
 
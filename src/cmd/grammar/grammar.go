@@ -0,0 +1,122 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package grammar
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/conf"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/parser"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/golang/glog"
+)
+
+// main interprets criteria with the criterion grammar and writes each step to stdout:
+// the lexer tokens, the parsed items, the CYK chart, the candidate trees with their scores,
+// and the processed relations. With -dot, the trees are also written in the Graphviz DOT
+// format, e.g., to be rendered with 'dot -Tsvg'. The criterion is given on the command line,
+// or criteria are read from stdin, one per line. The catalogs and the grammar are read from
+// the config file (variable_file, unit_file, and grammar_file), or the built-in catalogs
+// and grammar are used. A grammar file given with -g is validated and, with -rules, its
+// binarized production rules are written instead of interpreting criteria. The CYK chart
+// is written for the cyk parsing algorithm only.
+func main() {
+	configFname := flag.String("conf", "", "Config file with variable_file, unit_file, and grammar_file parameters")
+	grammarFname := flag.String("g", "", "Grammar file")
+	rules := flag.Bool("rules", false, "Write the binarized production rules of the grammar file")
+	algorithm := flag.String("a", "cyk", "Parsing algorithm of the grammar file: cyk or earley")
+	dot := flag.Bool("dot", false, "Write the parse trees in the Graphviz DOT format")
+
+	flag.Parse()
+
+	catalogs, err := load(*configFname)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if len(*grammarFname) > 0 {
+		ps, err := parser.LoadProductions(*grammarFname)
+		if err != nil {
+			glog.Fatal(err)
+		}
+		if msgs := ps.Validate(parser.StartSymbol); len(msgs) > 0 {
+			fmt.Println(strings.Join(msgs, "\n"))
+			glog.Flush()
+			os.Exit(1)
+		}
+		if *rules {
+			fmt.Print(ps.Binarize())
+			return
+		}
+		if catalogs.Grammar, err = parser.LoadGrammar(*grammarFname, *algorithm); err != nil {
+			glog.Fatal(err)
+		}
+	}
+	interpreter := parser.NewCatalogInterpreter(catalogs)
+
+	debug := func(criterion string) {
+		trace := interpreter.Trace(strings.ToLower(criterion))
+		fmt.Print(trace)
+		if *dot {
+			fmt.Println()
+			for k, tree := range trace.Trees {
+				fmt.Print(tree.DOT(fmt.Sprintf("tree%d", k)))
+			}
+		}
+	}
+
+	if flag.NArg() > 0 {
+		debug(strings.Join(flag.Args(), " "))
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for first := true; scanner.Scan(); {
+		criterion := strings.TrimSpace(scanner.Text())
+		if len(criterion) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Println("\n----")
+		}
+		first = false
+		debug(criterion)
+	}
+	if err := scanner.Err(); err != nil {
+		glog.Fatal(err)
+	}
+}
+
+// load loads the catalogs that the criteria are interpreted with.
+func load(configFname string) (*parser.Catalogs, error) {
+	if len(configFname) == 0 {
+		return parser.NewCatalogs(variables.DefaultCatalog(), units.DefaultCatalog()), nil
+	}
+	parameters, err := conf.Load(configFname)
+	if err != nil {
+		return nil, err
+	}
+	vs, err := variables.Load(parameters.GetResourcePath("variable_file"))
+	if err != nil {
+		return nil, err
+	}
+	us, err := units.Load(parameters.GetResourcePath("unit_file"))
+	if err != nil {
+		return nil, err
+	}
+	catalogs := parser.NewCatalogs(vs, us)
+	if parameters.Exists("grammar_file") {
+		algorithm := "cyk"
+		if parameters.Exists("grammar_algorithm") {
+			algorithm = parameters.Get("grammar_algorithm")
+		}
+		if catalogs.Grammar, err = parser.LoadGrammar(parameters.GetResourcePath("grammar_file"), algorithm); err != nil {
+			return nil, err
+		}
+	}
+	return catalogs, nil
+}
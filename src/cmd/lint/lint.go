@@ -0,0 +1,74 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package lint
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/conf"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/lint"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/golang/glog"
+)
+
+// main checks the variable and unit catalogs and writes a report of the issues
+// found to stdout. It exits with status 1 if any issues are found. The catalog
+// files are read from the config file (variable_file and unit_file) unless they
+// are given on the command line, and the built-in catalogs are checked with -default.
+func main() {
+	configFname := flag.String("conf", "", "Config file with variable_file and unit_file parameters")
+	variableFname := flag.String("v", "", "Variable catalog file")
+	unitFname := flag.String("u", "", "Unit catalog file")
+	builtin := flag.Bool("default", false, "Check the built-in default catalogs")
+
+	flag.Parse()
+
+	vs, us, err := load(*configFname, *variableFname, *unitFname, *builtin)
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	issues := lint.Lint(vs, us)
+	fmt.Print(issues)
+	fmt.Printf("%d variables, %d units, %d issues\n", vs.Size(), us.Size(), len(issues))
+	glog.Flush()
+	if len(issues) > 0 {
+		os.Exit(1)
+	}
+}
+
+// load loads the catalogs to be checked.
+func load(configFname, variableFname, unitFname string, builtin bool) (*variables.Variables, *units.Units, error) {
+	if builtin {
+		return variables.DefaultCatalog(), units.DefaultCatalog(), nil
+	}
+	if len(configFname) > 0 {
+		parameters, err := conf.Load(configFname)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(variableFname) == 0 {
+			variableFname = parameters.GetResourcePath("variable_file")
+		}
+		if len(unitFname) == 0 {
+			unitFname = parameters.GetResourcePath("unit_file")
+		}
+	}
+	if len(variableFname) == 0 || len(unitFname) == 0 {
+		return nil, nil, fmt.Errorf("usage: %s -conf <config file> | -v <variable file> -u <unit file> | -default", os.Args[0])
+	}
+
+	vs, err := variables.Load(variableFname)
+	if err != nil {
+		return nil, nil, err
+	}
+	us, err := units.Load(unitFname)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vs, us, nil
+}
@@ -4,9 +4,11 @@ package nel
 
 import (
 	"bufio"
+	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -18,6 +20,9 @@ import (
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/slice"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/timer"
 	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies"
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/abbreviation"
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/cluster"
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/custom"
 	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/mesh"
 	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/taxonomy"
 	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/umls"
@@ -31,7 +36,8 @@ var (
 )
 
 // main matches (grounds) extracted (input) terms to vocabulary concepts.
-// Matching results are written to a file. This version does not use clustering.
+// Matching results are written to a file. If a cluster file is defined,
+// unmatched terms are clustered and the clusters are written to the cluster file.
 func main() {
 	m := NewMatcher()
 	if err := m.LoadParameters(); err != nil {
@@ -40,6 +46,7 @@ func main() {
 	if err := m.LoadVocabulary(); err != nil {
 		glog.Fatal(err)
 	}
+	m.LoadAbbreviations()
 	if err := m.Match(); err != nil {
 		glog.Fatal(err)
 	}
@@ -48,15 +55,16 @@ func main() {
 
 // Slot defines the extracted NER slot.
 type Slot struct {
-	label string  // Slot label
-	term  string  // Slot term
-	score float64 // NER score
+	label    string  // Slot label
+	term     string  // Slot term
+	original string  // Slot term as extracted by NER
+	score    float64 // NER score
 }
 
 type Slots []Slot
 
 func NewSlot(label string, term string, score float64) Slot {
-	return Slot{label: label, term: term, score: score}
+	return Slot{label: label, term: term, original: term, score: score}
 }
 
 func (s Slot) SubTerms() []string {
@@ -65,6 +73,11 @@ func (s Slot) SubTerms() []string {
 	return slice.RemoveEmpty(v)
 }
 
+// Expand replaces abbreviations in the slot term with their long forms.
+func (s *Slot) Expand(abbreviations abbreviation.Dictionary) {
+	s.term = abbreviations.Expand(s.term)
+}
+
 func (s *Slot) Normalize(normalize taxonomy.Normalizer) {
 	_, s.term = normalize(s.term)
 }
@@ -87,12 +100,14 @@ func (ss Slots) Size() int {
 }
 
 // Matcher defines the struct that matches extracted terms to concepts
-// from a vocabulary.
+// from vocabularies.
 type Matcher struct {
-	parameters conf.Config
-	vocabulary *taxonomy.Taxonomy
-	normalize  taxonomy.Normalizer
-	clock      timer.Timer
+	parameters    conf.Config
+	routes        Routes
+	vocabularies  map[string]*taxonomy.Taxonomy
+	normalize     taxonomy.Normalizer
+	abbreviations abbreviation.Dictionary
+	clock         timer.Timer
 }
 
 // NewMatcher creates a new matcher.
@@ -106,10 +121,12 @@ func (m *Matcher) LoadParameters() error {
 	configFname := flag.String("conf", "", "Config file")
 	inputFname := flag.String("i", "", "Input file")
 	outputFname := flag.String("o", "", "Output file")
+	outputFormat := flag.String("f", "", "Output format: tsv or json")
+	clusterFname := flag.String("c", "", "Cluster file for unmatched terms")
 
 	flag.Parse()
 	if len(*configFname) == 0 {
-		return fmt.Errorf("usage: %s -conf <config file> -i <input file> -o <output file>", os.Args[0])
+		return fmt.Errorf("usage: %s -conf <config file> -i <input file> -o <output file> [-f <tsv|json>] [-c <cluster file>]", os.Args[0])
 	}
 
 	parameters, err := conf.Load(*configFname)
@@ -123,6 +140,15 @@ func (m *Matcher) LoadParameters() error {
 	if len(*outputFname) > 0 {
 		parameters.Put("output_file", *outputFname)
 	}
+	if len(*clusterFname) > 0 {
+		parameters.Put("cluster_file", *clusterFname)
+	}
+	if len(*outputFormat) > 0 {
+		parameters.Put("output_format", *outputFormat)
+	}
+	if !parameters.Exists("output_format") {
+		parameters.Put("output_format", TSV.String())
+	}
 	if !parameters.Exists("input_file") {
 		return fmt.Errorf("input file not defined")
 	}
@@ -131,53 +157,132 @@ func (m *Matcher) LoadParameters() error {
 	}
 
 	m.parameters = parameters
+	m.routes = LoadRoutes(parameters)
 
 	return nil
 }
 
+// LoadVocabulary loads the vocabularies used by the label routes. The default vocabulary
+// is defined by the unprefixed vocabulary parameters, and other vocabularies by parameters
+// prefixed with the vocabulary name, e.g., 'drugs.vocabulary_file'.
 func (m *Matcher) LoadVocabulary() error {
-	vocabularyFname := m.parameters.Get("vocabulary_file")
+	m.normalize = mesh.Normalize
+	m.vocabularies = make(map[string]*taxonomy.Taxonomy)
+
+	names := set.New(DefaultVocabulary)
+	names.Add(m.routes.Vocabularies()...)
+	for _, name := range names.Slice() {
+		vocabulary, err := m.loadVocabulary(name)
+		if err != nil {
+			return err
+		}
+		m.vocabularies[name] = vocabulary
+	}
+
+	return nil
+}
+
+// loadVocabulary loads the named vocabulary.
+func (m *Matcher) loadVocabulary(name string) (*taxonomy.Taxonomy, error) {
+	key := func(k string) string {
+		if name == DefaultVocabulary {
+			return k
+		}
+		return name + "." + k
+	}
+	if !m.parameters.Exists(key("vocabulary_source")) {
+		return nil, fmt.Errorf("vocabulary not defined: %s", name)
+	}
+
 	var customFnames []string
-	if m.parameters.Exists("custom_vocabulary_file") {
-		path := m.parameters.Get("custom_vocabulary_file")
+	if m.parameters.Exists(key("custom_vocabulary_file")) {
+		path := m.parameters.Get(key("custom_vocabulary_file"))
 		customFnames = fio.ReadFnames(path)
 	}
 
-	source := vocabularies.ParseSource(m.parameters.Get("vocabulary_source"))
+	source := vocabularies.ParseSource(m.parameters.Get(key("vocabulary_source")))
 	var vocabulary *taxonomy.Taxonomy
 	switch source {
 	case vocabularies.MESH:
-		glog.Info("Loading MeSH ...")
-		vocabulary = mesh.Load(vocabularyFname, customFnames...)
+		glog.Infof("Loading MeSH (%s) ...", name)
+		vocabulary = mesh.Load(m.parameters.Get(key("vocabulary_file")), customFnames...)
 	case vocabularies.UMLS:
-		glog.Info("Loading UMLS ...")
-		vocabulary = umls.Load(vocabularyFname)
+		glog.Infof("Loading UMLS (%s) ...", name)
+		vocabulary = umls.Load(m.parameters.Get(key("vocabulary_file")))
+	case vocabularies.CUSTOM:
+		glog.Infof("Loading custom vocabulary (%s) ...", name)
+		fnames := fio.ReadFnames(m.parameters.Get(key("vocabulary_file")))
+		vocabulary = custom.Load(append(fnames, customFnames...)...)
 	default:
-		return fmt.Errorf("unknown vocabulary source")
+		return nil, fmt.Errorf("unknown vocabulary source: %s", name)
 	}
 
 	rows := m.parameters.GetInt("lsh_rows")
 	bands := m.parameters.GetInt("lsh_rows")
 
-	m.normalize = mesh.Normalize
 	vocabulary.Normalize(m.normalize)
 	vocabulary.SetHashIndex(rows, bands)
 	vocabulary.Info()
 
-	m.vocabulary = vocabulary
+	return vocabulary, nil
+}
 
-	return nil
+// LoadAbbreviations loads the abbreviation dictionary if one is defined.
+func (m *Matcher) LoadAbbreviations() {
+	m.abbreviations = abbreviation.NewDictionary()
+	if m.parameters.Exists("abbreviation_file") {
+		fnames := fio.ReadFnames(m.parameters.Get("abbreviation_file"))
+		m.abbreviations = abbreviation.Load(fnames...)
+	}
+}
+
+// detectAbbreviations finds abbreviations defined in the criteria of each study.
+// Study abbreviations are merged with the abbreviation dictionary.
+func (m *Matcher) detectAbbreviations(fname string) (map[string]abbreviation.Dictionary, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	studyAbbreviations := make(map[string]abbreviation.Dictionary)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 || line[0] == param.Comment {
+			continue
+		}
+		values := strings.Split(line, "\t")
+		nctID := values[0]
+		criterion := values[2]
+		d, ok := studyAbbreviations[nctID]
+		if !ok {
+			d = abbreviation.NewDictionary()
+			studyAbbreviations[nctID] = d
+		}
+		for k, v := range abbreviation.Detect(criterion) {
+			d[k] = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for nctID, d := range studyAbbreviations {
+		studyAbbreviations[nctID] = m.abbreviations.Merge(d)
+	}
+	return studyAbbreviations, nil
 }
 
-// getNERSlots gets the extracted terms from a string.
-func getNERSlots(termStr string, nerThreshold float64, validLabels set.Set) Slots {
+// getNERSlots gets the extracted terms of the routed labels from a string.
+func getNERSlots(termStr string, routes Routes) Slots {
 	var data map[string]interface{}
 	if err := json.Unmarshal([]byte(termStr), &data); err != nil {
 		glog.Fatal(termStr, err)
 	}
 	slots := NewSlots()
 	for label, values := range data {
-		if validLabels.Contains(label) {
+		if route, ok := routes.Get(label); ok {
 			for _, fields := range values.([]interface{}) {
 				var term string
 				var score float64
@@ -196,7 +301,7 @@ func getNERSlots(termStr string, nerThreshold float64, validLabels set.Set) Slot
 				if len(norm) > 0 {
 					term = norm
 				}
-				if score > nerThreshold && len(term) > 0 {
+				if score > route.NERThreshold && len(term) > 0 {
 					slots.Add(label, term, score)
 				}
 			}
@@ -205,23 +310,104 @@ func getNERSlots(termStr string, nerThreshold float64, validLabels set.Set) Slot
 	return slots
 }
 
-func (m *Matcher) Match() error {
-	nerThreshold := m.parameters.GetFloat64("ner_threshold")
-	validLabels := set.New(m.parameters.GetSlice("valid_labels", ",")...)
+// criterionSlots defines the NER slots extracted from a criterion.
+type criterionSlots struct {
+	eligibilityType string
+	criterion       string
+	slots           Slots
+}
+
+// loadConditions loads the conditions of studies from a clinical-trial csv file
+// with the columns: nct_id, title, has_us_facility, conditions, eligibility_criteria.
+func loadConditions(fname string) (map[string][]string, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	conditions := make(map[string][]string)
+	r := csv.NewReader(file)
+	r.Comment = rune(param.Comment)
+	r.FieldsPerRecord = -1
+	for {
+		line, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(line) < 4 {
+			return nil, fmt.Errorf("too few columns, at least 4 needed: %v", line)
+		}
+		v := strings.Split(line[3], param.FieldSep)
+		slice.TrimSpace(v)
+		conditions[line[0]] = slice.RemoveEmpty(v)
+	}
+	glog.Infof("%s: Studies: %d\n", fname, len(conditions))
+	return conditions, nil
+}
 
+func (m *Matcher) Match() error {
 	matchThreshold := m.parameters.GetFloat64("match_threshold")
 	matchMargin := m.parameters.GetFloat64("match_margin")
 
+	priors := make(taxonomy.Priors)
+	if m.parameters.Exists("category_priors") {
+		priors = taxonomy.ParsePriors(m.parameters.Get("category_priors"))
+	}
+
+	var clusterer *cluster.Clusterer
+	if m.parameters.Exists("cluster_file") {
+		rows := m.parameters.GetInt("lsh_rows")
+		bands := m.parameters.GetInt("lsh_bands")
+		threshold := m.parameters.GetFloat64("cluster_threshold")
+		examples := m.parameters.GetInt("cluster_examples")
+		clusterer = cluster.New(rows, bands, threshold, examples)
+	}
+
+	studyConditions := make(map[string][]string)
+	if m.parameters.Exists("study_file") {
+		var err error
+		if studyConditions, err = loadConditions(m.parameters.Get("study_file")); err != nil {
+			return err
+		}
+	}
+
 	matchedSlots := make(map[string]taxonomy.Terms)
 	conceptSet := set.New()
 	slotCnt := 0
 	matchedSlotCnt := 0
+	skippedSlotCnt := 0
 
 	defaultCategories := set.New()
-	cancerCategories := set.New("C")
-	personCategories := set.New("M")
+
+	// match matches the subterm to concepts in the vocabularies of the route.
+	match := func(subterm string, r *Route) taxonomy.Terms {
+		key := r.Label + "\t" + subterm
+		if terms, ok := matchedSlots[key]; ok {
+			return terms
+		}
+		terms := taxonomy.NewTerms(0)
+		for _, name := range r.Vocabularies {
+			terms = append(terms, m.vocabularies[name].MatchTreeNumbers(subterm, r.MatchMargin, r.Categories, r.TreeNumbers)...)
+		}
+		if len(r.Vocabularies) > 1 {
+			terms = terms.SortByKey().Dedupe().SortByValue().TopDelta(r.MatchMargin)
+		}
+		// The default term of an unmatched subterm does not have the tree numbers of the route.
+		terms = terms.PassTreeNumbers(r.TreeNumbers)
+		matchedSlots[key] = terms
+		return terms
+	}
 
 	fname := m.parameters.Get("input_file")
+	studyAbbreviations, err := m.detectAbbreviations(fname)
+	if err != nil {
+		return err
+	}
+
 	file, err := os.Open(fname)
 	if err != nil {
 		return err
@@ -235,76 +421,151 @@ func (m *Matcher) Match() error {
 	writer := fio.Writer(outputFname)
 	defer writer.Close()
 
-	header := "#nct_id\teligibility_type\tcriterion\tlabel\tterm\tner_score\tconcepts\ttree_numbers\tnel_score\n"
-	writer.WriteString(header)
-
-	glog.Infof("Matching NER terms ...")
+	format := ParseOutputFormat(m.parameters.Get("output_format"))
+	if format == TSV {
+		header := "#nct_id\teligibility_type\tcriterion\tlabel\tterm\tner_score\tconcepts\ttree_numbers\tnel_score\tconcept\treason\n"
+		writer.WriteString(header)
+	}
 
-	for scanner.Scan() {
-		lineCnt++
-		line := scanner.Text()
-		if len(line) == 0 || line[0] == param.Comment {
-			continue
+	// matchStudy matches the NER terms of a study to concepts. Ambiguous matches are
+	// disambiguated by the study conditions, unambiguous concepts linked in the study,
+	// and the category priors of the NER labels. The study context is seeded only by
+	// the unambiguous matches of the first pass, so that the selections do not depend
+	// on the order of the criteria.
+	matchStudy := func(nctID string, study []criterionSlots) error {
+		context := taxonomy.NewContext()
+		for _, condition := range studyConditions[nctID] {
+			if concepts := m.vocabularies[DefaultVocabulary].Match(condition, matchMargin, defaultCategories); concepts.MaxValue() >= matchThreshold {
+				context.AddConditions(concepts)
+			}
 		}
 
-		// Extract NER terms
-		values := strings.Split(line, "\t")
-		nctID := values[0]
-		eligibilityType := values[1]
-		criterion := values[2]
-		termStr := values[3]
-		slots := getNERSlots(termStr, nerThreshold, validLabels)
-		slotCnt += slots.Size()
-
-		// Match NER terms to concepts
-		for _, slot := range slots {
-			subterms := slot.SubTerms()
-			for _, subterm := range subterms {
-				if _, ok := matchedSlots[subterm]; !ok {
-					var validCategories set.Set
-					switch slot.label {
-					case "word_scores:cancer":
-						validCategories = cancerCategories
-					case "word_scores:gender":
-						validCategories = personCategories
-					default:
-						validCategories = defaultCategories
+		for _, c := range study {
+			for i := range c.slots {
+				route, _ := m.routes.Get(c.slots[i].label)
+				if route.Skip {
+					continue
+				}
+				c.slots[i].Expand(studyAbbreviations[nctID])
+				for _, subterm := range c.slots[i].SubTerms() {
+					if concepts := match(subterm, route); concepts.Len() == 1 && concepts.MaxValue() >= route.MatchThreshold {
+						context.AddConcept(concepts[0])
 					}
-					matchedSlots[subterm] = m.vocabulary.Match(subterm, matchMargin, validCategories)
 				}
 			}
+		}
 
-			slot.Normalize(m.normalize)
-			hasMatch := false
-
-			for _, subterm := range subterms {
-				matchedConcepts := matchedSlots[subterm]
-				if matchedConcepts.MaxValue() >= matchThreshold {
+		for _, c := range study {
+			for _, slot := range c.slots {
+				route, _ := m.routes.Get(slot.label)
+				subterms := slot.SubTerms()
+				if route.Skip {
+					subterms = nil
+					skippedSlotCnt++
+				}
+				slot.Normalize(m.normalize)
+				hasMatch := false
+				record := NewRecord(nctID, c.eligibilityType, c.criterion, slot)
+
+				for _, subterm := range subterms {
+					matchedConcepts := match(subterm, route)
+					if matchedConcepts.MaxValue() < route.MatchThreshold {
+						continue
+					}
 					hasMatch = true
 					conceptSet.Add(matchedConcepts.Keys()...)
+					ranked, reason := context.Disambiguate(matchedConcepts, slot.label, priors)
+					if format == JSON {
+						record.AddConcepts(NewConcepts(ranked, reason))
+						continue
+					}
 					concepts := strings.Join(matchedConcepts.Keys(), "|")
 					nelScore := matchedConcepts.MaxValue()
 					treeNumbers := strings.Join(matchedConcepts.TreeNumbers(), "|")
-					if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%.3f\n", nctID, eligibilityType, criterion, slot.String(), concepts, treeNumbers, nelScore); err != nil {
+					if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%.3f\t%s\t%s\n", nctID, c.eligibilityType, c.criterion, slot.String(), concepts, treeNumbers, nelScore, ranked.MaxKey(), reason); err != nil {
 						return err
 					}
 				}
-			}
 
-			if hasMatch {
-				matchedSlotCnt++
-			} else {
-				if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", nctID, eligibilityType, criterion, slot.String()); err != nil {
-					return err
+				if hasMatch {
+					matchedSlotCnt++
+				} else if clusterer != nil && !route.Skip {
+					clusterer.Add(slot.term, slot.label, c.criterion)
+				}
+				if format == JSON {
+					line, err := record.JSON()
+					if err != nil {
+						return err
+					}
+					if _, err := fmt.Fprintln(writer, line); err != nil {
+						return err
+					}
+				} else if !hasMatch {
+					if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", nctID, c.eligibilityType, c.criterion, slot.String()); err != nil {
+						return err
+					}
 				}
 			}
 		}
+		return nil
+	}
+
+	glog.Infof("Matching NER terms ...")
+
+	// Criteria of a study are assumed to be on consecutive lines.
+	nctID := ""
+	study := make([]criterionSlots, 0)
+	for scanner.Scan() {
+		lineCnt++
+		line := scanner.Text()
+		if len(line) == 0 || line[0] == param.Comment {
+			continue
+		}
+
+		// Extract NER terms
+		values := strings.Split(line, "\t")
+		if values[0] != nctID {
+			if err := matchStudy(nctID, study); err != nil {
+				return err
+			}
+			nctID = values[0]
+			study = study[:0]
+		}
+		eligibilityType := values[1]
+		criterion := values[2]
+		termStr := values[3]
+		slots := getNERSlots(termStr, m.routes)
+		slotCnt += slots.Size()
+		study = append(study, criterionSlots{eligibilityType: eligibilityType, criterion: criterion, slots: slots})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := matchStudy(nctID, study); err != nil {
+		return err
 	}
 
 	glog.Infof("Lines read: %d, Slots: %d, Unique slots: %d\n", lineCnt, slotCnt, len(matchedSlots))
 	glog.Infof("%d slots matched to %d concepts\n", matchedSlotCnt, conceptSet.Size())
-	glog.Infof("%d slots not matched\n", slotCnt-matchedSlotCnt)
+	glog.Infof("%d slots not matched, %d slots skipped\n", slotCnt-matchedSlotCnt-skippedSlotCnt, skippedSlotCnt)
 
+	if clusterer != nil {
+		return m.writeClusters(clusterer)
+	}
+	return nil
+}
+
+// writeClusters clusters the unmatched terms and writes the clusters to the cluster file.
+func (m *Matcher) writeClusters(clusterer *cluster.Clusterer) error {
+	glog.Infof("Clustering %d unmatched terms ...", clusterer.Size())
+	clusters := clusterer.Cluster()
+
+	writer := fio.Writer(m.parameters.Get("cluster_file"))
+	defer writer.Close()
+	if err := clusters.Write(writer); err != nil {
+		return err
+	}
+	glog.Infof("%d clusters written to %s\n", clusters.Len(), m.parameters.Get("cluster_file"))
 	return nil
 }
 
@@ -0,0 +1,163 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package nel
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/taxonomy"
+)
+
+// OutputFormat defines the format of the NEL output file.
+type OutputFormat int
+
+const (
+	// TSV writes a tab-separated row per matched sub-term or unmatched slot.
+	TSV OutputFormat = iota
+	// JSON writes a json record per slot (JSON lines).
+	JSON
+)
+
+// ParseOutputFormat converts the string to the output format.
+// The default format is TSV.
+func ParseOutputFormat(s string) OutputFormat {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "json", "jsonl":
+		return JSON
+	default:
+		return TSV
+	}
+}
+
+// String returns the corresponding string representation of the output format.
+func (f OutputFormat) String() string {
+	switch f {
+	case JSON:
+		return "json"
+	default:
+		return "tsv"
+	}
+}
+
+// Concept defines a candidate vocabulary concept linked to a slot.
+type Concept struct {
+	Name         string   `json:"name"`
+	DescriptorUI string   `json:"descriptor_ui,omitempty"`
+	TreeNumbers  []string `json:"tree_numbers"`
+	Categories   []string `json:"categories"`
+	Score        float64  `json:"nel_score"`
+	Selected     bool     `json:"selected,omitempty"` // Selected by disambiguation
+	Reason       string   `json:"reason,omitempty"`   // Reason for the selection
+}
+
+// Concepts defines a slice of concepts.
+type Concepts []Concept
+
+// NewConcepts converts the ranked taxonomy terms to concepts. The first
+// concept is marked as selected for the given reason.
+func NewConcepts(ts taxonomy.Terms, reason string) Concepts {
+	cs := make(Concepts, 0, ts.Len())
+	for _, t := range ts {
+		cs = append(cs, Concept{
+			Name:         t.Key,
+			DescriptorUI: t.ID,
+			TreeNumbers:  t.TreeNumbers.Slice(),
+			Categories:   t.Categories.Slice(),
+			Score:        t.Value,
+		})
+	}
+	if len(cs) > 0 {
+		cs[0].Selected = true
+		cs[0].Reason = reason
+	}
+	return cs
+}
+
+// Record defines the structured NEL output of one NER slot.
+type Record struct {
+	NCTID           string   `json:"nct_id"`
+	EligibilityType string   `json:"eligibility_type"`
+	Criterion       string   `json:"criterion"`
+	Label           string   `json:"label"`
+	Term            string   `json:"term"`            // Term as extracted by NER
+	NormalizedTerm  string   `json:"normalized_term"` // Term after normalization
+	NERScore        float64  `json:"ner_score"`
+	Span            []int    `json:"span,omitempty"` // Start and end byte positions of the term in the criterion
+	Concepts        Concepts `json:"concepts"`       // Candidate concepts, selected concepts first
+}
+
+// NewRecord creates a record for the slot extracted from the criterion.
+func NewRecord(nctID, eligibilityType, criterion string, slot Slot) *Record {
+	return &Record{
+		NCTID:           nctID,
+		EligibilityType: eligibilityType,
+		Criterion:       criterion,
+		Label:           slot.label,
+		Term:            slot.original,
+		NormalizedTerm:  slot.term,
+		NERScore:        slot.score,
+		Span:            span(criterion, slot.original),
+		Concepts:        Concepts{},
+	}
+}
+
+// AddConcepts adds the concepts to the record keeping the selected concepts
+// first and the rest ranked by score.
+func (r *Record) AddConcepts(cs Concepts) {
+	r.Concepts = append(r.Concepts, cs...)
+	sortConcepts(r.Concepts)
+}
+
+// JSON converts the record to the json string.
+func (r *Record) JSON() (string, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// span returns the byte positions of the term in the criterion. The search
+// is case insensitive. If the term is not found, nil is returned.
+func span(criterion, term string) []int {
+	if len(term) == 0 {
+		return nil
+	}
+	i := strings.Index(fold(criterion), fold(term))
+	if i < 0 {
+		return nil
+	}
+	return []int{i, i + len(term)}
+}
+
+// fold lowercases the characters of s whose lowercase form has the same byte
+// length, so that byte positions in the folded string are valid in s.
+func fold(s string) string {
+	b := []byte(s)
+	for i := 0; i < len(b); {
+		r, n := utf8.DecodeRune(b[i:])
+		if l := unicode.ToLower(r); l != r && r != utf8.RuneError && utf8.RuneLen(l) == n {
+			utf8.EncodeRune(b[i:], l)
+		}
+		i += n
+	}
+	return string(b)
+}
+
+// sortConcepts sorts selected concepts first and then concepts by score in reverse order.
+// Ties are broken by name.
+func sortConcepts(cs Concepts) {
+	sort.SliceStable(cs, func(i, j int) bool {
+		if cs[i].Selected != cs[j].Selected {
+			return cs[i].Selected
+		}
+		if cs[i].Score != cs[j].Score {
+			return cs[i].Score > cs[j].Score
+		}
+		return cs[i].Name < cs[j].Name
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package nel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpan(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		criterion string
+		term      string
+		expected  []int
+	}{
+		{"History of Diabetes Mellitus", "diabetes mellitus", []int{11, 28}},
+		{"Maladie de CROHN active", "crohn", []int{11, 16}},
+		{"Ärzte: İnfektion der Lunge", "lunge", []int{23, 28}},
+		{"Ärzte: İnfektion der Lunge", "infektion", nil},
+		{"ÄRZTE und Pflege", "ärzte", []int{0, 6}},
+		{"HbA1c > 7.5% (x+y)", "(x+y)", []int{13, 18}},
+		{"Fever \xff COVID", "covid", []int{8, 13}},
+		{"hypertension", "diabetes", nil},
+		{"hypertension", "", nil},
+	}
+	for _, test := range tests {
+		actual := span(test.criterion, test.term)
+		a.Equal(test.expected, actual, test.term)
+		if actual != nil {
+			a.Len(test.criterion[actual[0]:actual[1]], len(test.term), test.term)
+		}
+	}
+}
+
+func TestNewRecord(t *testing.T) {
+	a := assert.New(t)
+
+	slot := Slot{label: "chronic_disease", term: "type 2 diabetes mellitus", original: "T2DM", score: 0.9}
+	r := NewRecord("NCT00000001", "inclusion", "History of T2DM", slot)
+	a.Equal("NCT00000001", r.NCTID)
+	a.Equal("inclusion", r.EligibilityType)
+	a.Equal("chronic_disease", r.Label)
+	a.Equal("T2DM", r.Term)
+	a.Equal("type 2 diabetes mellitus", r.NormalizedTerm)
+	a.Equal(0.9, r.NERScore)
+	a.Equal([]int{11, 15}, r.Span)
+	a.Empty(r.Concepts)
+
+	s, err := r.JSON()
+	a.NoError(err)
+	a.Contains(s, `"span":[11,15]`)
+	a.Contains(s, `"concepts":[]`)
+
+	r = NewRecord("NCT00000001", "exclusion", "Pregnancy", NewSlot("condition", "lactation", 0.8))
+	a.Nil(r.Span)
+	s, err = r.JSON()
+	a.NoError(err)
+	a.NotContains(s, `"span"`)
+}
+
+func TestSortConcepts(t *testing.T) {
+	a := assert.New(t)
+
+	cs := Concepts{
+		{Name: "b", Score: 0.5},
+		{Name: "c", Score: 0.9},
+		{Name: "a", Score: 0.5},
+		{Name: "d", Score: 0.1, Selected: true},
+	}
+	sortConcepts(cs)
+	var actual []string
+	for _, c := range cs {
+		actual = append(actual, c.Name)
+	}
+	a.Equal([]string{"d", "c", "a", "b"}, actual)
+
+	r := &Record{Concepts: Concepts{{Name: "x", Score: 0.2}}}
+	r.AddConcepts(Concepts{{Name: "y", Score: 0.8}, {Name: "z", Score: 0.1, Selected: true}})
+	actual = nil
+	for _, c := range r.Concepts {
+		actual = append(actual, c.Name)
+	}
+	a.Equal([]string{"z", "y", "x"}, actual)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package nel
+
+import (
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/conf"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/param"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/slice"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+)
+
+// DefaultVocabulary is the name of the vocabulary defined by the unprefixed vocabulary parameters.
+const DefaultVocabulary = "default"
+
+// Route defines how the terms of an NER label are linked to vocabulary concepts.
+// Route parameters are read from the config with the label as a prefix, e.g.,
+// 'word_scores:cancer.categories = C'. Parameters that are not defined for
+// the label default to the global parameters.
+type Route struct {
+	Label          string
+	Skip           bool     // Terms are not linked
+	Vocabularies   []string // Names of the vocabularies to match terms to
+	Categories     set.Set  // Allowed vocabulary categories
+	TreeNumbers    []string // Allowed tree number prefixes
+	NERThreshold   float64
+	MatchThreshold float64
+	MatchMargin    float64
+}
+
+// Routes maps NER labels to routes.
+type Routes map[string]*Route
+
+// LoadRoutes loads the routes of the valid labels from the config.
+func LoadRoutes(parameters conf.Config) Routes {
+	nerThreshold := parameters.GetFloat64("ner_threshold")
+	matchThreshold := parameters.GetFloat64("match_threshold")
+	matchMargin := parameters.GetFloat64("match_margin")
+
+	routes := make(Routes)
+	for _, label := range parameters.GetSlice("valid_labels", ",") {
+		r := &Route{
+			Label:          label,
+			Vocabularies:   []string{DefaultVocabulary},
+			Categories:     set.New(),
+			NERThreshold:   nerThreshold,
+			MatchThreshold: matchThreshold,
+			MatchMargin:    matchMargin,
+		}
+		key := func(name string) string { return label + "." + name }
+		if parameters.Exists(key("skip")) {
+			r.Skip = parameters.GetBool(key("skip"))
+		}
+		if parameters.Exists(key("vocabulary")) {
+			r.Vocabularies = splitField(parameters.Get(key("vocabulary")))
+		}
+		if parameters.Exists(key("categories")) {
+			r.Categories.Add(splitField(parameters.Get(key("categories")))...)
+		}
+		if parameters.Exists(key("tree_numbers")) {
+			r.TreeNumbers = splitField(parameters.Get(key("tree_numbers")))
+			// Restrict the candidates to the categories of the tree numbers:
+			for _, tn := range r.TreeNumbers {
+				r.Categories.Add(text.LetterPrefix(tn))
+			}
+		}
+		if parameters.Exists(key("ner_threshold")) {
+			r.NERThreshold = parameters.GetFloat64(key("ner_threshold"))
+		}
+		if parameters.Exists(key("match_threshold")) {
+			r.MatchThreshold = parameters.GetFloat64(key("match_threshold"))
+		}
+		if parameters.Exists(key("match_margin")) {
+			r.MatchMargin = parameters.GetFloat64(key("match_margin"))
+		}
+		routes[label] = r
+	}
+	return routes
+}
+
+// Get returns the route of the label. Labels without a route are not valid.
+func (rs Routes) Get(label string) (*Route, bool) {
+	r, ok := rs[label]
+	return r, ok
+}
+
+// Vocabularies returns the names of the vocabularies used by the routes.
+func (rs Routes) Vocabularies() []string {
+	names := set.New()
+	for _, r := range rs {
+		if !r.Skip {
+			names.Add(r.Vocabularies...)
+		}
+	}
+	return names.Slice()
+}
+
+// splitField splits a multi-valued parameter.
+func splitField(s string) []string {
+	v := strings.Split(s, param.FieldSep)
+	slice.TrimSpace(v)
+	return slice.RemoveEmpty(v)
+}
@@ -0,0 +1,203 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/conf"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/timer"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/ner"
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies"
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/mesh"
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/umls"
+
+	"github.com/golang/glog"
+)
+
+// main trains a NER model or tags extracted criteria with a trained model.
+// Tagged criteria are written in the same format as the PyText NER model
+// writes them (src/ie/ner.py), so that the output can be linked with nel.
+func main() {
+	t := NewTagger()
+	if err := t.LoadParameters(); err != nil {
+		glog.Fatal(err)
+	}
+	if t.train {
+		if err := t.Train(); err != nil {
+			glog.Fatal(err)
+		}
+		return
+	}
+	if err := t.Tag(); err != nil {
+		glog.Fatal(err)
+	}
+}
+
+// Tagger trains and applies the NER model.
+type Tagger struct {
+	parameters conf.Config
+	train      bool
+}
+
+// NewTagger creates a new tagger.
+func NewTagger() *Tagger {
+	return &Tagger{}
+}
+
+// LoadParameters loads parameters from command line and a config file.
+func (t *Tagger) LoadParameters() error {
+	configFname := flag.String("conf", "", "Config file")
+	train := flag.Bool("train", false, "Train a model")
+	modelFname := flag.String("m", "", "Model file")
+	inputFname := flag.String("i", "", "Input file")
+	outputFname := flag.String("o", "", "Output file")
+	epochs := flag.Int("epochs", 0, "Number of training epochs")
+
+	flag.Parse()
+	if len(*configFname) == 0 {
+		return fmt.Errorf("usage: %s -conf <config file> [-train] [-m <model file>] [-i <input file> -o <output file>] [-epochs <n>]", os.Args[0])
+	}
+
+	parameters, err := conf.Load(*configFname)
+	if err != nil {
+		return err
+	}
+
+	if len(*modelFname) > 0 {
+		parameters.Put("model_file", *modelFname)
+	}
+	if len(*inputFname) > 0 {
+		parameters.Put("input_file", *inputFname)
+	}
+	if len(*outputFname) > 0 {
+		parameters.Put("output_file", *outputFname)
+	}
+	if *epochs > 0 {
+		parameters.Put("epochs", fmt.Sprint(*epochs))
+	}
+	if !parameters.Exists("model_file") {
+		return fmt.Errorf("model file not defined")
+	}
+	if *train {
+		if !parameters.Exists("train_file") {
+			return fmt.Errorf("train file not defined")
+		}
+	} else {
+		if !parameters.Exists("input_file") {
+			return fmt.Errorf("input file not defined")
+		}
+		if !parameters.Exists("output_file") {
+			return fmt.Errorf("output file not defined")
+		}
+	}
+
+	t.parameters = parameters
+	t.train = *train
+
+	return nil
+}
+
+// Train trains a model on the training data and saves it to the model file.
+// The gazetteer is built from the training entities and the vocabulary, if one is defined.
+func (t *Tagger) Train() error {
+	tmr := timer.New()
+	sentences, err := ner.LoadSentences(t.parameters.Get("train_file"))
+	if err != nil {
+		return err
+	}
+	glog.Infof("Training sentences: %d\n", len(sentences))
+
+	g := ner.NewGazetteer()
+	g.AddSentences(sentences)
+	if t.parameters.Exists("vocabulary_file") && t.parameters.Exists("gazetteer_labels") {
+		rules := ner.ParseLabelRules(t.parameters.Get("gazetteer_labels"))
+		switch vocabularies.ParseSource(t.parameters.Get("vocabulary_source")) {
+		case vocabularies.MESH:
+			g.AddTaxonomy(mesh.Load(t.parameters.Get("vocabulary_file")), rules)
+		case vocabularies.UMLS:
+			g.AddTaxonomy(umls.Load(t.parameters.Get("vocabulary_file")), rules)
+		default:
+			return fmt.Errorf("unknown vocabulary source: %s", t.parameters.Get("vocabulary_source"))
+		}
+	}
+	glog.Infof("Gazetteer phrases: %d\n", g.Size())
+
+	m := ner.NewModel(g)
+	m.Train(sentences, t.parameters.GetInt("epochs"), int64(t.parameters.GetInt("seed")))
+	glog.Infof("Features: %d\n", len(m.Weights))
+
+	if t.parameters.Exists("test_file") {
+		test, err := ner.LoadSentences(t.parameters.Get("test_file"))
+		if err != nil {
+			return err
+		}
+		e := m.Evaluate(test)
+		glog.Infof("Test sentences: %d, precision: %.3f, recall: %.3f, F1: %.3f\n", len(test), e.Precision(), e.Recall(), e.F1())
+	}
+
+	if err := m.Save(t.parameters.Get("model_file")); err != nil {
+		return err
+	}
+	glog.Infof("Training time: %v\n", tmr.Elapsed())
+	return nil
+}
+
+// Tag tags the criteria of the input file and writes the detected slots to the output file.
+func (t *Tagger) Tag() error {
+	tmr := timer.New()
+	m, err := ner.LoadModel(t.parameters.Get("model_file"))
+	if err != nil {
+		return err
+	}
+	tagger := ner.NewTagger(m)
+
+	inputFname := t.parameters.Get("input_file")
+	input, err := os.Open(inputFname)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	output, err := os.Create(t.parameters.Get("output_file"))
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	writer := bufio.NewWriter(output)
+	defer writer.Flush()
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	lineCnt := 0
+	criteriaCnt := 0
+	for scanner.Scan() {
+		lineCnt++
+		line := strings.TrimSpace(scanner.Text())
+		if lineCnt == 1 {
+			fmt.Fprintf(writer, "%s\tdetected_slots\n", line)
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			glog.Errorf("%s: bad row: line %d\n", inputFname, lineCnt)
+			continue
+		}
+		data, err := json.Marshal(tagger.Tag(fields[2]))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", strings.Join(fields, "\t"), data)
+		criteriaCnt++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	glog.Infof("Tagged criteria: %d, time: %v\n", criteriaCnt, tmr.Elapsed())
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package promote
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/cluster"
+
+	"github.com/golang/glog"
+)
+
+// main promotes reviewed clusters of unmatched NER terms into a custom
+// vocabulary file. Cluster rows are appended as concept-synonym rows
+// that are not already in the vocabulary file.
+func main() {
+	inputFname := flag.String("i", "", "Cluster file")
+	outputFname := flag.String("o", "", "Custom vocabulary file")
+	minCount := flag.Int("min", 2, "Minimum cluster count")
+
+	flag.Parse()
+	if len(*inputFname) == 0 || len(*outputFname) == 0 {
+		glog.Fatalf("usage: %s -i <cluster file> -o <custom vocabulary file> [-min <count>]", os.Args[0])
+	}
+
+	clusters, err := cluster.Load(*inputFname)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	cnt, err := Promote(clusters, *outputFname, *minCount)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	glog.Infof("%d clusters read, %d vocabulary rows added to %s\n", clusters.Len(), cnt, *outputFname)
+	glog.Flush()
+}
+
+// Promote appends the clusters whose count is at least minCount to the custom
+// vocabulary file. Rows that already exist in the file are skipped, and the last
+// row of the file is terminated by a newline if it is not already.
+func Promote(clusters cluster.Clusters, fname string, minCount int) (int, error) {
+	existing, err := loadRows(fname)
+	if err != nil {
+		return 0, err
+	}
+	terminated, err := endsWithNewline(fname)
+	if err != nil {
+		return 0, err
+	}
+
+	var b bytes.Buffer
+	if _, err := clusters.WriteVocabulary(&b, minCount); err != nil {
+		return 0, err
+	}
+
+	file, err := os.OpenFile(fname, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	if !terminated {
+		if _, err := fmt.Fprintln(file); err != nil {
+			return 0, err
+		}
+	}
+
+	cnt := 0
+	for _, row := range strings.Split(b.String(), "\n") {
+		if len(row) == 0 || existing.Contains(key(row)) {
+			continue
+		}
+		if _, err := fmt.Fprintln(file, row); err != nil {
+			return cnt, err
+		}
+		existing.Add(key(row))
+		cnt++
+	}
+	return cnt, nil
+}
+
+// loadRows loads the concept-synonym keys of the vocabulary file.
+func loadRows(fname string) (set.Set, error) {
+	rows := set.New()
+	file, err := os.Open(fname)
+	if os.IsNotExist(err) {
+		return rows, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); len(line) > 0 {
+			rows.Add(key(line))
+		}
+	}
+	return rows, scanner.Err()
+}
+
+// endsWithNewline tests whether the vocabulary file is empty, missing, or ends
+// in a newline, so that appended rows are not glued onto its last row.
+func endsWithNewline(fname string) (bool, error) {
+	file, err := os.Open(fname)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() == 0 {
+		return true, nil
+	}
+	b := make([]byte, 1)
+	if _, err := file.ReadAt(b, info.Size()-1); err != nil {
+		return false, err
+	}
+	return b[0] == '\n', nil
+}
+
+// key returns the lowercase concept and synonym of a vocabulary row.
+func key(row string) string {
+	values := strings.Split(row, "\t")
+	if len(values) > 2 {
+		values = values[:2]
+	}
+	return strings.ToLower(strings.Join(values, "\t"))
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package promote
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/cluster"
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/taxonomy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromote(t *testing.T) {
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "promote")
+	if !a.NoError(err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	clusters := cluster.Clusters{
+		{Concept: "COVID-19", TreeNumbers: []string{"C01.925"}, Count: 3, Terms: []string{"covid-19 infection", "Diabetes"}},
+		{Concept: "Rare", Count: 1, Terms: []string{"rare disease"}},
+	}
+
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"unterminated", "Diabetes\tdiabetes mellitus\nCOVID-19\tdiabetes"},
+		{"terminated", "Diabetes\tdiabetes mellitus\nCOVID-19\tdiabetes\n"},
+		{"empty", ""},
+	}
+	for _, test := range tests {
+		fname := filepath.Join(dir, test.name+".txt")
+		if !a.NoError(ioutil.WriteFile(fname, []byte(test.contents), 0666), test.name) {
+			continue
+		}
+		cnt, err := Promote(clusters, fname, 2)
+		a.NoError(err, test.name)
+		expected := 2
+		if len(test.contents) > 0 {
+			expected = 1
+		}
+		a.Equal(expected, cnt, test.name)
+
+		nodes := taxonomy.LoadNodes(fname)
+		synonyms := make(map[string]set.Set)
+		for _, n := range nodes {
+			synonyms[n.Name()] = n.Synonyms()
+		}
+		if a.Contains(synonyms, "COVID-19", test.name) {
+			a.True(synonyms["COVID-19"].Contains("covid-19 infection"), test.name)
+		}
+		a.NotContains(synonyms, "Rare", test.name)
+		if len(test.contents) > 0 {
+			a.Len(nodes, 2, test.name)
+			if a.Contains(synonyms, "Diabetes", test.name) {
+				a.True(synonyms["Diabetes"].Contains("diabetes mellitus"), test.name)
+			}
+		}
+
+		cnt, err = Promote(clusters, fname, 2)
+		a.NoError(err, test.name)
+		a.Equal(0, cnt, test.name)
+	}
+}
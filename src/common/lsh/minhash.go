@@ -62,6 +62,21 @@ func (h MinHash) Similarity(s1 string, s2 string) float64 {
 	return shingles1.Jaccard(shingles2)
 }
 
+// Shingles returns the shingle set of string s. Callers that compare
+// the same strings repeatedly can precompute the shingles once and
+// use ShingleSimilarity instead of Similarity.
+func (h MinHash) Shingles(s string) set.Set {
+	return h.generateShingles(s)
+}
+
+// ShingleSimilarity computes the Jaccard similarity between two precomputed shingle sets.
+func (h MinHash) ShingleSimilarity(shingles1, shingles2 set.Set) float64 {
+	if shingles1.Empty() || shingles2.Empty() {
+		return 0
+	}
+	return shingles1.Jaccard(shingles2)
+}
+
 // probability converts a Jaccard similarity score to probability.
 func (h MinHash) probability(score float64) float64 {
 	return 1.0 - math.Pow(1.0-math.Pow(score, float64(h.Rows)), float64(h.Bands))
@@ -34,3 +34,13 @@ func TestDifference(t *testing.T) {
 	s2 := "gastrointestinal disorders"
 	a.False(minHash.IsSimilar(s1, s2))
 }
+
+func TestShingleSimilarity(t *testing.T) {
+	a := assert.New(t)
+	s1 := "autism spectrum disorder"
+	s2 := "autism spectrum"
+	expected := minHash.Similarity(s1, s2)
+	actual := minHash.ShingleSimilarity(minHash.Shingles(s1), minHash.Shingles(s2))
+	a.Equal(expected, actual)
+	a.Zero(minHash.ShingleSimilarity(minHash.Shingles(""), minHash.Shingles(s2)))
+}
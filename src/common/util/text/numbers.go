@@ -0,0 +1,218 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package text
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberClass defines the class of number words, which determines the words that may follow.
+type numberClass int
+
+const (
+	unitNumber  numberClass = iota // zero to nine
+	teenNumber                     // ten to nineteen
+	tensNumber                     // twenty, thirty, ..., ninety
+	scaleNumber                    // hundred, thousand, million
+)
+
+// numberWord defines the value and the class of a cardinal or ordinal number word.
+type numberWord struct {
+	value   int
+	class   numberClass
+	ordinal bool
+}
+
+var numberWords = map[string]numberWord{
+	"zero":        {0, unitNumber, false},
+	"one":         {1, unitNumber, false},
+	"two":         {2, unitNumber, false},
+	"three":       {3, unitNumber, false},
+	"four":        {4, unitNumber, false},
+	"five":        {5, unitNumber, false},
+	"six":         {6, unitNumber, false},
+	"seven":       {7, unitNumber, false},
+	"eight":       {8, unitNumber, false},
+	"nine":        {9, unitNumber, false},
+	"ten":         {10, teenNumber, false},
+	"eleven":      {11, teenNumber, false},
+	"twelve":      {12, teenNumber, false},
+	"thirteen":    {13, teenNumber, false},
+	"fourteen":    {14, teenNumber, false},
+	"fifteen":     {15, teenNumber, false},
+	"sixteen":     {16, teenNumber, false},
+	"seventeen":   {17, teenNumber, false},
+	"eighteen":    {18, teenNumber, false},
+	"nineteen":    {19, teenNumber, false},
+	"twenty":      {20, tensNumber, false},
+	"thirty":      {30, tensNumber, false},
+	"forty":       {40, tensNumber, false},
+	"fifty":       {50, tensNumber, false},
+	"sixty":       {60, tensNumber, false},
+	"seventy":     {70, tensNumber, false},
+	"eighty":      {80, tensNumber, false},
+	"ninety":      {90, tensNumber, false},
+	"hundred":     {100, scaleNumber, false},
+	"thousand":    {1000, scaleNumber, false},
+	"million":     {1000000, scaleNumber, false},
+	"first":       {1, unitNumber, true},
+	"second":      {2, unitNumber, true},
+	"third":       {3, unitNumber, true},
+	"fourth":      {4, unitNumber, true},
+	"fifth":       {5, unitNumber, true},
+	"sixth":       {6, unitNumber, true},
+	"seventh":     {7, unitNumber, true},
+	"eighth":      {8, unitNumber, true},
+	"ninth":       {9, unitNumber, true},
+	"tenth":       {10, teenNumber, true},
+	"eleventh":    {11, teenNumber, true},
+	"twelfth":     {12, teenNumber, true},
+	"thirteenth":  {13, teenNumber, true},
+	"fourteenth":  {14, teenNumber, true},
+	"fifteenth":   {15, teenNumber, true},
+	"sixteenth":   {16, teenNumber, true},
+	"seventeenth": {17, teenNumber, true},
+	"eighteenth":  {18, teenNumber, true},
+	"nineteenth":  {19, teenNumber, true},
+	"twentieth":   {20, tensNumber, true},
+	"thirtieth":   {30, tensNumber, true},
+	"fortieth":    {40, tensNumber, true},
+	"fiftieth":    {50, tensNumber, true},
+	"sixtieth":    {60, tensNumber, true},
+	"seventieth":  {70, tensNumber, true},
+	"eightieth":   {80, tensNumber, true},
+	"ninetieth":   {90, tensNumber, true},
+	"hundredth":   {100, scaleNumber, true},
+	"thousandth":  {1000, scaleNumber, true},
+}
+
+// multiplicatives maps the multiplicative words to their values.
+var multiplicatives = map[string]int{
+	"once":   1,
+	"twice":  2,
+	"thrice": 3,
+}
+
+// denominators maps the fraction words to their denominators.
+var denominators = map[string]int{
+	"half":     2,
+	"halves":   2,
+	"third":    3,
+	"thirds":   3,
+	"quarter":  4,
+	"quarters": 4,
+	"fourth":   4,
+	"fourths":  4,
+	"fifth":    5,
+	"fifths":   5,
+	"sixth":    6,
+	"sixths":   6,
+	"seventh":  7,
+	"sevenths": 7,
+	"eighth":   8,
+	"eighths":  8,
+	"ninth":    9,
+	"ninths":   9,
+	"tenth":    10,
+	"tenths":   10,
+}
+
+var reDigitOrdinal = regexp.MustCompile(`^(\d+)(?:st|nd|rd|th)$`)
+
+// ParseNumberWords converts a spelled-out number to its numeral: cardinal numbers
+// ('twenty-five', 'one hundred'), ordinal numbers ('third', '2nd'), multiplicatives
+// ('twice', 'threefold'), and fractions ('half', 'two-thirds'). Fractions are converted
+// to decimals with at most three decimals. Words are separated by spaces or hyphens.
+// If s is not a number, false is returned.
+func ParseNumberWords(s string) (string, bool) {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '-'
+	})
+	switch len(words) {
+	case 0:
+		return "", false
+	case 1:
+		if m := reDigitOrdinal.FindStringSubmatch(words[0]); m != nil {
+			return m[1], true
+		}
+	}
+
+	// Multiplicative suffix, e.g., 'three-fold' and 'threefold':
+	last := words[len(words)-1]
+	switch {
+	case last == "fold" && len(words) > 1:
+		words = words[:len(words)-1]
+	case strings.HasSuffix(last, "fold") && len(last) > len("fold"):
+		words[len(words)-1] = strings.TrimSuffix(last, "fold")
+	}
+
+	if len(words) == 1 {
+		if v, ok := multiplicatives[words[0]]; ok {
+			return strconv.Itoa(v), true
+		}
+		if words[0] == "half" {
+			return "0.5", true
+		}
+	}
+
+	// Fraction with a one-word numerator, e.g., 'two thirds':
+	if d, ok := denominators[words[len(words)-1]]; ok && len(words) == 2 {
+		if w, ok := numberWords[words[0]]; ok && w.class == unitNumber && !w.ordinal && w.value > 0 {
+			v := strconv.FormatFloat(float64(w.value)/float64(d), 'f', 3, 64)
+			return strings.TrimRight(strings.TrimRight(v, "0"), "."), true
+		}
+	}
+
+	if v, ok := parseCardinal(words); ok {
+		return strconv.Itoa(v), true
+	}
+	return "", false
+}
+
+// parseCardinal parses the value of the cardinal or ordinal number words.
+// Only the last word may be ordinal.
+func parseCardinal(words []string) (int, bool) {
+	total, current := 0, 0
+	var previous *numberWord
+	for i, s := range words {
+		w, ok := numberWords[s]
+		if !ok || (w.ordinal && i < len(words)-1) {
+			return 0, false
+		}
+		if previous == nil {
+			if w.class == scaleNumber {
+				return 0, false
+			}
+		} else {
+			switch {
+			case previous.value == 0:
+				return 0, false
+			case previous.class == tensNumber:
+				if w.class != scaleNumber && (w.class != unitNumber || w.value == 0) {
+					return 0, false
+				}
+			case previous.class == scaleNumber:
+				if w.class == scaleNumber && (w.value <= previous.value || current == 0) {
+					return 0, false
+				}
+			default:
+				if w.class != scaleNumber {
+					return 0, false
+				}
+			}
+		}
+		switch {
+		case w.class != scaleNumber:
+			current += w.value
+		case w.value == 100:
+			current *= 100
+		default:
+			total += current * w.value
+			current = 0
+		}
+		previous = &w
+	}
+	return total + current, true
+}
@@ -0,0 +1,89 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNumberWords(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// Cardinals:
+		{"zero", "0"},
+		{"one", "1"},
+		{"eighteen", "18"},
+		{"twenty", "20"},
+		{"sixty-five", "65"},
+		{"sixty five", "65"},
+		{"one hundred", "100"},
+		{"one hundred twenty", "120"},
+		{"two thousand five hundred", "2500"},
+		{"one million", "1000000"},
+
+		// Ordinals:
+		{"first", "1"},
+		{"third", "3"},
+		{"twenty first", "21"},
+		{"twenty-first", "21"},
+		{"one hundredth", "100"},
+		{"1st", "1"},
+		{"2nd", "2"},
+		{"23rd", "23"},
+		{"4th", "4"},
+
+		// Multiplicatives:
+		{"once", "1"},
+		{"twice", "2"},
+		{"thrice", "3"},
+		{"threefold", "3"},
+		{"three-fold", "3"},
+		{"ten fold", "10"},
+
+		// Fractions:
+		{"half", "0.5"},
+		{"one half", "0.5"},
+		{"one third", "0.333"},
+		{"two-thirds", "0.667"},
+		{"three quarters", "0.75"},
+	}
+	for _, test := range tests {
+		actual, ok := ParseNumberWords(test.input)
+		a.True(ok, test.input)
+		a.Equal(test.expected, actual, test.input)
+	}
+}
+
+func TestParseNotNumberWords(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []string{
+		"",
+		"-",
+		"hundred",
+		"one two",
+		"twenty twenty",
+		"ten one",
+		"zero one",
+		"first one",
+		"thousand hundred",
+		"one thousand thousand",
+		"twenty zero",
+		"zero thirds",
+		"first thirds",
+		"fold",
+		"years",
+		"1st2",
+		"second half",
+	}
+	for _, input := range tests {
+		_, ok := ParseNumberWords(input)
+		a.False(ok, input)
+	}
+}
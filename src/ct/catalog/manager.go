@@ -0,0 +1,194 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+// Package catalog manages the variable and unit catalogs of a long-running process.
+// The catalog files are watched for changes, and new versions are validated before
+// they are activated.
+package catalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/lint"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/golang/glog"
+)
+
+// Snapshot defines a consistent pair of variable and unit catalogs and their version.
+type Snapshot struct {
+	Variables *variables.Variables
+	Units     *units.Units
+	Version   string
+}
+
+// Manager loads the variable and unit catalogs, activates them, and reloads them when
+// the catalog files change.
+type Manager struct {
+	variableFname string
+	unitFname     string
+	snapshot      atomic.Value // *Snapshot
+	stamps        [2]stamp     // stamps of the variable and unit files of the active snapshot
+	mu            sync.Mutex   // serializes reloads
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+// stamp identifies a version of a file by its size and modification time.
+type stamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// NewManager creates a new manager of the variable and unit catalog files.
+func NewManager(variableFname, unitFname string) *Manager {
+	return &Manager{variableFname: variableFname, unitFname: unitFname}
+}
+
+// Load loads, validates, and activates the catalogs.
+func (m *Manager) Load() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.load()
+}
+
+// Reload loads, validates, and activates the catalogs if a catalog file has changed
+// since the active catalogs were loaded. It returns true if new catalogs were activated.
+// If the new catalogs fail validation, the active catalogs are kept.
+func (m *Manager) Reload() (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stamps, err := m.stat()
+	if err != nil {
+		return false, err
+	}
+	if stamps == m.stamps {
+		return false, nil
+	}
+	if err := m.load(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// load loads the catalog files and activates them if they are valid.
+func (m *Manager) load() error {
+	stamps, err := m.stat()
+	if err != nil {
+		return err
+	}
+	vs, err := variables.Load(m.variableFname)
+	if err != nil {
+		return err
+	}
+	us, err := units.Load(m.unitFname)
+	if err != nil {
+		return err
+	}
+	if issues := lint.References(vs, us); len(issues) > 0 {
+		return fmt.Errorf("invalid catalogs: %d reference issues, first: %s", len(issues), issues[0])
+	}
+	hash, err := m.hash()
+	if err != nil {
+		return err
+	}
+
+	version := hash
+	if len(vs.Version) > 0 {
+		version = vs.Version + "-" + hash
+	}
+	m.activate(&Snapshot{Variables: vs, Units: us, Version: version})
+	m.stamps = stamps
+	glog.Infof("Catalog version %s activated\n", version)
+	return nil
+}
+
+// activate sets the snapshot and the package-level catalogs.
+func (m *Manager) activate(s *Snapshot) {
+	m.snapshot.Store(s)
+	variables.Set(s.Variables)
+	units.Set(s.Units)
+}
+
+// Snapshot returns the active catalogs, or nil if no catalogs have been loaded.
+func (m *Manager) Snapshot() *Snapshot {
+	s, _ := m.snapshot.Load().(*Snapshot)
+	return s
+}
+
+// Version returns the version of the active catalogs, or an empty string if no catalogs have been loaded.
+func (m *Manager) Version() string {
+	if s := m.Snapshot(); s != nil {
+		return s.Version
+	}
+	return ""
+}
+
+// Watch checks the catalog files for changes every interval and reloads them until Stop is called.
+// Reload errors are logged, and the active catalogs are kept.
+func (m *Manager) Watch(interval time.Duration) {
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := m.Reload(); err != nil {
+					glog.Errorf("Catalog reload failed, keeping version %s: %v\n", m.Version(), err)
+				}
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops watching the catalog files.
+func (m *Manager) Stop() {
+	if m.stop != nil {
+		close(m.stop)
+		<-m.done
+		m.stop = nil
+	}
+}
+
+// stat returns the stamps of the catalog files.
+func (m *Manager) stat() ([2]stamp, error) {
+	var stamps [2]stamp
+	for i, fname := range []string{m.variableFname, m.unitFname} {
+		info, err := os.Stat(fname)
+		if err != nil {
+			return stamps, err
+		}
+		stamps[i] = stamp{size: info.Size(), modTime: info.ModTime()}
+	}
+	return stamps, nil
+}
+
+// hash returns the first 12 hex digits of the SHA-256 hash of the catalog files.
+func (m *Manager) hash() (string, error) {
+	h := sha256.New()
+	for _, fname := range []string{m.variableFname, m.unitFname} {
+		f, err := os.Open(fname)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
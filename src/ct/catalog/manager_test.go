@@ -0,0 +1,102 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package catalog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testVariables = "200,numerical,age,Age,age|aged,0.0|120.0,year,How old are you?\n"
+	testUnits     = "306,year,year,years|year,\n"
+)
+
+// write writes the file and moves its modification time forward so that the change is detected.
+func write(t *testing.T, fname, content string, offset time.Duration) {
+	if err := ioutil.WriteFile(fname, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(offset)
+	if err := os.Chtimes(fname, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManager(t *testing.T) {
+	a := assert.New(t)
+	defer variables.Set(variables.DefaultCatalog())
+	defer units.Set(units.DefaultCatalog())
+
+	dir, err := ioutil.TempDir("", "catalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	variableFname := filepath.Join(dir, "variables.csv")
+	unitFname := filepath.Join(dir, "units.csv")
+	write(t, variableFname, testVariables, 0)
+	write(t, unitFname, testUnits, 0)
+
+	m := NewManager(variableFname, unitFname)
+	a.Nil(m.Snapshot())
+	a.NoError(m.Load())
+	s := m.Snapshot()
+	a.Len(m.Version(), 12)
+	a.Equal(1, s.Variables.Size())
+	a.Equal(s.Variables, variables.Get())
+	a.Equal(s.Units, units.Get())
+
+	reloaded, err := m.Reload()
+	a.NoError(err)
+	a.False(reloaded)
+
+	// A variable that references an unknown unit is rejected:
+	write(t, variableFname, testVariables+"201,numerical,height,Height,height,,cm,\n", time.Second)
+	reloaded, err = m.Reload()
+	a.Error(err)
+	a.False(reloaded)
+	a.Equal(s, m.Snapshot())
+
+	write(t, unitFname, testUnits+"501,cm,cm,cm,\n", 2*time.Second)
+	reloaded, err = m.Reload()
+	a.NoError(err)
+	a.True(reloaded)
+	a.NotEqual(s.Version, m.Version())
+	a.Equal(2, m.Snapshot().Variables.Size())
+	a.Equal(m.Snapshot().Units, units.Get())
+}
+
+func TestManagerWatch(t *testing.T) {
+	a := assert.New(t)
+	defer variables.Set(variables.DefaultCatalog())
+	defer units.Set(units.DefaultCatalog())
+
+	dir, err := ioutil.TempDir("", "catalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	variableFname := filepath.Join(dir, "variables.csv")
+	unitFname := filepath.Join(dir, "units.csv")
+	write(t, variableFname, testVariables, 0)
+	write(t, unitFname, testUnits, 0)
+
+	m := NewManager(variableFname, unitFname)
+	a.NoError(m.Load())
+	version := m.Version()
+
+	m.Watch(10 * time.Millisecond)
+	defer m.Stop()
+	write(t, variableFname, testVariables+"202,numerical,weight,Weight,weight,,,\n", time.Second)
+	a.Eventually(func() bool { return m.Version() != version }, time.Second, 10*time.Millisecond)
+	a.Equal(2, variables.Get().Size())
+}
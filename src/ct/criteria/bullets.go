@@ -0,0 +1,400 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+)
+
+// Numbering defines the numbering style of a bullet.
+type Numbering int
+
+const (
+	// NoNumbering is the style of paragraphs without bullet markers
+	NoNumbering Numbering = iota
+	// Dash is the style of '-', '*' and '•' bullets
+	Dash
+	// Arabic is the style of '1.' and '1)' bullets
+	Arabic
+	// LowerAlpha is the style of 'a.' and 'a)' bullets
+	LowerAlpha
+	// UpperAlpha is the style of 'A.' and 'A)' bullets
+	UpperAlpha
+	// LowerRoman is the style of 'i.' and 'i)' bullets
+	LowerRoman
+	// UpperRoman is the style of 'I.' and 'I)' bullets
+	UpperRoman
+)
+
+// String converts the numbering style to a string.
+func (n Numbering) String() string {
+	switch n {
+	case Dash:
+		return "dash"
+	case Arabic:
+		return "arabic"
+	case LowerAlpha:
+		return "lower_alpha"
+	case UpperAlpha:
+		return "upper_alpha"
+	case LowerRoman:
+		return "lower_roman"
+	case UpperRoman:
+		return "upper_roman"
+	default:
+		return "none"
+	}
+}
+
+// Group defines how the sub-bullets of a header combine.
+type Group int
+
+const (
+	// NoGroup is the group of bullets without a quantified header
+	NoGroup Group = iota
+	// AllOf is the group of bullets under an 'all of the following' header (AND)
+	AllOf
+	// AnyOf is the group of bullets under an 'any of the following' header (OR)
+	AnyOf
+)
+
+// ParseGroup converts a string to a group.
+func ParseGroup(s string) Group {
+	switch strings.ToLower(s) {
+	case "all":
+		return AllOf
+	case "any":
+		return AnyOf
+	default:
+		return NoGroup
+	}
+}
+
+// String converts the group to a string.
+func (g Group) String() string {
+	switch g {
+	case AllOf:
+		return "all"
+	case AnyOf:
+		return "any"
+	default:
+		return ""
+	}
+}
+
+var (
+	reDashMarker    = regexp.MustCompile(`^[-*•·–]\s+`)
+	reNumberMarker  = regexp.MustCompile(`^\(?(\d{1,2})[.)]\s+`)
+	reRomanMarker   = regexp.MustCompile(`^\(?([ivx]{1,4}|[IVX]{1,4})[.)]\s+`)
+	reLetterMarker  = regexp.MustCompile(`^\(?([a-zA-Z])[.)]\s+`)
+	reInlineMarker  = regexp.MustCompile(`(?:^|\s)\(?([a-z]|[ivx]{1,4}|\d{1,2})\)\s+`)
+	reFollowing     = regexp.MustCompile(`(?i)\b(?:the\s+)?following\b|\bdefined\s+(?:as|by)\b|\bincluding\b|\bas\s+follows\b`)
+	reAnyOfHeader   = regexp.MustCompile(`(?i)\b(?:any|one|either|at\s+least\s+(?:one|1)|one\s+or\s+more)\s+(?:of\s+)?(?:the\s+)?(?:following|these|below)\b`)
+	reAllOfHeader   = regexp.MustCompile(`(?i)\b(?:all|each|both|every)\s+(?:of\s+)?(?:the\s+)?(?:following|these|below)\b`)
+	romanNumerals   = []string{"i", "ii", "iii", "iv", "v", "vi", "vii", "viii", "ix", "x", "xi", "xii"}
+	indentTabWidth  = 4
+	maxInlineLabels = 26
+)
+
+// Bullet defines a node in the tree of a criteria list.
+type Bullet struct {
+	Text      string    // Bullet text without the marker
+	Numbering Numbering // Numbering style of the marker
+	Label     string    // Marker label, e.g., '1', 'a' or 'ii'
+	Indent    int       // Indentation of the marker
+	Parent    *Bullet
+	Children  Bullets
+}
+
+// Bullets defines a slice of bullets.
+type Bullets []*Bullet
+
+// Depth returns the depth of the bullet in the tree; root bullets have depth 0.
+func (b *Bullet) Depth() int {
+	d := 0
+	for p := b.Parent; p != nil; p = p.Parent {
+		d++
+	}
+	return d
+}
+
+// IsLeaf tests whether the bullet has no sub-bullets.
+func (b *Bullet) IsLeaf() bool {
+	return len(b.Children) == 0
+}
+
+// IsHeader tests whether the bullet introduces a list, e.g., 'any of the following:'.
+func (b *Bullet) IsHeader() bool {
+	return strings.HasSuffix(b.Text, ":") || reFollowing.MatchString(b.Text)
+}
+
+// Group returns how the sub-bullets of the bullet combine.
+func (b *Bullet) Group() Group {
+	switch {
+	case b.IsLeaf():
+		return NoGroup
+	case reAnyOfHeader.MatchString(b.Text):
+		return AnyOf
+	case reAllOfHeader.MatchString(b.Text):
+		return AllOf
+	default:
+		return NoGroup
+	}
+}
+
+// ParseBullets parses a criteria block into a tree of bullets and returns the root bullets.
+// A line that starts with a marker starts a bullet, and so does a line without a marker that
+// follows a blank line; other lines continue the previous bullet. A bullet is a child of
+// the previous bullet if it is indented deeper, or if it has a different numbering style and
+// the previous bullet is a header. A header that ends with a colon and refers to 'the following'
+// adopts the bullets of the same style that follow it, because lists are often flattened.
+// Inline lists, e.g., 'defined as: a) ANC ≥ 1500 b) platelets ≥ 100,000', are split into sub-bullets.
+func ParseBullets(s string) Bullets {
+	var bullets Bullets
+	var cur *Bullet
+	blank := true
+	for _, line := range strings.Split(s, "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			blank = true
+			continue
+		}
+		indent := indentation(line)
+		numbering, label, content := marker(strings.TrimSpace(line))
+		if numbering == NoNumbering && !blank && cur != nil {
+			cur.Text += " " + content
+			continue
+		}
+		if numbering == LowerRoman && cur != nil && len(label) == 1 && continuesAlpha(bullets, indent, label) {
+			numbering = LowerAlpha
+		}
+		cur = &Bullet{Text: content, Numbering: numbering, Label: label, Indent: indent}
+		bullets = append(bullets, cur)
+		blank = false
+	}
+	// Blocks are often trimmed, so the first bullet is indented as its next sibling:
+	if len(bullets) > 0 && bullets[0].Indent == 0 {
+		for _, b := range bullets[1:] {
+			if b.Numbering == bullets[0].Numbering {
+				bullets[0].Indent = b.Indent
+				break
+			}
+		}
+	}
+
+	var roots Bullets
+	var stack Bullets
+	var adopter *Bullet // Header that adopts the following bullets of the same style
+	for _, b := range bullets {
+		b.Text = text.NormalizeWhitespace(b.Text)
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if b.Indent > top.Indent || (b.Indent == top.Indent && b.Numbering != top.Numbering && top.IsHeader()) {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		adopted := false
+		if adopter != nil && (len(stack) == 0 || stack[len(stack)-1] == adopter.Parent) &&
+			b.Indent == adopter.Indent && b.Numbering == adopter.Numbering && !b.IsHeader() {
+			stack = append(stack, adopter)
+			adopted = true
+		}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			b.Parent = parent
+			parent.Children = append(parent.Children, b)
+			if parent == adopter && !adopted {
+				// The header has a nested list, so it does not adopt its siblings.
+				adopter = nil
+			}
+		} else {
+			roots = append(roots, b)
+		}
+		if !adopted && (len(stack) == 0 || stack[len(stack)-1] != adopter) {
+			adopter = nil
+		}
+		if b.Numbering != NoNumbering && strings.HasSuffix(b.Text, ":") && reFollowing.MatchString(b.Text) {
+			adopter = b
+		}
+		stack = append(stack, b)
+	}
+
+	for _, b := range bullets {
+		b.splitInline()
+	}
+	return roots
+}
+
+// continuesAlpha tests whether a bullet labeled 'i', 'v' or 'x' continues an alphabetic list at the indentation.
+func continuesAlpha(bullets Bullets, indent int, label string) bool {
+	for i := len(bullets) - 1; i >= 0; i-- {
+		b := bullets[i]
+		if b.Indent < indent {
+			return false
+		}
+		if b.Indent == indent && b.Numbering == LowerAlpha {
+			return len(b.Label) == 1 && b.Label[0]+1 == label[0]
+		}
+	}
+	return false
+}
+
+// marker parses the bullet marker of the line.
+func marker(line string) (Numbering, string, string) {
+	if m := reDashMarker.FindString(line); len(m) > 0 {
+		return Dash, "", line[len(m):]
+	}
+	if m := reNumberMarker.FindStringSubmatch(line); m != nil {
+		return Arabic, m[1], line[len(m[0]):]
+	}
+	if m := reRomanMarker.FindStringSubmatch(line); m != nil {
+		if strings.ToLower(m[1]) == m[1] {
+			return LowerRoman, m[1], line[len(m[0]):]
+		}
+		return UpperRoman, strings.ToLower(m[1]), line[len(m[0]):]
+	}
+	if m := reLetterMarker.FindStringSubmatch(line); m != nil {
+		if strings.ToLower(m[1]) == m[1] {
+			return LowerAlpha, m[1], line[len(m[0]):]
+		}
+		return UpperAlpha, strings.ToLower(m[1]), line[len(m[0]):]
+	}
+	return NoNumbering, "", line
+}
+
+// indentation returns the width of the leading whitespace of the line.
+func indentation(line string) int {
+	n := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += indentTabWidth
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// splitInline splits an inline list that follows a colon in the bullet text into sub-bullets.
+// The inline markers must be consecutive labels, e.g., 'a)', 'b)', starting from the first label.
+func (b *Bullet) splitInline() {
+	i := strings.Index(b.Text, ":")
+	if i < 0 || !b.IsLeaf() {
+		return
+	}
+	locs := reInlineMarker.FindAllStringSubmatchIndex(b.Text[i+1:], -1)
+	if len(locs) < 2 {
+		return
+	}
+	numbering, labels := inlineLabels(b.Text[i+1:], locs)
+	if numbering == NoNumbering {
+		return
+	}
+	rest := b.Text[i+1:]
+	for k, loc := range locs {
+		end := len(rest)
+		if k+1 < len(locs) {
+			end = locs[k+1][0]
+		}
+		child := &Bullet{
+			Text:      strings.TrimSpace(rest[loc[1]:end]),
+			Numbering: numbering,
+			Label:     labels[k],
+			Indent:    b.Indent,
+			Parent:    b,
+		}
+		b.Children = append(b.Children, child)
+	}
+	b.Text = strings.TrimSpace(b.Text[:i+1] + " " + strings.TrimSpace(rest[:locs[0][0]]))
+}
+
+// inlineLabels returns the numbering style and the labels of inline markers
+// if the labels are consecutive from the first label of the style.
+func inlineLabels(s string, locs [][]int) (Numbering, []string) {
+	labels := make([]string, len(locs))
+	for k, loc := range locs {
+		labels[k] = s[loc[2]:loc[3]]
+	}
+	if len(labels) > maxInlineLabels {
+		return NoNumbering, nil
+	}
+	consecutive := func(expected func(int) string) bool {
+		for k, l := range labels {
+			if l != expected(k) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case consecutive(func(k int) string { return string(rune('a' + k)) }):
+		return LowerAlpha, labels
+	case len(labels) <= len(romanNumerals) && consecutive(func(k int) string { return romanNumerals[k] }):
+		return LowerRoman, labels
+	case consecutive(func(k int) string { return strconv.Itoa(k + 1) }):
+		return Arabic, labels
+	default:
+		return NoNumbering, nil
+	}
+}
+
+// Leaves returns the leaf bullets of the trees in depth-first order.
+func (bs Bullets) Leaves() Bullets {
+	var leaves Bullets
+	for _, b := range bs {
+		if b.IsLeaf() {
+			leaves = append(leaves, b)
+		} else {
+			leaves = append(leaves, b.Children.Leaves()...)
+		}
+	}
+	return leaves
+}
+
+// Item defines a criterion flattened from a bullet tree.
+type Item struct {
+	Text    string // Criterion text, prefixed by the text of the parent bullet if the parent has a marker
+	Header  string // Text of the parent bullet, if any
+	Group   Group  // Group of the closest ancestor bullet that defines one
+	GroupID int    // Positive ID of the group, or zero if the criterion is not in a group
+	Cohort  string // Cohort that the criterion or the closest ancestor bullet is restricted to, if any
+}
+
+// Items flattens the bullet trees to criteria. Groups are numbered from groupID,
+// and the ID following the last group is returned.
+func (bs Bullets) Items(groupID int) ([]Item, int) {
+	ids := make(map[*Bullet]int)
+	var items []Item
+	for _, leaf := range bs.Leaves() {
+		item := Item{Text: leaf.Text}
+		if p := leaf.Parent; p != nil {
+			item.Header = p.Text
+			if p.Numbering != NoNumbering {
+				item.Text = p.Text + " " + leaf.Text
+			}
+		}
+		for p := leaf.Parent; p != nil; p = p.Parent {
+			if item.Group = p.Group(); item.Group != NoGroup {
+				if _, ok := ids[p]; !ok {
+					ids[p] = groupID
+					groupID++
+				}
+				item.GroupID = ids[p]
+				break
+			}
+		}
+		item.Cohort = DetectCohort(leaf.Text)
+		for p := leaf.Parent; p != nil && len(item.Cohort) == 0; p = p.Parent {
+			item.Cohort = DetectCohort(p.Text)
+		}
+		items = append(items, item)
+	}
+	return items, groupID
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBulletsNested(t *testing.T) {
+	a := assert.New(t)
+
+	input := `1. Age ≥ 18
+
+          2. Adequate organ function defined as all of the following:
+
+               a) ANC ≥ 1500
+
+               b) platelets ≥ 100,000
+                  without transfusion
+
+          3. ECOG 0-1`
+	bullets := ParseBullets(input)
+	a.Len(bullets, 3)
+	a.Equal("Age ≥ 18", bullets[0].Text)
+	a.Equal(Arabic, bullets[0].Numbering)
+	a.Equal(10, bullets[0].Indent)
+
+	header := bullets[1]
+	a.Equal(AllOf, header.Group())
+	a.Len(header.Children, 2)
+	a.Equal(LowerAlpha, header.Children[1].Numbering)
+	a.Equal("b", header.Children[1].Label)
+	a.Equal("platelets ≥ 100,000 without transfusion", header.Children[1].Text)
+	a.Equal(1, header.Children[1].Depth())
+
+	items, next := bullets.Items(1)
+	a.Equal(2, next)
+	a.Equal([]Item{
+		{Text: "Age ≥ 18"},
+		{Text: "Adequate organ function defined as all of the following: ANC ≥ 1500", Header: "Adequate organ function defined as all of the following:", Group: AllOf, GroupID: 1},
+		{Text: "Adequate organ function defined as all of the following: platelets ≥ 100,000 without transfusion", Header: "Adequate organ function defined as all of the following:", Group: AllOf, GroupID: 1},
+		{Text: "ECOG 0-1"},
+	}, items)
+}
+
+func TestParseBulletsInline(t *testing.T) {
+	a := assert.New(t)
+
+	input := "1. Adequate organ function defined as: a) ANC ≥ 1500 b) platelets ≥ 100,000\n2. Age ≥ 18"
+	bullets := ParseBullets(input)
+	a.Len(bullets, 2)
+	a.Equal("Adequate organ function defined as:", bullets[0].Text)
+	a.Len(bullets[0].Children, 2)
+	a.Equal("ANC ≥ 1500", bullets[0].Children[0].Text)
+	a.Equal("platelets ≥ 100,000", bullets[0].Children[1].Text)
+	a.Equal(NoGroup, bullets[0].Group())
+
+	// Labels that are not consecutive are not split:
+	bullets = ParseBullets("Dose: a) 10 mg c) 20 mg")
+	a.Len(bullets, 1)
+	a.True(bullets[0].IsLeaf())
+}
+
+func TestParseBulletsFlattened(t *testing.T) {
+	a := assert.New(t)
+
+	input := `-  Fever
+
+          -  Presence of at least one of the following criteria:
+
+          -  CRP ≥ 10 mg/dl
+
+          -  Ferritin > 500 ng/ml`
+	bullets := ParseBullets(input)
+	a.Len(bullets, 2)
+	a.Equal(AnyOf, bullets[1].Group())
+	a.Len(bullets[1].Children, 2)
+
+	input = `An individual who meets any of the following criteria will be excluded:
+
+          -  Illegal drug use
+
+          -  Abnormal findings on neurological examination`
+	items, next := ParseBullets(input).Items(3)
+	a.Equal(4, next)
+	a.Len(items, 2)
+	a.Equal(Item{Text: "Illegal drug use", Header: "An individual who meets any of the following criteria will be excluded:", Group: AnyOf, GroupID: 3}, items[0])
+}
+
+func TestParseBulletsRoman(t *testing.T) {
+	a := assert.New(t)
+
+	bullets := ParseBullets("a. one\nb. two\n   i. sub one\n   ii. sub two\nh. eight\ni. nine")
+	a.Len(bullets, 4)
+	a.Equal(LowerRoman, bullets[1].Children[1].Numbering)
+	a.Equal(LowerAlpha, bullets[3].Numbering)
+	a.Equal("i", bullets[3].Label)
+}
+
+func TestParseGroup(t *testing.T) {
+	a := assert.New(t)
+
+	for _, g := range []Group{NoGroup, AllOf, AnyOf} {
+		a.Equal(g, ParseGroup(g.String()))
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// abbreviations end with a period that does not end a clause.
+var abbreviations = map[string]bool{
+	"e.g": true, "i.e": true, "eg": true, "ie": true, "etc": true, "vs": true, "viz": true, "cf": true,
+	"al": true, "approx": true, "incl": true, "excl": true, "max": true, "min": true, "no": true,
+	"nos": true, "fig": true, "dr": true, "mr": true, "mrs": true, "ms": true, "st": true, "pts": true,
+	"yrs": true, "yr": true, "hrs": true, "hr": true, "wks": true, "wk": true, "mos": true, "mo": true,
+	"sec": true, "resp": true, "ref": true, "dept": true, "u.s": true, "u.k": true,
+}
+
+// Clause defines a clause of a criterion and its byte span [Start, End) in the criterion.
+type Clause struct {
+	Text       string
+	Start, End int
+}
+
+// Clauses defines a slice of clauses.
+type Clauses []Clause
+
+// SplitClauses splits the criterion into clauses at sentence boundaries and semicolons.
+// A period, question mark, or exclamation mark ends a sentence if it is followed by
+// whitespace and an uppercase letter, a digit, or a parenthesis, or if it ends the text.
+// Periods of decimals (e.g., '1.5'), abbreviations (e.g., 'e.g.'), and units (e.g., '10^9/L')
+// do not end a sentence. Semicolons and periods in parentheses do not split the criterion.
+func SplitClauses(s string) Clauses {
+	var clauses Clauses
+	add := func(start, end int) {
+		for start < end {
+			r, w := utf8.DecodeRuneInString(s[start:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			start += w
+		}
+		for end > start {
+			r, w := utf8.DecodeLastRuneInString(s[:end])
+			if !unicode.IsSpace(r) && !strings.ContainsRune(".;?!", r) {
+				break
+			}
+			end -= w
+		}
+		if start < end {
+			clauses = append(clauses, Clause{Text: s[start:end], Start: start, End: end})
+		}
+	}
+
+	depth := 0
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			if depth > 0 {
+				depth--
+			}
+		case ';':
+			if depth == 0 {
+				add(start, i+1)
+				start = i + 1
+			}
+		case '.', '?', '!':
+			if depth == 0 && sentenceEnd(s, i) {
+				add(start, i+1)
+				start = i + 1
+			}
+		}
+	}
+	add(start, len(s))
+	return clauses
+}
+
+// sentenceEnd tests whether the punctuation at byte position i ends a sentence.
+func sentenceEnd(s string, i int) bool {
+	next := strings.TrimLeftFunc(s[i+1:], unicode.IsSpace)
+	if len(next) == 0 {
+		return true
+	}
+	if len(next) == len(s[i+1:]) {
+		// Not followed by whitespace: a decimal, an abbreviation, a unit, or ellipsis.
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(next)
+	if !unicode.IsUpper(r) && !unicode.IsDigit(r) && r != '(' {
+		return false
+	}
+	if s[i] == '.' {
+		word := lastWord(s[:i])
+		if abbreviations[strings.ToLower(word)] {
+			return false
+		}
+	}
+	return true
+}
+
+// lastWord returns the word that ends the string.
+func lastWord(s string) string {
+	i := strings.LastIndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '(' || r == '['
+	})
+	return s[i+1:]
+}
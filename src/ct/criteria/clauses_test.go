@@ -0,0 +1,66 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func clauseTexts(clauses Clauses) []string {
+	texts := make([]string, len(clauses))
+	for i, c := range clauses {
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+func TestSplitClausesSentences(t *testing.T) {
+	a := assert.New(t)
+
+	input := "Age ≥ 18. ECOG 0-1. Adequate renal function"
+	clauses := SplitClauses(input)
+	a.Equal([]string{"Age ≥ 18", "ECOG 0-1", "Adequate renal function"}, clauseTexts(clauses))
+	for _, c := range clauses {
+		a.Equal(c.Text, input[c.Start:c.End])
+	}
+}
+
+func TestSplitClausesSemicolons(t *testing.T) {
+	a := assert.New(t)
+
+	input := "ANC ≥ 1500/mm3; platelets ≥ 100,000/mm3 (or ≥ 75,000/mm3; if marrow involvement)"
+	expected := []string{"ANC ≥ 1500/mm3", "platelets ≥ 100,000/mm3 (or ≥ 75,000/mm3; if marrow involvement)"}
+	a.Equal(expected, clauseTexts(SplitClauses(input)))
+}
+
+func TestSplitClausesProtected(t *testing.T) {
+	a := assert.New(t)
+
+	inputs := []string{
+		"Creatinine ≤ 1.5 x ULN",
+		"Prior therapy, e.g. Chemotherapy or radiation",
+		"Hemoglobin ≥ 9 g/dL.",
+		"Lesions (e.g., brain. Metastases) are allowed",
+	}
+	for _, input := range inputs {
+		clauses := SplitClauses(input)
+		a.Len(clauses, 1, input)
+	}
+}
+
+func TestSplitClausesUnits(t *testing.T) {
+	a := assert.New(t)
+
+	input := "ANC ≥ 1.5 x 10^9/L. Platelets ≥ 100 x 10^9/L"
+	expected := []string{"ANC ≥ 1.5 x 10^9/L", "Platelets ≥ 100 x 10^9/L"}
+	a.Equal(expected, clauseTexts(SplitClauses(input)))
+}
+
+func TestSplitClausesEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	a.Empty(SplitClauses(""))
+	a.Empty(SplitClauses(" ; . "))
+}
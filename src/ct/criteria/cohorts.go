@@ -0,0 +1,39 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+)
+
+var (
+	// Cohort prefix of a criterion, e.g., 'For Cohort B only:', 'Arm 2:', or 'Patients in part A -':
+	reCohortPrefix = regexp.MustCompile(`^\(?\s*(?:(?:for|in|applies\s+to)\s+)?(?:(?:patients?|participants?|subjects?)\s+in\s+)?(?:the\s+)?(` +
+		reCohort.String() + `)(?:\s+(?:patients?|participants?|subjects?))?(?:\s+only)?\s*\)?\s*(?::|\s[-–]\s)`)
+	// Parenthesized cohort of a criterion, e.g., '(Cohort B only)' or '(for part 2)':
+	reCohortParen = regexp.MustCompile(`\(\s*(?:(?:for|in)\s+)?(?:the\s+)?(` + reCohort.String() + `)(?:\s+only)?\s*\)`)
+	// Cohort suffix of a criterion, e.g., '... for cohort B only':
+	reCohortSuffix = regexp.MustCompile(`\b(?:for|in)\s+(?:the\s+)?(` + reCohort.String() + `)\s+only\b`)
+)
+
+// DetectCohort returns the lowercase cohort label that the criterion is restricted to,
+// e.g., 'cohort b' for 'For Cohort B only: prior PD-1 therapy' or 'Prior PD-1 therapy (Cohort B only)',
+// or an empty string if the criterion is not restricted to a cohort.
+func DetectCohort(s string) string {
+	s = strings.ToLower(text.NormalizeWhitespace(strings.TrimSpace(s)))
+	for _, re := range []*regexp.Regexp{reCohortPrefix, reCohortParen, reCohortSuffix} {
+		if m := re.FindStringSubmatch(s); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// Applies tests whether a criterion of cohort c applies to the cohort.
+// Criteria without a cohort apply to all cohorts.
+func Applies(c, cohort string) bool {
+	return len(c) == 0 || c == cohort
+}
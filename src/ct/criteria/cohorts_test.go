@@ -0,0 +1,52 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectCohort(t *testing.T) {
+	a := assert.New(t)
+
+	tests := map[string]string{
+		"For Cohort B only: prior PD-1 therapy":                       "cohort b",
+		"Cohort 2: measurable disease per RECIST 1.1":                 "cohort 2",
+		"Arm A - no prior chemotherapy":                               "arm a",
+		"Patients in Part 1 only: ECOG 0-1":                           "part 1",
+		"Prior PD-1 therapy (Cohort B only)":                          "cohort b",
+		"Prior anti-CTLA-4 therapy for the dose escalation part only": "dose escalation part",
+		"Age ≥ 18 years":                                              "",
+		"Group A streptococcal infection":                             "",
+		"Participants who are part of another study":                  "",
+	}
+	for input, expected := range tests {
+		a.Equal(expected, DetectCohort(input), input)
+	}
+}
+
+func TestItemsCohort(t *testing.T) {
+	a := assert.New(t)
+
+	input := "- Age ≥ 18\n- Cohort B only:\n  - prior PD-1 therapy\n  - PD-L1 ≥ 1%\n- Arm 2: no prior chemotherapy"
+	items, _ := ParseBullets(input).Items(1)
+	a.Len(items, 4)
+	a.Equal("", items[0].Cohort)
+	a.Equal("cohort b", items[1].Cohort)
+	a.Equal("cohort b", items[2].Cohort)
+	a.Equal("arm 2", items[3].Cohort)
+}
+
+func TestCriteriaCohort(t *testing.T) {
+	a := assert.New(t)
+
+	all := &Criterion{text: "all"}
+	b := &Criterion{text: "b", Cohort: "cohort b"}
+	c := &Criterion{text: "c", Cohort: "cohort c"}
+	cs := Criteria{all, b, c}
+	a.Equal(Criteria{all, b}, cs.Cohort("cohort b"))
+	a.Equal(Criteria{all}, cs.Cohort("cohort d"))
+	a.Equal(Criteria{all}, cs.Cohort(""))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/diagnostics"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
 )
 
@@ -16,6 +17,12 @@ type Criterion struct {
 	score        float64
 	ClusterID    int
 	ClusterTopic string
+	Header       string                  // Header of the list that the criterion is in, if any
+	Group        Group                   // 'Any/all of the following' group of the criterion
+	GroupID      int                     // Positive ID of the group within the study, or zero
+	Cohort       string                  // Cohort or arm that the criterion applies to, or empty if it applies to all
+	Diagnostics  diagnostics.Diagnostics // Failures to parse clauses of the criterion
+	Disjunctions []relation.Relations    // Relations of clauses that are alternatives, e.g., 'a1c > 5.7% or bmi ≥ 25'
 }
 
 // Criteria defines a slice of eligibility criteria.
@@ -31,6 +38,14 @@ func NewCriteria() Criteria {
 	return make(Criteria, 0)
 }
 
+// SetItem sets the list header, group, and cohort of the criterion from the item.
+func (c *Criterion) SetItem(item Item) {
+	c.Header = item.Header
+	c.Group = item.Group
+	c.GroupID = item.GroupID
+	c.Cohort = item.Cohort
+}
+
 // Names returns a concatenated string of criterion/variable names.
 func (c *Criterion) Names() string {
 	switch len(c.relations) {
@@ -71,6 +86,17 @@ func (cs Criteria) Relations() relation.Relations {
 	return rs
 }
 
+// Cohort returns the criteria that apply to the cohort.
+func (cs Criteria) Cohort(cohort string) Criteria {
+	applicable := NewCriteria()
+	for _, c := range cs {
+		if Applies(c.Cohort, cohort) {
+			applicable = append(applicable, c)
+		}
+	}
+	return applicable
+}
+
 // String returns the string of criteria.
 func (cs Criteria) String() string {
 	str := ""
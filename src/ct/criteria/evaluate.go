@@ -0,0 +1,130 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"fmt"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/eligibility"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+)
+
+// Evaluate tests whether the patient meets the criterion: all relations of the criterion
+// are satisfied, except for the disjunctions, of which one relation is to be satisfied.
+// Relations of exclusion criteria are negated, so an exclusion criterion is met if it does
+// not exclude the patient. Relations scored zero, e.g., implausible values, are ignored, and
+// a criterion without other relations is met. An error is returned if the relations that
+// decide the criterion cannot be evaluated, e.g., a value of the patient is missing.
+func (c *Criterion) Evaluate(p *formula.Patient, vs *variables.Variables) (bool, error) {
+	alternative := make(map[*relation.Relation]bool)
+	for _, d := range c.Disjunctions {
+		for _, r := range d {
+			alternative[r] = true
+		}
+	}
+	var conjunction relation.Relations
+	for _, r := range c.relations {
+		if !alternative[r] && r.Score > 0 {
+			conjunction = append(conjunction, r)
+		}
+	}
+	terms := make([]func() (bool, error), 0, len(conjunction)+len(c.Disjunctions))
+	for _, r := range conjunction {
+		r := r
+		terms = append(terms, func() (bool, error) { return r.Evaluate(p, vs) })
+	}
+	for _, d := range c.Disjunctions {
+		var rs relation.Relations
+		for _, r := range d {
+			if r.Score > 0 {
+				rs = append(rs, r)
+			}
+		}
+		if len(rs) == 0 {
+			continue
+		}
+		var alternatives []func() (bool, error)
+		for _, r := range rs {
+			r := r
+			alternatives = append(alternatives, func() (bool, error) { return r.Evaluate(p, vs) })
+		}
+		terms = append(terms, func() (bool, error) { return combine(AnyOf, alternatives) })
+	}
+	return combine(AllOf, terms)
+}
+
+// Evaluate tests whether the patient meets the criteria of the eligibility type. Criteria that
+// are not in a group are to be met, and a group of criteria is met if all or any of its criteria are
+// met. Because exclusion criteria are met if they do not exclude the patient, the patient is not
+// excluded by a group of 'any of the following' exclusion criteria only if all of its criteria are
+// met, and vice versa. Criteria that are not parsed to relations are assumed to be met.
+func (cs Criteria) Evaluate(p *formula.Patient, vs *variables.Variables, t eligibility.Type) (bool, error) {
+	var terms []func() (bool, error)
+	groups := make(map[int]Criteria)
+	var ids []int
+	for _, c := range cs {
+		c := c
+		if c.GroupID == 0 || c.Group == NoGroup {
+			terms = append(terms, func() (bool, error) { return c.Evaluate(p, vs) })
+			continue
+		}
+		if _, ok := groups[c.GroupID]; !ok {
+			ids = append(ids, c.GroupID)
+		}
+		groups[c.GroupID] = append(groups[c.GroupID], c)
+	}
+	for _, id := range ids {
+		group := groups[id]
+		g := group[0].Group
+		if t == eligibility.Exclusion {
+			if g == AnyOf {
+				g = AllOf
+			} else {
+				g = AnyOf
+			}
+		}
+		var members []func() (bool, error)
+		for _, c := range group {
+			c := c
+			members = append(members, func() (bool, error) { return c.Evaluate(p, vs) })
+		}
+		id := id
+		terms = append(terms, func() (bool, error) {
+			ok, err := combine(g, members)
+			if err != nil {
+				return false, fmt.Errorf("group %d: %v", id, err)
+			}
+			return ok, nil
+		})
+	}
+	return combine(AllOf, terms)
+}
+
+// combine combines the values of the terms by all or any. A term that cannot be evaluated
+// fails the combination only if the other terms do not decide it, e.g., any term that is
+// true decides 'any'. The combination of no terms is true.
+func combine(g Group, terms []func() (bool, error)) (bool, error) {
+	decisive := g == AnyOf
+	var firstErr error
+	for _, term := range terms {
+		ok, err := term()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if ok == decisive {
+			return decisive, nil
+		}
+	}
+	if firstErr != nil {
+		return false, firstErr
+	}
+	if len(terms) == 0 {
+		return true, nil
+	}
+	return !decisive, nil
+}
@@ -0,0 +1,90 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/eligibility"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// above creates a criterion that is met if the value of the variable is above the bound.
+func above(name, bound string, group Group, groupID int) *Criterion {
+	r := &relation.Relation{Name: name, Lower: &relation.Limit{Value: bound}, VariableType: variables.Numerical, Score: 1}
+	c := NewCriterion(name+" > "+bound, 1, relation.Relations{r}, 0)
+	c.Group = group
+	c.GroupID = groupID
+	return c
+}
+
+func TestCriterionEvaluate(t *testing.T) {
+	a := assert.New(t)
+
+	vs := variables.New()
+	c := above("a", "1", NoGroup, 0)
+	b := &relation.Relation{Name: "b", Lower: &relation.Limit{Value: "1"}, VariableType: variables.Numerical, Score: 1}
+	implausible := &relation.Relation{Name: "c", Lower: &relation.Limit{Value: "1"}, VariableType: variables.Numerical}
+	c.relations = append(c.relations, b, implausible)
+	c.Disjunctions = []relation.Relations{{c.relations[0], b}}
+
+	tests := []struct {
+		patient  *formula.Patient
+		expected bool
+	}{
+		{formula.NewPatient(formula.Female).Set("a", 2).Set("b", 0), true},
+		{formula.NewPatient(formula.Female).Set("a", 0).Set("b", 2), true},
+		{formula.NewPatient(formula.Female).Set("a", 0).Set("b", 0), false},
+		{formula.NewPatient(formula.Female).Set("a", 2), true},
+	}
+	for i, test := range tests {
+		actual, err := c.Evaluate(test.patient, vs)
+		if a.NoError(err, i) {
+			a.Equal(test.expected, actual, i)
+		}
+	}
+	_, err := c.Evaluate(formula.NewPatient(formula.Female).Set("a", 0), vs)
+	a.Error(err)
+
+	ok, err := NewCriterion("unparsed", 0, nil, 0).Evaluate(formula.NewPatient(formula.Female), vs)
+	a.NoError(err)
+	a.True(ok)
+}
+
+func TestCriteriaEvaluate(t *testing.T) {
+	a := assert.New(t)
+
+	vs := variables.New()
+	cs := Criteria{
+		above("a", "1", NoGroup, 0),
+		above("b", "1", AnyOf, 1),
+		above("c", "1", AnyOf, 1),
+	}
+
+	tests := []struct {
+		patient  *formula.Patient
+		t        eligibility.Type
+		expected bool
+	}{
+		{formula.NewPatient(formula.Female).Set("a", 2).Set("b", 2).Set("c", 0), eligibility.Inclusion, true},
+		{formula.NewPatient(formula.Female).Set("a", 2).Set("b", 0).Set("c", 0), eligibility.Inclusion, false},
+		{formula.NewPatient(formula.Female).Set("a", 0).Set("b", 2).Set("c", 2), eligibility.Inclusion, false},
+		// Exclusion criteria are met if the patient is not excluded by any criterion of the group:
+		{formula.NewPatient(formula.Female).Set("a", 2).Set("b", 2).Set("c", 0), eligibility.Exclusion, false},
+		{formula.NewPatient(formula.Female).Set("a", 2).Set("b", 2).Set("c", 2), eligibility.Exclusion, true},
+		// Any criterion of the group decides it even if the value of another criterion is missing:
+		{formula.NewPatient(formula.Female).Set("a", 2).Set("b", 2), eligibility.Inclusion, true},
+	}
+	for i, test := range tests {
+		actual, err := cs.Evaluate(test.patient, vs, test.t)
+		if a.NoError(err, i) {
+			a.Equal(test.expected, actual, i)
+		}
+	}
+	_, err := cs.Evaluate(formula.NewPatient(formula.Female).Set("a", 2).Set("b", 0), vs, eligibility.Inclusion)
+	a.Error(err)
+}
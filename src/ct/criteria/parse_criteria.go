@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/diagnostics"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
 )
 
@@ -28,12 +29,17 @@ var (
 )
 
 type ParsedCriterion struct {
-	EligibilityType string             `json:"eligibility_type,omitempty"` // inclusion or exclusion
-	VariableType    string             `json:"variable_type,omitempty"`    // numerical or ordinal
-	CriterionIndex  int                `json:"criterion_index"`
-	Criterion       string             `json:"criterion,omitempty"`
-	Question        string             `json:"question,omitempty"`
-	Relation        relation.Relations `json:"relation,omitempty"`
+	EligibilityType string                  `json:"eligibility_type,omitempty"` // inclusion or exclusion
+	VariableType    string                  `json:"variable_type,omitempty"`    // numerical or ordinal
+	CriterionIndex  int                     `json:"criterion_index"`
+	Criterion       string                  `json:"criterion,omitempty"`
+	Question        string                  `json:"question,omitempty"`
+	Header          string                  `json:"header,omitempty"`   // header of the list that the criterion is in
+	Group           string                  `json:"group,omitempty"`    // any or all of the following
+	GroupID         int                     `json:"group_id,omitempty"` // criteria with the same group id are in the same group
+	Cohort          string                  `json:"cohort,omitempty"`   // cohort or arm that the criterion applies to
+	Relation        relation.Relations      `json:"relation,omitempty"`
+	Diagnostics     diagnostics.Diagnostics `json:"diagnostics,omitempty"` // failures to parse clauses of the criterion
 }
 
 type ParsedCriteria []*ParsedCriterion
@@ -49,6 +55,15 @@ func NewParsedCriterion(eligibilityType, variableType string, criterionIndex int
 	}
 }
 
+// SetCriterion sets the list header, group, cohort, and diagnostics of the parsed criterion from the criterion.
+func (p *ParsedCriterion) SetCriterion(c *Criterion) {
+	p.Header = c.Header
+	p.Group = c.Group.String()
+	p.GroupID = c.GroupID
+	p.Cohort = c.Cohort
+	p.Diagnostics = c.Diagnostics
+}
+
 func (p *ParsedCriteria) JSON() string {
 	if data, err := json.Marshal(p); err == nil {
 		return string(data)
@@ -0,0 +1,337 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/eligibility"
+)
+
+// Strategy defines how eligibility criteria text is segmented into sections.
+type Strategy int
+
+const (
+	// NoStrategy is used when the text has no criteria
+	NoStrategy Strategy = iota
+	// StandardHeaders segments the text at literal 'inclusion/exclusion criteria' headers
+	StandardHeaders
+	// HeaderVocabulary segments the text at headers of the broad header vocabulary
+	HeaderVocabulary
+	// Headerless classifies each criterion of a text without headers
+	Headerless
+)
+
+// ParseStrategy converts a string to a segmentation strategy.
+func ParseStrategy(s string) Strategy {
+	switch strings.ToLower(s) {
+	case "standard_headers":
+		return StandardHeaders
+	case "header_vocabulary":
+		return HeaderVocabulary
+	case "headerless":
+		return Headerless
+	default:
+		return NoStrategy
+	}
+}
+
+// String converts the strategy to a string.
+func (s Strategy) String() string {
+	switch s {
+	case StandardHeaders:
+		return "standard_headers"
+	case HeaderVocabulary:
+		return "header_vocabulary"
+	case Headerless:
+		return "headerless"
+	default:
+		return "none"
+	}
+}
+
+// maxHeaderWords is the maximum number of words in a section header.
+const maxHeaderWords = 12
+
+// maxBareHeaderWords is the maximum number of words in a header that does not end with a colon.
+const maxBareHeaderWords = 6
+
+var (
+	reExclusionHeader = regexp.MustCompile(`\b(?:exclusions?|non-?inclusion|ineligibility)(?:\s+criteria)?\b` +
+		`|\b(?:participants?|patients?|subjects?|individuals?|volunteers?|persons?|people|women|men|children)\s+(?:must|should|may|will|can)\s*not\b(?:\s+(?:have|be|meet))?` +
+		`|\b(?:participants?|patients?|subjects?|individuals?|volunteers?|persons?|people)\s+(?:are|is|will\s+be|would\s+be)\s+(?:not\s+eligible|ineligible|excluded)(?:\s+from(?:\s+the)?\s+(?:study|trial))?(?:\s+(?:if|for|when))?` +
+		`|\bnot\s+eligible(?:\s+(?:if|for))?` +
+		`|\bwho\s+(?:can\s*not|may\s+not|should\s+not)\s+(?:participate|enroll|enrol)` +
+		`|\bcontraindications?`)
+	reInclusionHeader = regexp.MustCompile(`\binclusions?(?:\s+criteria)?\b` +
+		`|\beligibility(?:\s+(?:criteria|requirements))?\b` +
+		`|\b(?:participants?|patients?|subjects?|individuals?|volunteers?|persons?|people)\s+(?:must|should|will\s+need\s+to|need\s+to|have\s+to)(?:\s+(?:meet|have|be|fulfill|fulfil|satisfy))?(?:\s+(?:all|each|any)(?:\s+of)?(?:\s+the)?\s+following)?` +
+		`|\b(?:participants?|patients?|subjects?|individuals?|volunteers?|persons?|people)\s+(?:are|is|will\s+be|may\s+be)\s+eligible(?:\s+(?:if|for|when))?` +
+		`|\beligible\s+(?:participants?|patients?|subjects?)` +
+		`|\bwho\s+(?:can|may)\s+(?:participate|enroll|enrol)` +
+		`|\brequirements?\s+for\s+(?:participation|enrollment|enrolment|inclusion)`)
+	reCohort = regexp.MustCompile(`\b(?:(?:part|cohort|arm|group|substudy|sub-study|module)\s+(?:[a-z]|[0-9]+[a-z]?|[ivx]+)\b` +
+		`|dose[- ]escalation(?:\s+(?:part|cohort|phase))?|dose[- ]expansion(?:\s+(?:part|cohort|phase))?|expansion\s+(?:part|cohort|phase))`)
+
+	// Exclusion cues of criteria in texts without headers:
+	reExclusionCue = regexp.MustCompile(`^(?:no|not|non|without|never|neither|unable|inability|unwilling|refus\w*|history\s+of|known|active|uncontrolled|concurrent|concomitant|current|ongoing|severe|significant|pregnan\w*|breast-?feeding|lactating|nursing|allerg\w*|hypersensitivity|contraindications?|participation\s+in\s+(?:another|other|any))\b` +
+		`|\b(?:is|are|will\s+be)\s+excluded\b|\bexclusion\b`)
+	// Negated requirements and pregnancy or lactation anywhere in a criterion are exclusion cues
+	// that precede the inclusion cues, e.g., 'must not have active infection' or 'women who are pregnant'.
+	reNegatedRequirement = regexp.MustCompile(`\b(?:must|should|may|shall|can)\s*not\b|\b(?:cannot|can't|must\s+never)\b`)
+	rePregnancyCue       = regexp.MustCompile(`\b(?:pregnan\w*|lactating|breast-?feeding|nursing\s+(?:mothers?|women))\b`)
+	// rePregnancyRequirement matches pregnancy phrases of inclusion criteria, e.g., 'negative pregnancy test'.
+	rePregnancyRequirement = regexp.MustCompile(`\bpregnancy\s+tests?\b|\bcontracepti\w*|\bbirth\s+control\b`)
+	// Inclusion cues of criteria in texts without headers:
+	reInclusionCue = regexp.MustCompile(`^(?:age|aged|male|female|men|women|adults?|willing|able|ability|signed|written|provide|documented|confirmed|diagnosis\s+of|histologically|must|has|have|at\s+least)\b` +
+		`|\b(?:must|should)\s+(?:have|be|agree)\b|\binformed\s+consent\b`)
+
+	// fillers are words that may precede or follow a header phrase.
+	fillers = map[string]bool{
+		"key": true, "general": true, "main": true, "major": true, "minor": true, "additional": true,
+		"specific": true, "other": true, "all": true, "any": true, "each": true, "common": true,
+		"study": true, "trial": true, "this": true, "these": true, "subject": true, "subjects": true,
+		"patient": true, "patients": true, "participant": true, "participants": true, "they": true,
+		"who": true, "the": true, "for": true, "in": true, "and": true, "of": true, "to": true,
+		"if": true, "are": true, "be": true, "will": true, "apply": true, "applies": true, "only": true,
+		"criteria": true, "criterion": true, "requirements": true, "following": true,
+	}
+)
+
+// Section defines a block of criteria of the same eligibility type and cohort.
+type Section struct {
+	Type   eligibility.Type
+	Cohort string // Lowercase cohort label, e.g., 'part a', or empty if the section applies to all cohorts
+	Header string // Header line, or empty if the section was not headed
+	Text   string
+}
+
+// Sections defines a slice of sections.
+type Sections []Section
+
+// Blocks returns the texts of the sections of eligibility type t.
+func (ss Sections) Blocks(t eligibility.Type) []string {
+	var blocks []string
+	for _, s := range ss {
+		if s.Type == t {
+			blocks = append(blocks, s.Text)
+		}
+	}
+	return blocks
+}
+
+// Segment segments eligibility criteria text into inclusion and exclusion sections and returns
+// the sections and the strategy used. Literal 'inclusion/exclusion criteria' headers are used when
+// they define both types of sections. Otherwise, headers are recognized from a broad vocabulary,
+// including cohort sections, e.g., 'Part A inclusion criteria:' or 'Patients are not eligible if'.
+// If the text has no headers, each criterion is classified as an inclusion or an exclusion criterion.
+func Segment(s string) (Sections, Strategy) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil, NoStrategy
+	}
+
+	standard := standardSections(s)
+	headed, cohorts := headerSections(s)
+	switch {
+	case hasBoth(standard) && !cohorts:
+		return standard, StandardHeaders
+	case len(headed) > 0 && (hasBoth(headed) || cohorts || len(standard) == 0):
+		return headed, HeaderVocabulary
+	case len(standard) > 0:
+		return standard, StandardHeaders
+	}
+
+	if sections := classifySections(s); len(sections) > 0 {
+		return sections, Headerless
+	}
+	return nil, NoStrategy
+}
+
+// standardSections extracts the sections with the literal inclusion and exclusion headers.
+func standardSections(s string) Sections {
+	var sections Sections
+	for _, block := range ExtractInclusionCriteria(s) {
+		sections = append(sections, Section{Type: eligibility.Inclusion, Text: block})
+	}
+	for _, block := range ExtractExclusionCriteria(s) {
+		sections = append(sections, Section{Type: eligibility.Exclusion, Text: block})
+	}
+	return sections
+}
+
+// hasBoth tests whether the sections include both inclusion and exclusion sections.
+func hasBoth(sections Sections) bool {
+	inclusion, exclusion := false, false
+	for _, s := range sections {
+		inclusion = inclusion || s.Type == eligibility.Inclusion
+		exclusion = exclusion || s.Type == eligibility.Exclusion
+	}
+	return inclusion && exclusion
+}
+
+// header defines a parsed section header.
+type header struct {
+	typ    eligibility.Type
+	cohort string
+	rest   string // Text following the header on the same line
+}
+
+// headerSections segments the text at header lines. It returns the sections
+// and whether cohort headers were found. Text before the first header is dropped.
+func headerSections(s string) (Sections, bool) {
+	var sections Sections
+	var lines []string
+	cur := Section{}
+	cohortScope := "" // Cohort set by a cohort-only header, applies to the following type headers
+	cohorts := false
+
+	flush := func() {
+		if t := strings.TrimSpace(strings.Join(lines, "\n")); len(t) > 0 && cur.Type != eligibility.Unknown {
+			cur.Text = t
+			sections = append(sections, cur)
+		}
+		lines = lines[:0]
+	}
+
+	for _, line := range strings.Split(s, "\n") {
+		h, ok := parseHeader(line)
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+		flush()
+		switch {
+		case h.typ == eligibility.Unknown:
+			cohortScope = h.cohort
+			cur = Section{Type: cur.Type, Cohort: h.cohort, Header: strings.TrimSpace(line)}
+		case len(h.cohort) > 0:
+			cur = Section{Type: h.typ, Cohort: h.cohort, Header: strings.TrimSpace(line)}
+		default:
+			cur = Section{Type: h.typ, Cohort: cohortScope, Header: strings.TrimSpace(line)}
+		}
+		if len(h.cohort) > 0 {
+			cohorts = true
+		}
+		if len(h.rest) > 0 {
+			lines = append(lines, h.rest)
+		}
+	}
+	flush()
+	return sections, cohorts
+}
+
+// parseHeader tests whether the line is a section header. A header consists of a header phrase,
+// an optional cohort and filler words, and it may end with a colon followed by criteria text.
+func parseHeader(line string) (header, bool) {
+	line = strings.TrimSpace(line)
+	if len(line) == 0 || reMatchBulletLine.MatchString(line) {
+		return header{}, false
+	}
+
+	head, rest := line, ""
+	colon := false
+	if i := strings.Index(line, ":"); i >= 0 {
+		head, rest = line[:i], strings.TrimSpace(line[i+1:])
+		colon = true
+	}
+	head = strings.ToLower(text.NormalizeWhitespace(head))
+	head = strings.Trim(head, " .-–")
+	words := len(strings.Fields(head))
+	if words == 0 || words > maxHeaderWords || (!colon && words > maxBareHeaderWords) {
+		return header{}, false
+	}
+
+	h := header{rest: rest}
+	if loc := reCohort.FindStringIndex(head); loc != nil {
+		h.cohort = head[loc[0]:loc[1]]
+	}
+	loc := reExclusionHeader.FindStringIndex(head)
+	h.typ = eligibility.Exclusion
+	if loc == nil {
+		loc = reInclusionHeader.FindStringIndex(head)
+		h.typ = eligibility.Inclusion
+	}
+	if loc == nil {
+		// Cohort-only header, e.g., 'Cohort B:'
+		if len(h.cohort) > 0 && colon && onlyFillers(reCohort.ReplaceAllString(head, " ")) {
+			return header{cohort: h.cohort, rest: rest}, true
+		}
+		return header{}, false
+	}
+	other := head[:loc[0]] + " " + head[loc[1]:]
+	if !onlyFillers(reCohort.ReplaceAllString(other, " ")) {
+		return header{}, false
+	}
+	return h, true
+}
+
+// onlyFillers tests whether the string consists of filler words and punctuation only.
+func onlyFillers(s string) bool {
+	for _, w := range strings.Fields(s) {
+		w = strings.Trim(w, "()[],;.-–/")
+		if len(w) > 0 && !fillers[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// classifySections classifies each criterion of a text without headers as an inclusion
+// or an exclusion criterion and groups consecutive criteria of the same type into sections.
+func classifySections(s string) Sections {
+	var sections Sections
+	var lines []string
+	cur := eligibility.Unknown
+	flush := func() {
+		if len(lines) > 0 {
+			sections = append(sections, Section{Type: cur, Text: strings.Join(lines, "\n\n")})
+		}
+		lines = lines[:0]
+	}
+	for _, c := range headerlessCriteria(s) {
+		t := Classify(c)
+		if t != cur {
+			flush()
+			cur = t
+		}
+		lines = append(lines, c)
+	}
+	flush()
+	return sections
+}
+
+// headerlessCriteria splits a text without headers into criteria at blank lines
+// or, if the text has no blank lines, at line breaks.
+func headerlessCriteria(s string) []string {
+	parts := reCriteriaSplitter.Split(strings.TrimSpace(s), -1)
+	if len(parts) == 1 {
+		parts = strings.Split(parts[0], "\n")
+	}
+	var criteria []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); len(p) > 0 {
+			criteria = append(criteria, p)
+		}
+	}
+	return criteria
+}
+
+// Classify classifies a criterion from a text without headers as an inclusion
+// or an exclusion criterion by its leading words. Negated requirements and pregnancy
+// or lactation that is not a requirement of a pregnancy test or contraception are
+// exclusions. Criteria without exclusion cues are inclusions.
+func Classify(criterion string) eligibility.Type {
+	s := strings.ToLower(TrimCriterion(criterion))
+	if reNegatedRequirement.MatchString(s) {
+		return eligibility.Exclusion
+	}
+	if rePregnancyCue.MatchString(s) && !rePregnancyRequirement.MatchString(s) {
+		return eligibility.Exclusion
+	}
+	if reExclusionCue.MatchString(s) && !reInclusionCue.MatchString(s) {
+		return eligibility.Exclusion
+	}
+	return eligibility.Inclusion
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package criteria
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/eligibility"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentStandardHeaders(t *testing.T) {
+	a := assert.New(t)
+
+	input := "Inclusion Criteria:\ni1\ni2\nExclusion Criteria:\ne1\ne2"
+	sections, strategy := Segment(input)
+	a.Equal(StandardHeaders, strategy)
+	a.Equal([]string{"i1\ni2"}, sections.Blocks(eligibility.Inclusion))
+	a.Equal([]string{"e1\ne2"}, sections.Blocks(eligibility.Exclusion))
+}
+
+func TestSegmentHeaderVocabulary(t *testing.T) {
+	a := assert.New(t)
+
+	input := "Key Eligibility:\n- i1\n- i2\nParticipants must not:\n- e1\n- e2"
+	sections, strategy := Segment(input)
+	a.Equal(HeaderVocabulary, strategy)
+	a.Equal([]string{"- i1\n- i2"}, sections.Blocks(eligibility.Inclusion))
+	a.Equal([]string{"- e1\n- e2"}, sections.Blocks(eligibility.Exclusion))
+
+	input = "Patients are eligible if\n\ni1\n\nPatients are not eligible if\n\ne1"
+	sections, strategy = Segment(input)
+	a.Equal(HeaderVocabulary, strategy)
+	a.Equal([]string{"i1"}, sections.Blocks(eligibility.Inclusion))
+	a.Equal([]string{"e1"}, sections.Blocks(eligibility.Exclusion))
+
+	// A criterion that starts with a header phrase is not a header:
+	input = "Inclusion Criteria:\ni1\nParticipants must not be pregnant\nExclusion Criteria:\ne1"
+	sections, strategy = Segment(input)
+	a.Equal(StandardHeaders, strategy)
+	a.Equal([]string{"i1\nParticipants must not be pregnant"}, sections.Blocks(eligibility.Inclusion))
+}
+
+func TestSegmentCohorts(t *testing.T) {
+	a := assert.New(t)
+
+	input := "Part A inclusion criteria:\ni1\nPart A exclusion criteria:\ne1\nPart B inclusion criteria:\nj1\nPart B exclusion criteria:\nf1"
+	sections, strategy := Segment(input)
+	a.Equal(HeaderVocabulary, strategy)
+	a.Len(sections, 4)
+	a.Equal(Section{Type: eligibility.Inclusion, Cohort: "part a", Header: "Part A inclusion criteria:", Text: "i1"}, sections[0])
+	a.Equal(Section{Type: eligibility.Exclusion, Cohort: "part b", Header: "Part B exclusion criteria:", Text: "f1"}, sections[3])
+
+	input = "Inclusion Criteria:\ni1\nCohort 2:\nj1\nExclusion Criteria:\ne1"
+	sections, strategy = Segment(input)
+	a.Equal(HeaderVocabulary, strategy)
+	a.Len(sections, 3)
+	a.Equal(Section{Type: eligibility.Inclusion, Text: "i1", Header: "Inclusion Criteria:"}, sections[0])
+	a.Equal(Section{Type: eligibility.Inclusion, Cohort: "cohort 2", Header: "Cohort 2:", Text: "j1"}, sections[1])
+	a.Equal(Section{Type: eligibility.Exclusion, Cohort: "cohort 2", Header: "Exclusion Criteria:", Text: "e1"}, sections[2])
+}
+
+func TestSegmentHeaderless(t *testing.T) {
+	a := assert.New(t)
+
+	input := "Age 18 years or older\n\nSigned informed consent\n\nNo prior chemotherapy\n\nKnown HIV infection\n\nPregnant or breastfeeding"
+	sections, strategy := Segment(input)
+	a.Equal(Headerless, strategy)
+	a.Equal([]string{"Age 18 years or older\n\nSigned informed consent"}, sections.Blocks(eligibility.Inclusion))
+	a.Equal([]string{"No prior chemotherapy\n\nKnown HIV infection\n\nPregnant or breastfeeding"}, sections.Blocks(eligibility.Exclusion))
+
+	input = "Age 18 years or older\n\nMust not be pregnant\n\nMust have adequate organ function"
+	sections, strategy = Segment(input)
+	a.Equal(Headerless, strategy)
+	a.Equal([]string{"Must not be pregnant"}, sections.Blocks(eligibility.Exclusion))
+
+	sections, strategy = Segment("  \n ")
+	a.Equal(NoStrategy, strategy)
+	a.Empty(sections)
+}
+
+func TestClassify(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(eligibility.Inclusion, Classify("1. Histologically confirmed adenocarcinoma"))
+	a.Equal(eligibility.Inclusion, Classify("- Must have adequate organ function"))
+	a.Equal(eligibility.Exclusion, Classify("- Uncontrolled hypertension"))
+	a.Equal(eligibility.Exclusion, Classify("Patients with brain metastases are excluded"))
+	a.Equal(eligibility.Exclusion, Classify("Must not be pregnant"))
+	a.Equal(eligibility.Exclusion, Classify("Patients must not have active infection"))
+	a.Equal(eligibility.Exclusion, Classify("Subjects should not be receiving other investigational agents"))
+	a.Equal(eligibility.Exclusion, Classify("Women who are pregnant"))
+	a.Equal(eligibility.Exclusion, Classify("Female patients who are lactating or breastfeeding"))
+	a.Equal(eligibility.Inclusion, Classify("Women of childbearing potential must have a negative pregnancy test"))
+	a.Equal(eligibility.Inclusion, Classify("Must agree to use contraception to avoid pregnancy"))
+}
+
+func TestParseStrategy(t *testing.T) {
+	a := assert.New(t)
+
+	for _, s := range []Strategy{NoStrategy, StandardHeaders, HeaderVocabulary, Headerless} {
+		a.Equal(s, ParseStrategy(s.String()))
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package diagnostics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stage defines the stage of criterion parsing that a diagnostic is reported by.
+type Stage int
+
+const (
+	// Unknown is the stage of diagnostics without a stage
+	Unknown Stage = iota
+	// Parser is the stage that parses criterion text to items
+	Parser
+	// Grammar is the stage that parses items to trees
+	Grammar
+	// Evaluation is the stage that evaluates trees to relations
+	Evaluation
+)
+
+// ParseStage converts a string to a stage.
+func ParseStage(s string) Stage {
+	switch strings.ToLower(s) {
+	case "parser":
+		return Parser
+	case "grammar":
+		return Grammar
+	case "evaluation":
+		return Evaluation
+	default:
+		return Unknown
+	}
+}
+
+// String converts the stage to a string.
+func (s Stage) String() string {
+	switch s {
+	case Parser:
+		return "parser"
+	case Grammar:
+		return "grammar"
+	case Evaluation:
+		return "evaluation"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText converts the stage to its string in JSON.
+func (s Stage) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Diagnostic defines a failure to parse a criterion clause completely, such as
+// a recovered panic or criterion text that the grammar does not parse.
+type Diagnostic struct {
+	Clause int    `json:"clause_index"`   // index of the clause in the criterion
+	Stage  Stage  `json:"stage"`          // stage that failed
+	Reason string `json:"reason"`         // reason of the failure
+	Text   string `json:"text,omitempty"` // text that failed to parse, if known
+}
+
+// New creates a new diagnostic of the stage.
+func New(stage Stage, reason, text string) *Diagnostic {
+	return &Diagnostic{Stage: stage, Reason: reason, Text: text}
+}
+
+// String returns the human-readable diagnostic.
+func (d *Diagnostic) String() string {
+	if len(d.Text) == 0 {
+		return fmt.Sprintf("%s: %s", d.Stage, d.Reason)
+	}
+	return fmt.Sprintf("%s: %s: %q", d.Stage, d.Reason, d.Text)
+}
+
+// Diagnostics defines a slice of diagnostics.
+type Diagnostics []*Diagnostic
+
+// SetClause sets the clause index of the diagnostics.
+func (ds Diagnostics) SetClause(index int) {
+	for _, d := range ds {
+		d.Clause = index
+	}
+}
+
+// Empty tests whether there are no diagnostics.
+func (ds Diagnostics) Empty() bool {
+	return len(ds) == 0
+}
@@ -0,0 +1,35 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStage(t *testing.T) {
+	a := assert.New(t)
+
+	for _, s := range []Stage{Unknown, Parser, Grammar, Evaluation} {
+		a.Equal(s, ParseStage(s.String()))
+	}
+	a.Equal(Unknown, ParseStage("lexer"))
+}
+
+func TestJSON(t *testing.T) {
+	a := assert.New(t)
+
+	ds := Diagnostics{New(Grammar, "partial parse", "and or"), New(Evaluation, "index out of range", "")}
+	ds.SetClause(2)
+
+	data, err := json.Marshal(ds)
+	a.NoError(err)
+	a.JSONEq(`[
+		{"clause_index":2,"stage":"grammar","reason":"partial parse","text":"and or"},
+		{"clause_index":2,"stage":"evaluation","reason":"index out of range"}
+	]`, string(data))
+	a.Equal(`grammar: partial parse: "and or"`, ds[0].String())
+	a.Equal("evaluation: index out of range", ds[1].String())
+}
@@ -0,0 +1,253 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package formula
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Formula defines a formula that computes a derived variable from other variables.
+type Formula int
+
+const (
+	// Unknown is the formula of variables that are not derived
+	Unknown Formula = iota
+	// CKDEPI estimates the glomerular filtration rate by the 2021 CKD-EPI creatinine equation
+	CKDEPI
+	// MDRD estimates the glomerular filtration rate by the 4-variable MDRD study equation
+	MDRD
+	// CockcroftGault estimates the creatinine clearance by the Cockcroft-Gault equation
+	CockcroftGault
+	// BMI computes the body mass index from weight and height
+	BMI
+	// UPCR computes the urine protein to creatinine ratio
+	UPCR
+	// PFRatio computes the ratio of arterial oxygen partial pressure to fraction of inspired oxygen
+	PFRatio
+)
+
+// Names of the variables that formulas are computed from.
+const (
+	Age             = "age"              // age in years
+	Weight          = "weight"           // weight in kg
+	Height          = "height"           // height in cm
+	Creatinine      = "creatinine_level" // serum creatinine in mg/dl
+	UrineProtein    = "urine_protein"    // urine protein, in the unit of urine creatinine
+	UrineCreatinine = "urine_creatinine" // urine creatinine, in the unit of urine protein
+	PaO2            = "pao2"             // arterial oxygen partial pressure in mmHg
+	FiO2            = "fio2"             // fraction of inspired oxygen as a fraction or a percentage
+)
+
+// methods detect the calculation methods of derived variables in criterion text.
+var methods = []struct {
+	formula Formula
+	re      *regexp.Regexp
+}{
+	{CKDEPI, regexp.MustCompile(`(?i)\bckd[\s-]*epi\b|chronic kidney disease epidemiology`)},
+	{MDRD, regexp.MustCompile(`(?i)\bmdrd\b|modification of diet in renal disease`)},
+	{CockcroftGault, regexp.MustCompile(`(?i)\bcockc?roft[\s-]*(?:and\s+|&\s*)?gault\b|\bc-g\b`)},
+}
+
+// ParseFormula converts a string to a formula. Case, spaces, hyphens, and underscores are ignored.
+func ParseFormula(s string) Formula {
+	s = strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(s))
+	switch s {
+	case "ckdepi":
+		return CKDEPI
+	case "mdrd":
+		return MDRD
+	case "cockcroftgault", "cg":
+		return CockcroftGault
+	case "bmi":
+		return BMI
+	case "upcr":
+		return UPCR
+	case "p/f", "pfratio", "pao2/fio2":
+		return PFRatio
+	default:
+		return Unknown
+	}
+}
+
+// String converts the formula to a string.
+func (f Formula) String() string {
+	switch f {
+	case CKDEPI:
+		return "ckd-epi"
+	case MDRD:
+		return "mdrd"
+	case CockcroftGault:
+		return "cockcroft-gault"
+	case BMI:
+		return "bmi"
+	case UPCR:
+		return "upcr"
+	case PFRatio:
+		return "p/f"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText converts the formula to its string in JSON.
+func (f Formula) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText converts the string in JSON to the formula.
+func (f *Formula) UnmarshalText(text []byte) error {
+	if *f = ParseFormula(string(text)); *f == Unknown {
+		return fmt.Errorf("unknown formula: %q", text)
+	}
+	return nil
+}
+
+// Unit returns the unit of the values that the formula computes.
+func (f Formula) Unit() string {
+	switch f {
+	case CKDEPI, MDRD:
+		return "ml/min/1.73_m2"
+	case CockcroftGault:
+		return "ml/min"
+	case BMI:
+		return "kg/m2"
+	case UPCR:
+		return "mg/mg"
+	case PFRatio:
+		return "mmhg"
+	default:
+		return ""
+	}
+}
+
+// Inputs returns the names of the variables that the formula is computed from.
+func (f Formula) Inputs() []string {
+	switch f {
+	case CKDEPI, MDRD:
+		return []string{Age, Creatinine}
+	case CockcroftGault:
+		return []string{Age, Weight, Creatinine}
+	case BMI:
+		return []string{Weight, Height}
+	case UPCR:
+		return []string{UrineProtein, UrineCreatinine}
+	case PFRatio:
+		return []string{PaO2, FiO2}
+	default:
+		return nil
+	}
+}
+
+// Compatible tests whether the formula g can compute the variable that the formula f computes.
+// The formulas of renal function are compatible because criteria use them interchangeably,
+// e.g., 'creatinine clearance calculated by CKD-EPI'.
+func (f Formula) Compatible(g Formula) bool {
+	if f == Unknown || g == Unknown {
+		return false
+	}
+	return f == g || (f.renal() && g.renal())
+}
+
+// renal tests whether the formula estimates the renal function.
+func (f Formula) renal() bool {
+	return f == CKDEPI || f == MDRD || f == CockcroftGault
+}
+
+// Compute computes the value of the formula from the values of the patient.
+// An error is returned if a value that the formula needs is missing or invalid.
+func (f Formula) Compute(p *Patient) (float64, error) {
+	if f == Unknown {
+		return 0, fmt.Errorf("unknown formula")
+	}
+	inputs := f.Inputs()
+	values := make([]float64, len(inputs))
+	for i, name := range inputs {
+		val, ok := p.Values[name]
+		if !ok {
+			return 0, fmt.Errorf("%s: missing value: %s", f, name)
+		}
+		values[i] = val
+	}
+	if f.renal() && p.Sex == UnknownSex {
+		return 0, fmt.Errorf("%s: missing sex", f)
+	}
+	positive := func(names ...string) error {
+		for _, name := range names {
+			if p.Values[name] <= 0 {
+				return fmt.Errorf("%s: value of %s is not positive: %g", f, name, p.Values[name])
+			}
+		}
+		return nil
+	}
+
+	switch f {
+	case CKDEPI:
+		if err := positive(Age, Creatinine); err != nil {
+			return 0, err
+		}
+		age, scr := values[0], values[1]
+		kappa, alpha, factor := 0.9, -0.302, 1.0
+		if p.Sex == Female {
+			kappa, alpha, factor = 0.7, -0.241, 1.012
+		}
+		r := scr / kappa
+		return 142 * math.Pow(math.Min(r, 1), alpha) * math.Pow(math.Max(r, 1), -1.2) * math.Pow(0.9938, age) * factor, nil
+	case MDRD:
+		if err := positive(Age, Creatinine); err != nil {
+			return 0, err
+		}
+		age, scr := values[0], values[1]
+		factor := 1.0
+		if p.Sex == Female {
+			factor = 0.742
+		}
+		return 175 * math.Pow(scr, -1.154) * math.Pow(age, -0.203) * factor, nil
+	case CockcroftGault:
+		if err := positive(Age, Weight, Creatinine); err != nil {
+			return 0, err
+		}
+		age, weight, scr := values[0], values[1], values[2]
+		factor := 1.0
+		if p.Sex == Female {
+			factor = 0.85
+		}
+		return (140 - age) * weight / (72 * scr) * factor, nil
+	case BMI:
+		if err := positive(Weight, Height); err != nil {
+			return 0, err
+		}
+		weight, height := values[0], values[1]/100
+		return weight / (height * height), nil
+	case UPCR:
+		if err := positive(UrineCreatinine); err != nil {
+			return 0, err
+		}
+		return values[0] / values[1], nil
+	case PFRatio:
+		if err := positive(FiO2); err != nil {
+			return 0, err
+		}
+		pao2, fio2 := values[0], values[1]
+		if fio2 > 1 {
+			fio2 /= 100
+		}
+		return pao2 / fio2, nil
+	}
+	return 0, fmt.Errorf("%s: not implemented", f)
+}
+
+// Detect returns the calculation method that the criterion text specifies for derived
+// variables, such as 'creatinine clearance calculated by Cockcroft-Gault'. If the text
+// specifies several methods, the first one is returned.
+func Detect(text string) Formula {
+	f, first := Unknown, len(text)
+	for _, m := range methods {
+		if loc := m.re.FindStringIndex(text); loc != nil && loc[0] < first {
+			f, first = m.formula, loc[0]
+		}
+	}
+	return f
+}
@@ -0,0 +1,124 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package formula
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormula(t *testing.T) {
+	a := assert.New(t)
+
+	tests := map[string]Formula{
+		"ckd-epi":         CKDEPI,
+		"CKD EPI":         CKDEPI,
+		"mdrd":            MDRD,
+		"Cockcroft-Gault": CockcroftGault,
+		"bmi":             BMI,
+		"upcr":            UPCR,
+		"p/f":             PFRatio,
+		"pf_ratio":        PFRatio,
+		"apache":          Unknown,
+		"":                Unknown,
+	}
+	for input, expected := range tests {
+		a.Equal(expected, ParseFormula(input), input)
+		if expected != Unknown {
+			a.Equal(expected, ParseFormula(expected.String()), input)
+		}
+	}
+
+	var f Formula
+	a.NoError(f.UnmarshalText([]byte("cockcroft-gault")))
+	a.Equal(CockcroftGault, f)
+	a.Error(f.UnmarshalText([]byte("apache")))
+}
+
+func TestCompute(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		formula  Formula
+		patient  *Patient
+		expected float64
+	}{
+		{CKDEPI, NewPatient(Female).Set(Age, 50).Set(Creatinine, 0.8), 89.71},
+		{CKDEPI, NewPatient(Male).Set(Age, 60).Set(Creatinine, 0.7), 105.48},
+		{MDRD, NewPatient(Female).Set(Age, 65).Set(Creatinine, 1.2), 45.09},
+		{CockcroftGault, NewPatient(Male).Set(Age, 70).Set(Weight, 80).Set(Creatinine, 1), 77.78},
+		{CockcroftGault, NewPatient(Female).Set(Age, 70).Set(Weight, 80).Set(Creatinine, 1), 66.11},
+		{BMI, NewPatient(UnknownSex).Set(Weight, 90).Set(Height, 180), 27.78},
+		{UPCR, NewPatient(UnknownSex).Set(UrineProtein, 150).Set(UrineCreatinine, 100), 1.5},
+		{PFRatio, NewPatient(UnknownSex).Set(PaO2, 80).Set(FiO2, 0.4), 200},
+		{PFRatio, NewPatient(UnknownSex).Set(PaO2, 80).Set(FiO2, 40), 200},
+	}
+	for _, test := range tests {
+		actual, err := test.formula.Compute(test.patient)
+		if a.NoError(err, test.formula.String()) {
+			a.InDelta(test.expected, actual, 0.01, test.formula.String())
+		}
+	}
+}
+
+func TestComputeErrors(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		formula Formula
+		patient *Patient
+		reason  string
+	}{
+		{CKDEPI, NewPatient(Female).Set(Age, 50), "missing value: creatinine_level"},
+		{CKDEPI, NewPatient(UnknownSex).Set(Age, 50).Set(Creatinine, 0.8), "missing sex"},
+		{CockcroftGault, NewPatient(Male).Set(Age, 70).Set(Weight, 80).Set(Creatinine, 0), "not positive"},
+		{UPCR, NewPatient(UnknownSex).Set(UrineProtein, 150).Set(UrineCreatinine, 0), "not positive"},
+		{Unknown, NewPatient(Male), "unknown formula"},
+	}
+	for _, test := range tests {
+		_, err := test.formula.Compute(test.patient)
+		if a.Error(err, test.formula.String()) {
+			a.Contains(err.Error(), test.reason, test.formula.String())
+		}
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(CockcroftGault.Compatible(CKDEPI))
+	a.True(CKDEPI.Compatible(MDRD))
+	a.True(BMI.Compatible(BMI))
+	a.False(BMI.Compatible(CKDEPI))
+	a.False(Unknown.Compatible(CKDEPI))
+	a.False(CKDEPI.Compatible(Unknown))
+}
+
+func TestDetect(t *testing.T) {
+	a := assert.New(t)
+
+	tests := map[string]Formula{
+		"creatinine clearance ≥ 60 ml/min calculated by Cockcroft-Gault":     CockcroftGault,
+		"CrCl ≥ 60 ml/min (Cockroft and Gault formula)":                      CockcroftGault,
+		"eGFR ≥ 45 ml/min/1.73 m2 (CKD-EPI)":                                 CKDEPI,
+		"eGFR ≥ 45 by the Chronic Kidney Disease Epidemiology Collaboration": CKDEPI,
+		"eGFR ≥ 45 (MDRD)": MDRD,
+		"eGFR by the Modification of Diet in Renal Disease study equation ≥ 30": MDRD,
+		"eGFR ≥ 45 by MDRD or ≥ 50 by CKD-EPI":                                  MDRD,
+		"eGFR ≥ 45 ml/min/1.73 m2":                                              Unknown,
+		"mdrd4":                                                                 Unknown,
+	}
+	for input, expected := range tests {
+		a.Equal(expected, Detect(input), input)
+	}
+}
+
+func TestParseSex(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(Female, ParseSex("Female"))
+	a.Equal(Male, ParseSex(" m "))
+	a.Equal(UnknownSex, ParseSex("all"))
+	a.Equal("female", Female.String())
+}
@@ -0,0 +1,116 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package formula
+
+import (
+	"strings"
+)
+
+// SexVariable is the name of the variable of the sex of a patient.
+const SexVariable = "sex"
+
+// Sex defines the sex of a patient, which formulas of the renal function depend on.
+type Sex int
+
+const (
+	// UnknownSex is the sex of patients whose sex is not known
+	UnknownSex Sex = iota
+	// Female sex
+	Female
+	// Male sex
+	Male
+)
+
+// ParseSex converts a string to a sex.
+func ParseSex(s string) Sex {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "female", "f", "woman", "women":
+		return Female
+	case "male", "m", "man", "men":
+		return Male
+	default:
+		return UnknownSex
+	}
+}
+
+// String converts the sex to a string.
+func (s Sex) String() string {
+	switch s {
+	case Female:
+		return "female"
+	case Male:
+		return "male"
+	default:
+		return "unknown"
+	}
+}
+
+// Patient defines the raw values of a patient that derived variables are computed from.
+// Values are by variable name in the units that the formulas use, e.g., age in years and
+// serum creatinine in mg/dl, and in the default units of other variables or their first allowed
+// units. Baselines are the values at baseline, which bounds relative to the baseline, such as
+// 'ALT > 3× baseline', are relative to. ULN and LLN are the upper and lower limits of normal of
+// the laboratory that measured the values, which bounds such as 'ALT < 3× ULN' are relative to.
+// Categories are the values of boolean, nominal, and ordinal variables, e.g., 'pregnant': 'no'.
+type Patient struct {
+	Sex        Sex
+	Values     map[string]float64
+	Baselines  map[string]float64
+	ULN        map[string]float64
+	LLN        map[string]float64
+	Categories map[string]string
+}
+
+// NewPatient creates a new patient of the sex without values.
+func NewPatient(sex Sex) *Patient {
+	return &Patient{
+		Sex:        sex,
+		Values:     make(map[string]float64),
+		Baselines:  make(map[string]float64),
+		ULN:        make(map[string]float64),
+		LLN:        make(map[string]float64),
+		Categories: make(map[string]string),
+	}
+}
+
+// Set sets the value of the variable.
+func (p *Patient) Set(name string, val float64) *Patient {
+	p.Values[name] = val
+	return p
+}
+
+// SetBaseline sets the baseline value of the variable.
+func (p *Patient) SetBaseline(name string, val float64) *Patient {
+	p.Baselines[name] = val
+	return p
+}
+
+// SetULN sets the upper limit of normal of the variable.
+func (p *Patient) SetULN(name string, val float64) *Patient {
+	p.ULN[name] = val
+	return p
+}
+
+// SetLLN sets the lower limit of normal of the variable.
+func (p *Patient) SetLLN(name string, val float64) *Patient {
+	p.LLN[name] = val
+	return p
+}
+
+// SetCategory sets the value of the boolean, nominal, or ordinal variable.
+func (p *Patient) SetCategory(name, val string) *Patient {
+	p.Categories[name] = val
+	return p
+}
+
+// Category returns the value of the boolean, nominal, or ordinal variable. The value of
+// the sex variable is the sex of the patient unless it is set as a category.
+func (p *Patient) Category(name string) (string, bool) {
+	if val, ok := p.Categories[name]; ok {
+		return val, true
+	}
+	if name == SexVariable && p.Sex != UnknownSex {
+		return p.Sex.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,303 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+// Package lint checks the variable and unit catalogs for mistakes that
+// silently degrade parsing, e.g., unknown units and colliding aliases.
+package lint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+)
+
+// Catalog names used in issues.
+const (
+	VariableCatalog = "variable"
+	UnitCatalog     = "unit"
+)
+
+// Issue defines a problem of a catalog entry.
+type Issue struct {
+	Catalog string // VariableCatalog or UnitCatalog
+	ID      string
+	Name    string
+	Message string
+}
+
+// String returns the string representation of the issue.
+func (i Issue) String() string {
+	return fmt.Sprintf("%s %s (%s): %s", i.Catalog, i.ID, i.Name, i.Message)
+}
+
+// Issues defines a slice of issues.
+type Issues []Issue
+
+// String returns the issues, one per line.
+func (is Issues) String() string {
+	var b strings.Builder
+	for _, i := range is {
+		b.WriteString(i.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Lint checks the variable and unit catalogs. It checks that units referenced by
+// variables and variables referenced by units exist, that numerical bounds are ordered,
+// that ordinal ranges are ascending integers, and that every alias resolves to its own
+// entry, i.e., it does not collide with an alias of another entry, it is not shadowed
+// by a wildcard alias, and it is not an alias in the other catalog.
+func Lint(vs *variables.Variables, us *units.Units) Issues {
+	var issues Issues
+	issues = append(issues, lintVariables(vs, us)...)
+	issues = append(issues, lintUnits(vs, us)...)
+	return issues
+}
+
+// References checks that units referenced by variables and variables referenced by units exist.
+// Catalogs with reference issues should not be used for parsing.
+func References(vs *variables.Variables, us *units.Units) Issues {
+	var issues Issues
+	for _, v := range vs.All() {
+		for _, msg := range variableReferences(v, us) {
+			issues = append(issues, Issue{Catalog: VariableCatalog, ID: string(v.ID), Name: v.Name, Message: msg})
+		}
+	}
+	for _, u := range us.All() {
+		for _, msg := range unitReferences(u, vs) {
+			issues = append(issues, Issue{Catalog: UnitCatalog, ID: string(u.ID), Name: u.Name, Message: msg})
+		}
+	}
+	return issues
+}
+
+// variableReferences checks that the units of the variable exist.
+func variableReferences(v *variables.Variable, us *units.Units) []string {
+	var msgs []string
+	if len(v.UnitName) > 0 {
+		if _, ok := us.ID(v.UnitName); !ok {
+			msgs = append(msgs, fmt.Sprintf("unknown default unit: %q", v.UnitName))
+		}
+	}
+	for _, u := range v.Units {
+		if _, ok := us.ID(u.Unit); !ok {
+			msgs = append(msgs, fmt.Sprintf("unknown allowed unit: %q", u.Unit))
+		}
+	}
+	return msgs
+}
+
+// unitReferences checks that the variable of the unit exists.
+func unitReferences(u *units.Unit, vs *variables.Variables) []string {
+	if len(u.VName) > 0 {
+		if _, ok := vs.ID(u.VName); !ok {
+			return []string{fmt.Sprintf("unknown variable: %q", u.VName)}
+		}
+	}
+	return nil
+}
+
+func lintVariables(vs *variables.Variables, us *units.Units) Issues {
+	var issues Issues
+	wildcards := make(map[string]string)
+	for _, v := range vs.All() {
+		for _, a := range vs.Aliases(v.ID) {
+			if strings.Contains(a, "*") {
+				wildcards[a] = v.Name
+			}
+		}
+	}
+
+	for _, v := range vs.All() {
+		report := func(format string, a ...interface{}) {
+			issues = append(issues, Issue{Catalog: VariableCatalog, ID: string(v.ID), Name: v.Name, Message: fmt.Sprintf(format, a...)})
+		}
+
+		for _, msg := range variableReferences(v, us) {
+			report("%s", msg)
+		}
+
+		switch v.Kind {
+		case variables.Numerical, variables.Count:
+			if bounds := nonEmpty(v.Range); len(bounds) > 0 {
+				report("%s bounds must be a min and a max: %v", v.Kind, bounds)
+			}
+			if len(v.NumRange) == 2 && v.NumRange[0] > v.NumRange[1] {
+				report("bounds out of order: %g > %g", v.NumRange[0], v.NumRange[1])
+			}
+		case variables.Ordinal:
+			for _, msg := range lintOrdinalRange(nonEmpty(v.Range)) {
+				report("%s", msg)
+			}
+		case variables.Unknown:
+			report("unknown variable type")
+		}
+
+		if v.ID == variables.Zero || v.Deprecated {
+			continue
+		}
+		if len(v.Extractor) > 0 && len(nonEmpty(vs.Aliases(v.ID))) == 0 {
+			// Variables set by an extractor are not matched by aliases.
+			continue
+		}
+		for _, msg := range lintAliases(vs.Aliases(v.ID), v.Name, vs.Get, wildcards) {
+			report("%s", msg)
+		}
+		for _, a := range nonEmpty(vs.Aliases(v.ID)) {
+			if name, ok := us.Get(text.CustomizeSlash(a)[0]); ok {
+				report("alias %q is also an alias of unit %q", a, name)
+			}
+		}
+	}
+	return issues
+}
+
+func lintUnits(vs *variables.Variables, us *units.Units) Issues {
+	var issues Issues
+	wildcards := make(map[string]string)
+	for _, u := range us.All() {
+		for _, a := range us.Aliases(u.ID) {
+			if strings.Contains(a, "*") {
+				wildcards[a] = u.Name
+			}
+		}
+	}
+
+	for _, u := range us.All() {
+		report := func(format string, a ...interface{}) {
+			issues = append(issues, Issue{Catalog: UnitCatalog, ID: string(u.ID), Name: u.Name, Message: fmt.Sprintf(format, a...)})
+		}
+
+		if strings.Contains(u.Display, "*") {
+			report("display name has a wildcard: %q", u.Display)
+		}
+		for _, msg := range unitReferences(u, vs) {
+			report("%s", msg)
+		}
+		for _, msg := range lintAliases(us.Aliases(u.ID), u.Name, us.Get, wildcards) {
+			report("%s", msg)
+		}
+	}
+	return issues
+}
+
+// lintOrdinalRange checks that the ordinal range consists of ascending integers.
+func lintOrdinalRange(values []string) []string {
+	if len(values) == 0 {
+		return []string{"ordinal variable has no value range"}
+	}
+	var msgs []string
+	prev := 0
+	for i, val := range values {
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("ordinal value is not an integer: %q", val))
+			continue
+		}
+		if i > 0 && n <= prev {
+			msgs = append(msgs, fmt.Sprintf("ordinal values are not ascending: %v", values))
+			break
+		}
+		prev = n
+	}
+	return msgs
+}
+
+// lintAliases checks that the aliases of the named entry resolve to the entry with the get function
+// of the catalog. Wildcard aliases map to their entry names and identify aliases that they shadow.
+// Aliases that resolve to the same other entry are reported together.
+func lintAliases(aliases []string, name string, get func(string) (string, bool), wildcards map[string]string) []string {
+	var msgs []string
+	if len(nonEmpty(aliases)) == 0 {
+		return []string{"no aliases"}
+	}
+
+	var keys []string
+	conflicts := make(map[string][]string)
+	conflict := func(key, alias string) {
+		if _, ok := conflicts[key]; !ok {
+			keys = append(keys, key)
+		}
+		conflicts[key] = append(conflicts[key], alias)
+	}
+	for _, a := range aliases {
+		switch {
+		case len(strings.TrimSpace(a)) == 0:
+			msgs = append(msgs, "empty alias")
+			continue
+		case a != strings.TrimSpace(a):
+			msgs = append(msgs, fmt.Sprintf("alias has surrounding whitespace: %q", a))
+		case strings.IndexFunc(a, unicode.IsUpper) >= 0:
+			msgs = append(msgs, fmt.Sprintf("alias has uppercase letters and is not matched in lowercased criteria: %q", a))
+			continue
+		}
+		if strings.Contains(a, "*") {
+			continue
+		}
+		other, ok := get(text.CustomizeSlash(a)[0])
+		switch {
+		case ok && other == name:
+		case ok:
+			if w, owner := shadowingWildcard(a, other, wildcards); len(w) > 0 {
+				conflict(fmt.Sprintf("aliases are shadowed by wildcard alias %q of %q", w, owner), a)
+			} else {
+				conflict(fmt.Sprintf("aliases collide with aliases of %q", other), a)
+			}
+		default:
+			if w, owner := shadowingWildcard(a, "", wildcards); len(w) > 0 {
+				conflict(fmt.Sprintf("aliases are shadowed by wildcard alias %q of %q", w, owner), a)
+			} else {
+				conflict("aliases are not matched", a)
+			}
+		}
+	}
+	for _, key := range keys {
+		msgs = append(msgs, fmt.Sprintf("%s (%d): %s", key, len(conflicts[key]), examples(conflicts[key])))
+	}
+	return msgs
+}
+
+// examples returns the first few quoted strings of v.
+func examples(v []string) string {
+	const n = 3
+	quoted := make([]string, 0, n)
+	for i := 0; i < len(v) && i < n; i++ {
+		quoted = append(quoted, strconv.Quote(v[i]))
+	}
+	if len(v) > n {
+		quoted = append(quoted, "...")
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// shadowingWildcard returns a wildcard alias whose prefix before the wildcard is a prefix
+// of the alias, and the name of its entry. If owner is not empty, the entry must be owner.
+func shadowingWildcard(alias, owner string, wildcards map[string]string) (string, string) {
+	best, bestOwner := "", ""
+	for w, name := range wildcards {
+		if len(owner) > 0 && name != owner {
+			continue
+		}
+		prefix := w[:strings.Index(w, "*")]
+		if strings.HasPrefix(alias, prefix) && (len(best) == 0 || w < best) {
+			best, bestOwner = w, name
+		}
+	}
+	return best, bestOwner
+}
+
+// nonEmpty returns the non-empty trimmed strings of v.
+func nonEmpty(v []string) []string {
+	var w []string
+	for _, s := range v {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			w = append(w, s)
+		}
+	}
+	return w
+}
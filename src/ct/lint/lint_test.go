@@ -0,0 +1,74 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package lint
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func messages(issues Issues) []string {
+	var msgs []string
+	for _, i := range issues {
+		msgs = append(msgs, i.String())
+	}
+	return msgs
+}
+
+func TestLintClean(t *testing.T) {
+	a := assert.New(t)
+
+	vs := variables.New()
+	a.NoError(vs.Add("100", variables.Ordinal, "ecog", "ECOG", []string{"ecog"}, []string{"0", "1", "2"}, "", ""))
+	a.NoError(vs.Add("200", variables.Numerical, "age", "Age", []string{"age", "aged"}, []string{"0", "120"}, "year", ""))
+	a.NoError(vs.Add("212", variables.Boolean, "pregnant", "Pregnant", nil, []string{"yes", "no"}, "", ""))
+	vs.Variable("212").Extractor = "reproductive"
+	us := units.New()
+	a.NoError(us.Add("306", "year", "year", []string{"year*"}, ""))
+
+	a.Empty(Lint(vs, us))
+}
+
+func TestLint(t *testing.T) {
+	a := assert.New(t)
+
+	vs := variables.New()
+	a.NoError(vs.Add("100", variables.Ordinal, "ecog", "ECOG", []string{"ecog"}, []string{"0", "2", "1"}, "", ""))
+	a.NoError(vs.Add("101", variables.Ordinal, "nyha", "NYHA", []string{"nyha"}, []string{"i", "ii"}, "", ""))
+	a.NoError(vs.Add("200", variables.Numerical, "bp", "BP", []string{"SBP/DBP", "bp"}, []string{"300", "10"}, "mmgh", ""))
+	a.NoError(vs.Add("201", variables.Numerical, "weight", "Weight", []string{"weigh*"}, nil, "kg", ""))
+	a.NoError(vs.Add("202", variables.Numerical, "weight_loss", "Weight loss", []string{"weight loss"}, nil, "", ""))
+	a.NoError(vs.Add("203", variables.Numerical, "blood_pressure", "Blood pressure", []string{"bp", ""}, nil, "", ""))
+	a.NoError(vs.Add("204", variables.Numerical, "mass", "Mass", []string{"kg"}, nil, "", ""))
+	us := units.New()
+	a.NoError(us.Add("200", "kg", "kg", []string{"kg"}, "weight"))
+	a.NoError(us.Add("305", "month", "month*", []string{"month"}, "duration"))
+
+	expected := []string{
+		`variable 100 (ecog): ordinal values are not ascending: [0 2 1]`,
+		`variable 101 (nyha): ordinal value is not an integer: "i"`,
+		`variable 101 (nyha): ordinal value is not an integer: "ii"`,
+		`variable 200 (bp): unknown default unit: "mmgh"`,
+		`variable 200 (bp): bounds out of order: 300 > 10`,
+		`variable 200 (bp): alias has uppercase letters and is not matched in lowercased criteria: "SBP/DBP"`,
+		`variable 200 (bp): aliases collide with aliases of "blood_pressure" (1): "bp"`,
+		`variable 202 (weight_loss): aliases are shadowed by wildcard alias "weigh*" of "weight" (1): "weight loss"`,
+		`variable 203 (blood_pressure): empty alias`,
+		`variable 204 (mass): alias "kg" is also an alias of unit "kg"`,
+		`unit 305 (month): display name has a wildcard: "month*"`,
+		`unit 305 (month): unknown variable: "duration"`,
+	}
+	a.Equal(expected, messages(Lint(vs, us)))
+}
+
+func TestLintDefaultCatalogsUnits(t *testing.T) {
+	a := assert.New(t)
+
+	for _, msg := range messages(Lint(variables.DefaultCatalog(), units.DefaultCatalog())) {
+		a.NotContains(msg, "unknown", msg)
+	}
+}
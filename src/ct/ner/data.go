@@ -0,0 +1,161 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// Outside is the tag of tokens outside of entities.
+	Outside = "O"
+
+	beginPrefix  = "B-"
+	insidePrefix = "I-"
+)
+
+// Sentence defines a tokenized sentence with BIO tags.
+type Sentence struct {
+	Tokens []string
+	Tags   []string
+}
+
+// Sentences defines a slice of sentences.
+type Sentences []Sentence
+
+// span defines a labeled character span [lo, hi) in the text.
+type span struct {
+	lo, hi int
+	label  string
+}
+
+// ParseSentence parses a sentence from the NER data format: labels and text.
+// The labels are comma-separated 'start:end:label' spans with 1-based start
+// and end positions, and the text is tokenized and separated by spaces.
+func ParseSentence(labels, text string) (Sentence, error) {
+	var spans []span
+	for _, v := range strings.Split(labels, ",") {
+		if v = strings.TrimSpace(v); len(v) == 0 {
+			continue
+		}
+		fields := strings.SplitN(v, ":", 3)
+		if len(fields) != 3 {
+			return Sentence{}, fmt.Errorf("bad label: %q", v)
+		}
+		start, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return Sentence{}, fmt.Errorf("bad label start: %q", v)
+		}
+		end, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return Sentence{}, fmt.Errorf("bad label end: %q", v)
+		}
+		spans = append(spans, span{lo: start - 1, hi: end - 1, label: fields[2]})
+	}
+
+	s := Sentence{}
+	pos := 0
+	prev := -1
+	for _, token := range strings.Split(text, " ") {
+		if len(token) == 0 {
+			pos++
+			continue
+		}
+		lo, hi := pos, pos+utf8.RuneCountInString(token) // Positions count characters
+		pos = hi + 1
+		tag := Outside
+		for i, sp := range spans {
+			if lo >= sp.lo && lo < sp.hi {
+				if i == prev {
+					tag = insidePrefix + sp.label
+				} else {
+					tag = beginPrefix + sp.label
+				}
+				prev = i
+				break
+			}
+		}
+		if tag == Outside {
+			prev = -1
+		}
+		s.Tokens = append(s.Tokens, token)
+		s.Tags = append(s.Tags, tag)
+	}
+	return s, nil
+}
+
+// LoadSentences loads sentences from a file in the NER data format.
+func LoadSentences(fname string) (Sentences, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sentences := make(Sentences, 0)
+	scanner := bufio.NewScanner(file)
+	lineCnt := 0
+	for scanner.Scan() {
+		lineCnt++
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		values := strings.SplitN(line, "\t", 2)
+		if len(values) != 2 {
+			return nil, fmt.Errorf("%s: expected two columns: line %d", fname, lineCnt)
+		}
+		s, err := ParseSentence(values[0], values[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %v", fname, lineCnt, err)
+		}
+		if len(s.Tokens) > 0 {
+			sentences = append(sentences, s)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sentences, nil
+}
+
+// Entity defines a labeled token span [Start, End) of a sentence.
+type Entity struct {
+	Label      string
+	Start, End int
+}
+
+// Entities decodes the entities from the BIO tags.
+func Entities(tags []string) []Entity {
+	var entities []Entity
+	for i := 0; i < len(tags); i++ {
+		label := Label(tags[i])
+		if len(label) == 0 {
+			continue
+		}
+		j := i + 1
+		for j < len(tags) && tags[j] == insidePrefix+label {
+			j++
+		}
+		entities = append(entities, Entity{Label: label, Start: i, End: j})
+		i = j - 1
+	}
+	return entities
+}
+
+// Label returns the entity label of the tag or an empty string for the outside tag.
+func Label(tag string) string {
+	switch {
+	case strings.HasPrefix(tag, beginPrefix):
+		return tag[len(beginPrefix):]
+	case strings.HasPrefix(tag, insidePrefix):
+		return tag[len(insidePrefix):]
+	default:
+		return ""
+	}
+}
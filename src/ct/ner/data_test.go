@@ -0,0 +1,38 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSentence(t *testing.T) {
+	a := assert.New(t)
+
+	s, err := ParseSentence("1:25:treatment,37:60:upper_bound", "coronary stent placement within the previous @NUMBER months")
+	a.NoError(err)
+	a.Equal([]string{"coronary", "stent", "placement", "within", "the", "previous", "@NUMBER", "months"}, s.Tokens)
+	a.Equal([]string{"B-treatment", "I-treatment", "I-treatment", "O", "O", "B-upper_bound", "I-upper_bound", "I-upper_bound"}, s.Tags)
+
+	s, err = ParseSentence("1:6:gender,45:63:pregnancy", "women of childbearing potential must have a negative pregnancy test")
+	a.NoError(err)
+	a.Equal([]string{"B-gender", "O", "O", "O", "O", "O", "O", "B-pregnancy", "I-pregnancy", "O"}, s.Tags)
+
+	_, err = ParseSentence("1:6", "women")
+	a.Error(err)
+}
+
+func TestEntities(t *testing.T) {
+	a := assert.New(t)
+
+	tags := []string{"B-cancer", "I-cancer", "O", "B-treatment", "B-treatment", "I-treatment", "I-cancer"}
+	expected := []Entity{
+		{Label: "cancer", Start: 0, End: 2},
+		{Label: "treatment", Start: 3, End: 4},
+		{Label: "treatment", Start: 4, End: 6},
+		{Label: "cancer", Start: 6, End: 7},
+	}
+	a.Equal(expected, Entities(tags))
+}
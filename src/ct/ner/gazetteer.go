@@ -0,0 +1,146 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/taxonomy"
+)
+
+// maxPhraseLen is the maximum number of tokens in a gazetteer phrase.
+const maxPhraseLen = 8
+
+// Gazetteer maps tokenized phrases to entity labels.
+type Gazetteer struct {
+	Phrases map[string]string `json:"phrases"`
+}
+
+// NewGazetteer creates a new empty gazetteer.
+func NewGazetteer() *Gazetteer {
+	return &Gazetteer{Phrases: make(map[string]string)}
+}
+
+// Add adds the phrase with the label. An existing label of the phrase is not replaced.
+func (g *Gazetteer) Add(phrase, label string) {
+	tokens := Tokenize(phrase)
+	if len(tokens) == 0 || len(tokens) > maxPhraseLen || len(label) == 0 {
+		return
+	}
+	key := strings.Join(tokens, " ")
+	if _, ok := g.Phrases[key]; !ok {
+		g.Phrases[key] = label
+	}
+}
+
+// Size returns the number of phrases in the gazetteer.
+func (g *Gazetteer) Size() int {
+	return len(g.Phrases)
+}
+
+// AddSentences adds the entities of the sentences to the gazetteer.
+func (g *Gazetteer) AddSentences(sentences Sentences) {
+	for _, s := range sentences {
+		for _, e := range Entities(s.Tags) {
+			if e.End-e.Start <= maxPhraseLen {
+				key := strings.Join(s.Tokens[e.Start:e.End], " ")
+				if _, ok := g.Phrases[key]; !ok {
+					g.Phrases[key] = e.Label
+				}
+			}
+		}
+	}
+}
+
+// LabelRule maps concepts with a tree number prefix to an entity label.
+type LabelRule struct {
+	Prefix string
+	Label  string
+}
+
+// LabelRules defines a slice of label rules.
+type LabelRules []LabelRule
+
+// ParseLabelRules parses label rules from a string of the form
+// "C04=cancer,C=chronic_disease,D|E02=treatment".
+func ParseLabelRules(s string) LabelRules {
+	var rules LabelRules
+	for _, v := range strings.Split(s, ",") {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		label := strings.TrimSpace(kv[1])
+		for _, prefix := range strings.Split(kv[0], "|") {
+			if prefix = strings.TrimSpace(prefix); len(prefix) > 0 && len(label) > 0 {
+				rules = append(rules, LabelRule{Prefix: prefix, Label: label})
+			}
+		}
+	}
+	return rules
+}
+
+// Label returns the label of the longest rule prefix that matches any of the tree numbers.
+func (rs LabelRules) Label(treeNumbers []string) string {
+	label := ""
+	longest := 0
+	for _, r := range rs {
+		for _, tn := range treeNumbers {
+			if strings.HasPrefix(tn, r.Prefix) && len(r.Prefix) > longest {
+				label = r.Label
+				longest = len(r.Prefix)
+			}
+		}
+	}
+	return label
+}
+
+// AddTaxonomy adds the synonyms of the taxonomy descriptors to the gazetteer.
+// Descriptors are labeled by the rules applied to their tree numbers. The taxonomy
+// must not be normalized, because the gazetteer phrases are tokenized from the original synonyms.
+func (g *Gazetteer) AddTaxonomy(t *taxonomy.Taxonomy, rules LabelRules) {
+	for _, n := range t.Descriptors() {
+		treeNumbers := n.TreeNumbers().Slice()
+		label := rules.Label(treeNumbers)
+		if len(label) == 0 {
+			continue
+		}
+		synonyms := n.Synonyms().Slice()
+		sort.Strings(synonyms)
+		g.Add(n.Name(), label)
+		for _, s := range synonyms {
+			g.Add(s, label)
+		}
+	}
+}
+
+// Tag tags the tokens with the longest gazetteer phrases that match them.
+// Tokens that are not in any phrase are tagged with Outside.
+func (g *Gazetteer) Tag(tokens []string) []string {
+	tags := make([]string, len(tokens))
+	for i := 0; i < len(tokens); {
+		n := 0
+		label := ""
+		for k := maxPhraseLen; k > 0; k-- {
+			if i+k > len(tokens) {
+				continue
+			}
+			if l, ok := g.Phrases[strings.Join(tokens[i:i+k], " ")]; ok {
+				n, label = k, l
+				break
+			}
+		}
+		if n == 0 {
+			tags[i] = Outside
+			i++
+			continue
+		}
+		tags[i] = beginPrefix + label
+		for j := i + 1; j < i+n; j++ {
+			tags[j] = insidePrefix + label
+		}
+		i += n
+	}
+	return tags
+}
@@ -0,0 +1,322 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// startTag is the previous tag of the first token.
+const startTag = "<s>"
+
+// Model defines an averaged perceptron sequence tagger with greedy left-to-right
+// decoding. Token features include the tags of the gazetteer phrases.
+type Model struct {
+	Tags      []string             `json:"tags"`
+	Weights   map[string][]float64 `json:"weights"`
+	Gazetteer *Gazetteer           `json:"gazetteer"`
+
+	tagIndex map[string]int
+
+	// Training state for averaging the weights:
+	totals  map[string][]float64
+	stamps  map[string][]int
+	updates int
+}
+
+// NewModel creates a new model with the gazetteer.
+func NewModel(g *Gazetteer) *Model {
+	if g == nil {
+		g = NewGazetteer()
+	}
+	return &Model{
+		Weights:   make(map[string][]float64),
+		Gazetteer: g,
+		tagIndex:  make(map[string]int),
+	}
+}
+
+// Train trains the model on the sentences for the number of epochs.
+// The sentences are shuffled each epoch with the seed.
+func (m *Model) Train(sentences Sentences, epochs int, seed int64) {
+	m.initTags(sentences)
+	m.totals = make(map[string][]float64)
+	m.stamps = make(map[string][]int)
+	m.updates = 0
+
+	order := make([]int, len(sentences))
+	for i := range order {
+		order[i] = i
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	for e := 0; e < epochs; e++ {
+		rnd.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		for _, i := range order {
+			m.trainSentence(sentences[i])
+		}
+	}
+	m.average()
+}
+
+// initTags collects the tags of the sentences.
+func (m *Model) initTags(sentences Sentences) {
+	tags := map[string]bool{Outside: true}
+	for _, s := range sentences {
+		for _, t := range s.Tags {
+			tags[t] = true
+		}
+	}
+	m.Tags = m.Tags[:0]
+	for t := range tags {
+		m.Tags = append(m.Tags, t)
+	}
+	sort.Strings(m.Tags)
+	m.index()
+}
+
+func (m *Model) index() {
+	m.tagIndex = make(map[string]int, len(m.Tags))
+	for i, t := range m.Tags {
+		m.tagIndex[t] = i
+	}
+}
+
+// trainSentence updates the weights with the errors of the greedy decoding of the sentence.
+func (m *Model) trainSentence(s Sentence) {
+	gazTags := m.Gazetteer.Tag(s.Tokens)
+	prev, prev2 := startTag, startTag
+	for i := range s.Tokens {
+		features := m.features(s.Tokens, gazTags, i, prev, prev2)
+		scores := m.scores(features)
+		guess := m.best(scores, prev)
+		truth := m.tagIndex[s.Tags[i]]
+		m.updates++
+		if guess != truth {
+			for _, f := range features {
+				m.update(f, truth, 1)
+				m.update(f, guess, -1)
+			}
+		}
+		// Condition on the gold history as in standard greedy training:
+		prev2, prev = prev, s.Tags[i]
+	}
+}
+
+// update adds the value to the weight of the feature and tag and
+// accumulates the weight totals for averaging.
+func (m *Model) update(f string, tag int, v float64) {
+	w, ok := m.Weights[f]
+	if !ok {
+		w = make([]float64, len(m.Tags))
+		m.Weights[f] = w
+		m.totals[f] = make([]float64, len(m.Tags))
+		m.stamps[f] = make([]int, len(m.Tags))
+	}
+	m.totals[f][tag] += float64(m.updates-m.stamps[f][tag]) * w[tag]
+	m.stamps[f][tag] = m.updates
+	w[tag] += v
+}
+
+// average replaces the weights with their averages over all updates.
+func (m *Model) average() {
+	if m.updates == 0 {
+		return
+	}
+	for f, w := range m.Weights {
+		zero := true
+		for tag := range w {
+			total := m.totals[f][tag] + float64(m.updates-m.stamps[f][tag])*w[tag]
+			w[tag] = total / float64(m.updates)
+			if math.Abs(w[tag]) > 1e-6 {
+				zero = false
+			}
+		}
+		if zero {
+			delete(m.Weights, f)
+		}
+	}
+	m.totals, m.stamps = nil, nil
+}
+
+// Predict tags the tokens and returns the tags and their confidence scores.
+func (m *Model) Predict(tokens []string) ([]string, []float64) {
+	tags := make([]string, len(tokens))
+	confidences := make([]float64, len(tokens))
+	if len(m.Tags) == 0 {
+		for i := range tags {
+			tags[i] = Outside
+		}
+		return tags, confidences
+	}
+	gazTags := m.Gazetteer.Tag(tokens)
+	prev, prev2 := startTag, startTag
+	for i := range tokens {
+		features := m.features(tokens, gazTags, i, prev, prev2)
+		scores := m.scores(features)
+		k := m.best(scores, prev)
+		tags[i] = m.Tags[k]
+		confidences[i] = softmax(scores, k, m.allowed(prev))
+		prev2, prev = prev, tags[i]
+	}
+	return tags, confidences
+}
+
+// scores computes the tag scores of the features.
+func (m *Model) scores(features []string) []float64 {
+	scores := make([]float64, len(m.Tags))
+	for _, f := range features {
+		if w, ok := m.Weights[f]; ok {
+			for i, v := range w {
+				scores[i] += v
+			}
+		}
+	}
+	return scores
+}
+
+// best returns the highest scoring tag that may follow the previous tag.
+func (m *Model) best(scores []float64, prev string) int {
+	allowed := m.allowed(prev)
+	k := m.tagIndex[Outside]
+	for i, s := range scores {
+		if allowed[i] && (s > scores[k] || (s == scores[k] && m.Tags[i] < m.Tags[k])) {
+			k = i
+		}
+	}
+	return k
+}
+
+// allowed returns the tags that may follow the previous tag:
+// an inside tag must continue an entity with the same label.
+func (m *Model) allowed(prev string) []bool {
+	allowed := make([]bool, len(m.Tags))
+	for i, t := range m.Tags {
+		allowed[i] = !strings.HasPrefix(t, insidePrefix) || Label(prev) == Label(t)
+	}
+	return allowed
+}
+
+// softmax returns the softmax probability of the k-th score among the allowed scores.
+func softmax(scores []float64, k int, allowed []bool) float64 {
+	max := scores[k]
+	sum := 0.0
+	for i, s := range scores {
+		if allowed[i] {
+			sum += math.Exp(s - max)
+		}
+	}
+	return 1 / sum
+}
+
+// features returns the features of the i-th token.
+func (m *Model) features(tokens, gazTags []string, i int, prev, prev2 string) []string {
+	word := tokens[i]
+	token := func(j int) string {
+		switch {
+		case j < 0:
+			return "<s>"
+		case j >= len(tokens):
+			return "</s>"
+		default:
+			return tokens[j]
+		}
+	}
+	return []string{
+		"bias",
+		"w=" + word,
+		"p3=" + prefix(word, 3),
+		"s3=" + suffix(word, 3),
+		"sh=" + shape(word),
+		"w-1=" + token(i-1),
+		"w+1=" + token(i+1),
+		"w-2=" + token(i-2),
+		"w+2=" + token(i+2),
+		"w-1w=" + token(i-1) + "|" + word,
+		"ww+1=" + word + "|" + token(i+1),
+		"t-1=" + prev,
+		"t-2t-1=" + prev2 + "|" + prev,
+		"t-1w=" + prev + "|" + word,
+		"g=" + gazTags[i],
+		"g-1=" + gazTag(gazTags, i-1),
+		"g+1=" + gazTag(gazTags, i+1),
+	}
+}
+
+func gazTag(tags []string, i int) string {
+	if i < 0 || i >= len(tags) {
+		return Outside
+	}
+	return tags[i]
+}
+
+func prefix(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		r = r[:n]
+	}
+	return string(r)
+}
+
+func suffix(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		r = r[len(r)-n:]
+	}
+	return string(r)
+}
+
+// shape maps letters to 'a' and digits to '0' collapsing repeated characters.
+func shape(s string) string {
+	var b strings.Builder
+	var last rune
+	for _, c := range s {
+		switch {
+		case unicode.IsLetter(c):
+			c = 'a'
+		case unicode.IsDigit(c):
+			c = '0'
+		}
+		if c != last {
+			b.WriteRune(c)
+			last = c
+		}
+	}
+	return b.String()
+}
+
+// Save saves the model to a JSON file.
+func (m *Model) Save(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(m); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadModel loads a model from a JSON file.
+func LoadModel(fname string) (*Model, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	m := NewModel(nil)
+	if err := json.NewDecoder(file).Decode(m); err != nil {
+		return nil, err
+	}
+	if m.Gazetteer == nil || m.Gazetteer.Phrases == nil {
+		m.Gazetteer = NewGazetteer()
+	}
+	m.index()
+	return m, nil
+}
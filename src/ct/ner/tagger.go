@@ -0,0 +1,110 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"strings"
+)
+
+// SlotPrefix prefixes the labels of the detected slots, as in the output of the PyText NER model.
+const SlotPrefix = "word_scores:"
+
+// Slot defines a detected entity: its score and text.
+// It is serialized as a [score, text] pair.
+type Slot [2]interface{}
+
+// NewSlot creates a new slot.
+func NewSlot(score float64, text string) Slot {
+	return Slot{score, text}
+}
+
+// DetectedSlots maps slot labels to the detected slots.
+type DetectedSlots map[string][]Slot
+
+// Tagger tags criterion text with entity labels.
+type Tagger struct {
+	model *Model
+}
+
+// NewTagger creates a new tagger with the model.
+func NewTagger(m *Model) *Tagger {
+	return &Tagger{model: m}
+}
+
+// Tag detects the entities of the text. Each entity is scored by the mean
+// confidence of its tokens. Numbers in the entity text are not masked.
+func (t *Tagger) Tag(text string) DetectedSlots {
+	words := split(text)
+	tokens := make([]string, len(words))
+	for i, w := range words {
+		tokens[i] = maskNumber(w)
+	}
+	tags, confidences := t.model.Predict(tokens)
+
+	slots := make(DetectedSlots)
+	for _, e := range Entities(tags) {
+		score := 0.0
+		for i := e.Start; i < e.End; i++ {
+			score += confidences[i]
+		}
+		score /= float64(e.End - e.Start)
+		label := SlotPrefix + e.Label
+		slots[label] = append(slots[label], NewSlot(score, strings.Join(words[e.Start:e.End], " ")))
+	}
+	return slots
+}
+
+// Evaluation defines the span-level evaluation metrics.
+type Evaluation struct {
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+}
+
+// Precision returns the precision of the detected entities.
+func (e Evaluation) Precision() float64 {
+	if e.TruePositives+e.FalsePositives == 0 {
+		return 0
+	}
+	return float64(e.TruePositives) / float64(e.TruePositives+e.FalsePositives)
+}
+
+// Recall returns the recall of the detected entities.
+func (e Evaluation) Recall() float64 {
+	if e.TruePositives+e.FalseNegatives == 0 {
+		return 0
+	}
+	return float64(e.TruePositives) / float64(e.TruePositives+e.FalseNegatives)
+}
+
+// F1 returns the harmonic mean of precision and recall.
+func (e Evaluation) F1() float64 {
+	p, r := e.Precision(), e.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+// Evaluate evaluates the model on the sentences. A detected entity is correct
+// if its label and token span equal those of an annotated entity.
+func (m *Model) Evaluate(sentences Sentences) Evaluation {
+	var eval Evaluation
+	for _, s := range sentences {
+		tags, _ := m.Predict(s.Tokens)
+		gold := make(map[Entity]bool)
+		for _, e := range Entities(s.Tags) {
+			gold[e] = true
+		}
+		for _, e := range Entities(tags) {
+			if gold[e] {
+				eval.TruePositives++
+				delete(gold, e)
+			} else {
+				eval.FalsePositives++
+			}
+		}
+		eval.FalseNegatives += len(gold)
+	}
+	return eval
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func trainingSentences(t *testing.T) Sentences {
+	data := [][2]string{
+		{"12:25:cancer", "history of breast cancer"},
+		{"1:22:chronic_disease", "type @NUMBER diabetes"},
+		{"11:23:chronic_disease", "diagnosed hypertension"},
+		{"1:13:treatment", "chemotherapy within @NUMBER months"},
+		{"1:9:pregnancy", "pregnant women"},
+		{"12:23:cancer,27:39:chronic_disease", "history of lung cancer or hypertension"},
+		{"11:19:chronic_disease,24:36:treatment", "diagnosed diabetes and chemotherapy"},
+		{"1:9:pregnancy,23:35:treatment", "pregnant or receiving chemotherapy"},
+	}
+	var sentences Sentences
+	for _, d := range data {
+		s, err := ParseSentence(d[0], d[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
+
+func TestGazetteer(t *testing.T) {
+	a := assert.New(t)
+
+	g := NewGazetteer()
+	g.Add("Breast Cancer", "cancer")
+	g.Add("cancer", "cancer")
+	g.Add("Breast Cancer", "chronic_disease")
+	a.Equal(2, g.Size())
+
+	tags := g.Tag(Tokenize("history of breast cancer"))
+	a.Equal([]string{"O", "O", "B-cancer", "I-cancer"}, tags)
+
+	rules := ParseLabelRules("C04=cancer,C=chronic_disease,D|E02=treatment")
+	a.Equal("cancer", rules.Label([]string{"C04.588.180"}))
+	a.Equal("chronic_disease", rules.Label([]string{"C14.907.489"}))
+	a.Equal("treatment", rules.Label([]string{"E02.183"}))
+	a.Equal("", rules.Label([]string{"F03.600"}))
+}
+
+func TestTagger(t *testing.T) {
+	a := assert.New(t)
+
+	sentences := trainingSentences(t)
+	g := NewGazetteer()
+	g.AddSentences(sentences)
+	m := NewModel(g)
+	m.Train(sentences, 10, 1)
+
+	e := m.Evaluate(sentences)
+	a.Equal(1.0, e.F1())
+
+	slots := NewTagger(m).Tag("History of breast cancer; type 2 diabetes.")
+	a.Len(slots, 2)
+	a.Equal("breast cancer", slots["word_scores:cancer"][0][1])
+	a.Equal("type 2 diabetes", slots["word_scores:chronic_disease"][0][1])
+	score := slots["word_scores:cancer"][0][0].(float64)
+	a.True(score > 0 && score <= 1)
+
+	dir, err := ioutil.TempDir("", "ner")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+	fname := path.Join(dir, "model.json")
+	a.NoError(m.Save(fname))
+	loaded, err := LoadModel(fname)
+	a.NoError(err)
+	a.Equal(slots, NewTagger(loaded).Tag("History of breast cancer; type 2 diabetes."))
+}
@@ -0,0 +1,137 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NumberToken replaces numbers in the tokenized text.
+const NumberToken = "@NUMBER"
+
+var (
+	reLess      = regexp.MustCompile(`[\x{FE64}\x{FF1C}<\x{227A}]`)
+	reGreater   = regexp.MustCompile(`[\x{FE65}\x{FF1E}>\x{227B}]`)
+	reLessEq    = regexp.MustCompile(`(<\s*/?\s*=|=\s*/?\s*<)`)
+	reGreaterEq = regexp.MustCompile(`(>\s*/?\s*=|=\s*/?\s*>)`)
+	reCompare   = regexp.MustCompile(`([=<>≤≥])`)
+	reSpace     = regexp.MustCompile(`\s+`)
+	reNumPrefix = regexp.MustCompile(`^(\d+)([a-z].*)$`)
+)
+
+const (
+	leadingPunct  = `([{"'`
+	trailingPunct = `)]}"',;:?!`
+)
+
+// Normalize normalizes comparison symbols and whitespace and lowercases the text,
+// as the text transformer of the NER training data does.
+func Normalize(s string) string {
+	s = strings.Trim(s, `"`)
+	s = reLess.ReplaceAllString(s, "<")
+	s = reGreater.ReplaceAllString(s, ">")
+	s = strings.Replace(s, "≦", "≤", -1)
+	s = strings.Replace(s, "≧", "≥", -1)
+	s = reLessEq.ReplaceAllString(s, "≤")
+	s = reGreaterEq.ReplaceAllString(s, "≥")
+	s = reCompare.ReplaceAllString(s, " $1 ")
+	s = reSpace.ReplaceAllString(s, " ")
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// Tokenize normalizes and tokenizes the text. Punctuation is split from words,
+// '/' is a separate token, a number prefix is split from a word (e.g., '5mg'),
+// and numbers are replaced with NumberToken.
+func Tokenize(s string) []string {
+	tokens := split(s)
+	for i, t := range tokens {
+		tokens[i] = maskNumber(t)
+	}
+	return tokens
+}
+
+// split normalizes and tokenizes the text without masking numbers.
+func split(s string) []string {
+	chunks := strings.Fields(Normalize(s))
+	tokens := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		for _, t := range splitPunct(chunk) {
+			for _, u := range splitSlash(t) {
+				if m := reNumPrefix.FindStringSubmatch(u); m != nil {
+					tokens = append(tokens, m[1], m[2])
+					continue
+				}
+				tokens = append(tokens, u)
+			}
+		}
+	}
+	return tokens
+}
+
+// splitPunct splits leading and trailing punctuation and the possessive 's from the chunk.
+// A period that ends the chunk is split, but a period followed by other punctuation is split
+// only if the chunk has no other periods (e.g., 'e.g.,' is split to 'e.g.' and ',').
+func splitPunct(chunk string) []string {
+	if chunk == "'s" {
+		return []string{chunk}
+	}
+	var head, tail []string
+	for len(chunk) > 1 && strings.ContainsAny(chunk[:1], leadingPunct) {
+		head = append(head, chunk[:1])
+		chunk = chunk[1:]
+	}
+loop:
+	for len(chunk) > 1 {
+		c := chunk[len(chunk)-1:]
+		switch {
+		case strings.ContainsAny(c, trailingPunct):
+		case c == "." && (len(tail) == 0 || !strings.Contains(chunk[:len(chunk)-1], ".")):
+		default:
+			break loop
+		}
+		tail = append([]string{c}, tail...)
+		chunk = chunk[:len(chunk)-1]
+	}
+	if len(chunk) > 2 && strings.HasSuffix(chunk, "'s") {
+		tail = append([]string{"'s"}, tail...)
+		chunk = chunk[:len(chunk)-2]
+	}
+	return append(append(head, chunk), tail...)
+}
+
+// splitSlash splits the token at slashes keeping the slashes as tokens.
+func splitSlash(t string) []string {
+	if t == "/" || !strings.Contains(t, "/") {
+		return []string{t}
+	}
+	var v []string
+	for i, u := range strings.Split(t, "/") {
+		if i > 0 {
+			v = append(v, "/")
+		}
+		if len(u) > 0 {
+			v = append(v, u)
+		}
+	}
+	return v
+}
+
+func maskNumber(t string) string {
+	if isNumber(t) || t == strings.ToLower(NumberToken) {
+		return NumberToken
+	}
+	return t
+}
+
+// isNumber tests whether the token is a number or a number range.
+func isNumber(t string) bool {
+	t = strings.Replace(t, ",", "", -1)
+	t = strings.Replace(t, "-", "", -1)
+	if len(t) == 0 {
+		return false
+	}
+	_, err := strconv.ParseFloat(t, 64)
+	return err == nil
+}
@@ -0,0 +1,37 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package ner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("hba1c ≥ 7.5 %", Normalize(`"HbA1c >= 7.5 %"`))
+	a.Equal("age < 18 years", Normalize("Age＜18   years"))
+	a.Equal("bmi ≤ 30", Normalize("BMI =< 30"))
+}
+
+func TestTokenize(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Auto-immune disease, acute stage (e.g., rheumatoid arthritis)", "auto-immune disease , acute stage ( e.g. , rheumatoid arthritis )"},
+		{"Hormonal contraceptive use (past 12 mo.)", "hormonal contraceptive use ( past @NUMBER mo . )"},
+		{"Non-English speaking patient or parent/guardian", "non-english speaking patient or parent / guardian"},
+		{"Dose of 5mg or 1,000 IU", "dose of @NUMBER mg or @NUMBER iu"},
+		{"IQ lower than 70.", "iq lower than @NUMBER ."},
+		{"Gilbert's disease (e.g. mild)", "gilbert 's disease ( e.g . mild )"},
+	}
+	for _, test := range tests {
+		a.Equal(test.expected, strings.Join(Tokenize(test.input), " "), test.input)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/parser/production"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+)
+
+// criterionGrammar is the default grammar to parse criteria. The grammar is read-only
+// after construction and is shared by interpreters.
+var criterionGrammar = NewCFGrammar(production.CriterionRules)
+
+// Catalogs defines the variable and unit catalogs and the grammar that criteria are
+// interpreted with. Passing catalogs explicitly allows criteria to be parsed with
+// different catalogs, e.g., per tenant or per catalog version, in the same process.
+type Catalogs struct {
+	Variables *variables.Variables
+	Units     *units.Units
+	Grammar   Grammar
+}
+
+// NewCatalogs creates catalogs from the variable and unit catalogs and the default criterion grammar.
+func NewCatalogs(vs *variables.Variables, us *units.Units) *Catalogs {
+	return &Catalogs{Variables: vs, Units: us, Grammar: criterionGrammar}
+}
+
+// DefaultCatalogs returns the active package-level variable and unit catalogs
+// and the default criterion grammar.
+func DefaultCatalogs() *Catalogs {
+	return NewCatalogs(variables.Get(), units.Get())
+}
+
+// isCount tests whether the variable name is a count variable in the catalog vs.
+func isCount(vs *variables.Variables, name string) bool {
+	id, ok := vs.ID(name)
+	if !ok {
+		return false
+	}
+	v := vs.Variable(id)
+	return v != nil && v.Kind == variables.Count
+}
@@ -3,6 +3,9 @@
 package parser
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
 )
 
@@ -53,15 +56,42 @@ func NewCFGrammar(s string) *CFG {
 	return &CFG{rules: LoadRules(s)}
 }
 
+// LoadCFGrammar loads a Context-Free Grammar from the grammar file. The production rules
+// are validated for the start symbol S and converted to the binary form.
+func LoadCFGrammar(fname string) (*CFG, error) {
+	ps, err := LoadProductions(fname)
+	if err != nil {
+		return nil, err
+	}
+	if msgs := ps.Validate(StartSymbol); len(msgs) > 0 {
+		return nil, fmt.Errorf("invalid grammar %s: %s", fname, strings.Join(msgs, "; "))
+	}
+	return &CFG{rules: newRules(ps.Binarize())}, nil
+}
+
 // BuildTrees computes the parse trees from the input items using the Lange-Leiss implementation
 // of the CYK algorithm. The grammar is assumed to be in the binary normal form.
 // Lange and Leiss, "To CNF or not to CNF? An Efficient Yet Presentable Version of the CYK Algorithm",
 // Informatica Didactica 8 (2009).
 func (g *CFG) BuildTrees(items Items) Trees {
-	dim := items.Len()
-	if dim == 0 {
+	if items.Len() == 0 {
 		return nil
 	}
+	return g.Chart(items).Trees()
+}
+
+// Chart defines the CYK state table. The cell (i, j) holds the nonterminals
+// that derive the items i..j, and the elements to build their derivations.
+// Items without terminal rules are not in the chart.
+type Chart struct {
+	items    Items
+	state    [][]set.Set
+	children [][]map[string]Element
+}
+
+// Chart computes the CYK state table of the input items.
+func (g *CFG) Chart(items Items) *Chart {
+	dim := items.Len()
 
 	state := make([][]set.Set, dim)
 	for i := 0; i < dim; i++ {
@@ -80,18 +110,19 @@ func (g *CFG) BuildTrees(items Items) Trees {
 
 	rules := g.rules
 
+	var terms Items
 	k := 0
 	for i := 0; i < dim; i++ {
 		term := items[i].typ
 		if rules.terminalRules[term].Empty() {
 			continue
 		}
+		terms = append(terms, items[i])
 		for A := range rules.terminalRules[term] {
 			state[k][k].Add(A)
 			children[k][k][A] = NewUnary(items[i].val).Set(k, k, k)
 			item_new := NewUnary(items[i].val).Set(k, k, k)
-			item_new.pos = int(items[i].pos)
-			item_new.width = int(items[i].pos) + len(items[i].name)
+			item_new.pos, item_new.width = items[i].span()
 			// item_new.val = items[i].name
 			children[k][k][A] = item_new
 		}
@@ -146,6 +177,14 @@ func (g *CFG) BuildTrees(items Items) Trees {
 		}
 	}
 
+	return &Chart{items: terms, state: state[:dim], children: children[:dim]}
+}
+
+// Trees builds the parse trees stored in the chart. The tree rooted at S that spans all
+// items is returned, or the partial parses selected by selectTrees, which are scored lower.
+func (c *Chart) Trees() Trees {
+	children := c.children
+
 	// Build a parse tree stored in the children table:
 
 	var iter func(n *Node, p Element)
@@ -170,24 +209,36 @@ func (g *CFG) BuildTrees(items Items) Trees {
 		}
 	}
 
-	trees := NewTrees()
-
-	for k := 0; k < dim; k++ {
-		score := 1.0 - 0.5*float64(k)/float64(dim)
-		for i := 0; i <= k; i++ {
-			if next, ok := children[i][dim+i-k-1]["S"]; ok {
-				node := NewNode("S", 0, 0)
-				iter(node, next)
-				if node.Size() > 1 {
-					tree := NewTree(node, score)
-					trees = append(trees, tree)
-				}
+	derives := func(begin, end int) bool {
+		_, ok := children[begin][end-1][StartSymbol]
+		return ok
+	}
+	build := func(begin, end int) *Node {
+		node := NewNode(StartSymbol, 0, 0)
+		iter(node, children[begin][end-1][StartSymbol])
+		return node
+	}
+	return selectTrees(c.items, derives, build)
+}
+
+// String returns the non-empty cells of the chart, one per line, by span length and position.
+// Each line shows the span of item indices, the item values, and the sorted nonterminals.
+func (c *Chart) String() string {
+	var b strings.Builder
+	dim := len(c.items)
+	for span := 1; span <= dim; span++ {
+		for begin := 0; begin <= dim-span; begin++ {
+			end := begin + span - 1
+			nonTerminals := c.state[begin][end].Slice()
+			if len(nonTerminals) == 0 {
+				continue
 			}
-		}
-		if !trees.Empty() {
-			break
+			values := make([]string, 0, span)
+			for _, item := range c.items[begin : end+1] {
+				values = append(values, item.val)
+			}
+			fmt.Fprintf(&b, "[%d,%d] %q: %s\n", begin, end, strings.Join(values, " "), strings.Join(nonTerminals, " "))
 		}
 	}
-
-	return trees
+	return b.String()
 }
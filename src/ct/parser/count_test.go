@@ -0,0 +1,141 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowParser(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input    string
+		expected string // value of the window item, or empty if there is no window
+		name     string // input text of the window
+	}{
+		{"no more than 2 hospitalizations in the past year", "1 year", "in the past year"},
+		{"hospitalizations ≤ 2 within the last 12 months", "12 month", "within the last 12 months"},
+		{"≤ 2 hospitalizations during the previous six months", "6 month", "during the previous six months"},
+		{"≤ 2 hospitalizations over the past 2 weeks", "2 week", "over the past 2 weeks"},
+		{"≤ 2 hospitalizations within 30 days", "30 day", "within 30 days"},
+		{"≤ 2 hospitalizations in the hospital", "", ""},
+		{"myocardial infarction within the last 6 months", "", ""},
+	}
+	for _, test := range tests {
+		var window *Item
+		for _, items := range parser.Parse(test.input) {
+			for _, item := range items {
+				if item.typ == itemWindow {
+					window = item
+				}
+			}
+		}
+		if len(test.expected) == 0 {
+			a.Nil(window, test.input)
+			continue
+		}
+		if a.NotNil(window, test.input) {
+			a.Equal(test.expected, window.val, test.input)
+			start, end := window.span()
+			a.Equal(test.name, test.input[start:end], test.input)
+		}
+	}
+}
+
+func TestCountInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input  string
+		name   string
+		lower  *relation.Limit
+		upper  *relation.Limit
+		window string // length and unit of the window
+	}{
+		{"≤ 2 prior lines of therapy", "prior_therapy_lines", nil, &relation.Limit{Incl: true, Value: "2"}, ""},
+		{"at least 1 but no more than 3 prior systemic regimens", "prior_therapy_lines", &relation.Limit{Incl: true, Value: "1"}, &relation.Limit{Incl: true, Value: "3"}, ""},
+		{"more than two prior regimens", "prior_therapy_lines", &relation.Limit{Incl: false, Value: "2"}, nil, ""},
+		{"no more than 2 hospitalizations in the past year", "hospitalizations", nil, &relation.Limit{Incl: true, Value: "2"}, "1 year"},
+		{"two or more hospitalizations in the past 6 months", "hospitalizations", &relation.Limit{Incl: true, Value: "2"}, nil, "6 month"},
+		{"hospitalizations ≤ 2 within the last 12 months", "hospitalizations", nil, &relation.Limit{Incl: true, Value: "2"}, "12 month"},
+		{"hospitalizations in the past 12 months", "hospitalizations", &relation.Limit{Incl: true, Value: "1"}, nil, "12 month"},
+	}
+	vs := interpreter.Catalogs().Variables
+	for _, test := range tests {
+		orRs, andRs := interpreter.Interpret(test.input)
+		rs := append(orRs, andRs...)
+		rs.ProcessWith(vs)
+		if !a.Len(rs, 1, test.input) {
+			continue
+		}
+		r := rs[0]
+		a.Equal(test.name, r.Name, test.input)
+		a.Equal(variables.Count, r.VariableType, test.input)
+		for _, l := range []struct{ expected, actual *relation.Limit }{{test.lower, r.Lower}, {test.upper, r.Upper}} {
+			if l.expected == nil {
+				a.Nil(l.actual, test.input)
+			} else if a.NotNil(l.actual, test.input) {
+				a.Equal(l.expected.Incl, l.actual.Incl, test.input)
+				a.Equal(l.expected.Value, l.actual.Value, test.input)
+			}
+		}
+		if len(test.window) == 0 {
+			a.Nil(r.Window, test.input)
+		} else if a.NotNil(r.Window, test.input) {
+			a.Equal(test.window, r.Window.Value+" "+r.Window.Unit, test.input)
+		}
+		if a.NotNil(r.Concept, test.input) {
+			a.Equal(variables.MeSH, r.Concept.System, test.input)
+		}
+	}
+}
+
+func TestOrComparison(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input    string
+		expected Items
+	}{
+		{"age 18 years or older", Items{
+			NewItem(itemVariable, "age"),
+			NewItem(itemNumber, "18"),
+			NewItem(itemUnit, "year"),
+			NewItem(itemComparison, "≥"),
+		}},
+		{"2 or more hospitalizations", Items{
+			NewItem(itemNumber, "2"),
+			NewItem(itemComparison, "≥"),
+			NewItem(itemVariable, "hospitalizations"),
+		}},
+		{"age 65 or less", Items{
+			NewItem(itemVariable, "age"),
+			NewItem(itemNumber, "65"),
+			NewItem(itemComparison, "≤"),
+		}},
+	}
+	for _, test := range tests {
+		list := parser.Parse(test.input)
+		if !a.Len(list, 1, test.input) {
+			continue
+		}
+		var actual Items
+		for _, item := range list[0] {
+			actual = append(actual, NewItem(item.typ, item.val))
+		}
+		a.Equal(test.expected, actual, test.input)
+
+		// The comparison word is covered by the parse, so the relation is not scored lower.
+		orRs, andRs := interpreter.Interpret(test.input)
+		rs := append(orRs, andRs...)
+		if a.Len(rs, 1, test.input) {
+			a.Equal(1.0, rs[0].Score, test.input)
+		}
+	}
+}
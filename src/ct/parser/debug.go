@@ -0,0 +1,124 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/diagnostics"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+)
+
+// Trace defines the intermediate results of interpreting a criterion,
+// which are used to debug the grammar and the catalogs.
+type Trace struct {
+	Input        string
+	Normalized   string             // input that is lexed
+	Tokens       Tokens             // lexer tokens of the normalized input
+	List         List               // parsed items that the grammar is applied to
+	Charts       []*Chart           // CYK charts of the items, or nil if the grammar is not a CFG
+	Trees        Trees              // candidate parse trees
+	OrRelations  relation.Relations // processed 'or' relations
+	AndRelations relation.Relations // processed 'and' relations
+	Diagnostics  diagnostics.Diagnostics
+}
+
+// Trace interprets the input string like Interpret and records the intermediate results.
+func (i *Interpreter) Trace(input string) *Trace {
+	c := i.Catalogs()
+	t := i.interpret(input)
+	t.Normalized = Normalize(input).Text
+	t.Tokens = NewLexer(t.Normalized).Drain()
+	if g, ok := c.Grammar.(*CFG); ok {
+		for _, items := range t.List {
+			if items.Len() > 0 {
+				t.Charts = append(t.Charts, g.Chart(items))
+			}
+		}
+	}
+	t.OrRelations.ProcessWith(c.Variables)
+	t.AndRelations.ProcessWith(c.Variables)
+	return t
+}
+
+// String returns the human-readable sections of the trace.
+func (t *Trace) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "input: %q\n", t.Input)
+	if t.Normalized != t.Input {
+		fmt.Fprintf(&b, "normalized: %q\n", t.Normalized)
+	}
+
+	b.WriteString("\ntokens:\n")
+	for _, token := range t.Tokens {
+		fmt.Fprintf(&b, "  %3d %-18s %q\n", token.pos, token.typ, token.val)
+	}
+
+	b.WriteString("\nitems:\n")
+	for k, items := range t.List {
+		fmt.Fprintf(&b, "  list %d:\n", k)
+		for j, item := range items {
+			fmt.Fprintf(&b, "    %2d %-11s %q (%q at %d)\n", j, item.typ, item.val, item.name, item.pos)
+		}
+	}
+
+	b.WriteString("\nchart:\n")
+	for k, chart := range t.Charts {
+		fmt.Fprintf(&b, "  list %d:\n", k)
+		for _, line := range strings.Split(strings.TrimSuffix(chart.String(), "\n"), "\n") {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+
+	b.WriteString("\ntrees:\n")
+	for _, tree := range t.Trees {
+		fmt.Fprintf(&b, "  %s\n", tree)
+	}
+
+	b.WriteString("\nrelations:\n")
+	for _, r := range t.OrRelations {
+		fmt.Fprintf(&b, "  or  %s\n", r.JSON())
+	}
+	for _, r := range t.AndRelations {
+		fmt.Fprintf(&b, "  and %s\n", r.JSON())
+	}
+
+	if !t.Diagnostics.Empty() {
+		b.WriteString("\ndiagnostics:\n")
+		for _, d := range t.Diagnostics {
+			fmt.Fprintf(&b, "  %s\n", d)
+		}
+	}
+	return b.String()
+}
+
+// DOT returns the Graphviz DOT rendering of the tree as a graph with the name.
+// Nonterminals are drawn as ellipses and terminals as boxes.
+func (t *Tree) DOT(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", name)
+	fmt.Fprintf(&b, "  label=%q;\n", fmt.Sprintf("score %.3f", t.score))
+	id := 0
+	var visit func(n *Node) int
+	visit = func(n *Node) int {
+		k := id
+		id++
+		shape := "ellipse"
+		if n.left == nil && n.right == nil {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  n%d [label=%q,shape=%s];\n", k, n.val, shape)
+		for _, m := range []*Node{n.left, n.right} {
+			if m != nil {
+				fmt.Fprintf(&b, "  n%d -> n%d;\n", k, visit(m))
+			}
+		}
+		return k
+	}
+	if t.root != nil {
+		visit(t.root)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
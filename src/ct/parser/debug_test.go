@@ -0,0 +1,75 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrace(t *testing.T) {
+	a := assert.New(t)
+
+	trace := NewInterpreter().Trace("a1c < 7%")
+
+	a.Len(trace.Tokens, 4)
+	if a.Len(trace.List, 1) {
+		a.Equal(4, trace.List[0].Len())
+		a.Equal(itemVariable, trace.List[0][0].typ)
+	}
+	if a.Len(trace.Charts, 1) {
+		chart := trace.Charts[0].String()
+		a.Contains(chart, `[0,0] "a1c": C R S V V1 X`)
+		a.Contains(chart, `[0,3] "a1c < 7 %": C R S X`)
+	}
+	a.Len(trace.Trees, 1)
+	a.Empty(trace.OrRelations)
+	if a.Len(trace.AndRelations, 1) {
+		a.Equal(variables.ID("400"), trace.AndRelations[0].ID)
+	}
+	a.Empty(trace.Diagnostics)
+
+	s := trace.String()
+	for _, section := range []string{"tokens:", "items:", "chart:", "trees:", "relations:"} {
+		a.Contains(s, section)
+	}
+}
+
+func TestTraceFormula(t *testing.T) {
+	a := assert.New(t)
+
+	input := "creatinine clearance ≥ 60 ml/min calculated by Cockcroft-Gault"
+	trace := NewInterpreter().Trace(input)
+	orRs, andRs := NewInterpreter().Interpret(input)
+	expected := append(orRs, andRs...)
+	expected.Process()
+
+	actual := append(trace.OrRelations, trace.AndRelations...)
+	if a.Len(actual, 1) {
+		a.Equal(formula.CockcroftGault, actual[0].Formula)
+	}
+	a.Equal(expected, actual)
+}
+
+func TestDOT(t *testing.T) {
+	a := assert.New(t)
+
+	tree := NewTree(&Node{val: "S", left: &Node{val: "V", left: &Node{val: "a1c"}}}, 0.5)
+	expected := strings.Join([]string{
+		`digraph "t" {`,
+		`  label="score 0.500";`,
+		`  n0 [label="S",shape=ellipse];`,
+		`  n1 [label="V",shape=ellipse];`,
+		`  n2 [label="a1c",shape=box];`,
+		`  n1 -> n2;`,
+		`  n0 -> n1;`,
+		`}`,
+		``,
+	}, "\n")
+	a.Equal(expected, tree.DOT("t"))
+}
@@ -0,0 +1,249 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// Earley defines the grammar that parses items with the Earley algorithm, which only
+// builds the states that are predicted from the start symbol, instead of the dense
+// table of all spans and nonterminals of CYK. It implements the Grammar interface.
+// The production rules are binarized like for CFG, so both return the same trees.
+type Earley struct {
+	symbols   map[string]int // nonterminal ids
+	names     []string       // nonterminal names by id
+	rules     []earleyRule
+	byLeft    [][]int // nonterminal rules by left-hand side id
+	terminals [][]int // terminal rules by left-hand side id
+	terms     map[itemType]bool
+	start     int
+}
+
+// earleyRule defines a binarized production rule with nonterminal ids.
+type earleyRule struct {
+	left  int
+	right []int    // nonterminal rules
+	term  itemType // terminal rules
+}
+
+// earleyState defines a dotted production rule and the position where its recognition started.
+type earleyState struct {
+	rule   int
+	dot    int
+	origin int
+}
+
+// earleyChart defines the spans of the nonterminals that derive items begin..end-1.
+type earleyChart struct {
+	n        int
+	size     int
+	complete []bool
+}
+
+// set records that the nonterminal A derives the items begin..end-1.
+func (c *earleyChart) set(A, begin, end int) {
+	c.complete[(begin*(c.n+1)+end)*c.size+A] = true
+}
+
+// has tests whether the nonterminal A derives the items begin..end-1.
+func (c *earleyChart) has(A, begin, end int) bool {
+	return c.complete[(begin*(c.n+1)+end)*c.size+A]
+}
+
+// NewEarleyGrammar creates a new Earley grammar. It loads the production rules from s.
+func NewEarleyGrammar(s string) *Earley {
+	ps, err := ReadProductions(s)
+	if err != nil {
+		glog.Fatalf("Cannot read production rules: %v\n", err)
+	}
+	return newEarley(ps.Binarize())
+}
+
+// LoadEarleyGrammar loads an Earley grammar from the grammar file. The production rules
+// are validated for the start symbol S.
+func LoadEarleyGrammar(fname string) (*Earley, error) {
+	ps, err := LoadProductions(fname)
+	if err != nil {
+		return nil, err
+	}
+	if msgs := ps.Validate(StartSymbol); len(msgs) > 0 {
+		return nil, fmt.Errorf("invalid grammar %s: %s", fname, strings.Join(msgs, "; "))
+	}
+	return newEarley(ps.Binarize()), nil
+}
+
+// newEarley creates the Earley grammar of the binarized production rules.
+func newEarley(ps Productions) *Earley {
+	e := &Earley{symbols: make(map[string]int), terms: make(map[itemType]bool)}
+	id := func(A string) int {
+		if k, ok := e.symbols[A]; ok {
+			return k
+		}
+		k := len(e.names)
+		e.symbols[A] = k
+		e.names = append(e.names, A)
+		e.byLeft = append(e.byLeft, nil)
+		e.terminals = append(e.terminals, nil)
+		return k
+	}
+	e.start = id(StartSymbol)
+	for _, p := range ps {
+		r := earleyRule{left: id(p.Left)}
+		if p.Terminal {
+			r.term = ItemType(p.Right[0])
+			e.terms[r.term] = true
+			e.terminals[r.left] = append(e.terminals[r.left], len(e.rules))
+		} else {
+			for _, X := range p.Right {
+				r.right = append(r.right, id(X))
+			}
+			e.byLeft[r.left] = append(e.byLeft[r.left], len(e.rules))
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e
+}
+
+// BuildTrees computes the parse trees from the input items. Like CFG.BuildTrees, it returns
+// the tree rooted at S that spans all items or the partial parses selected by selectTrees,
+// and items without terminal rules are skipped.
+func (e *Earley) BuildTrees(items Items) Trees {
+	if items.Len() == 0 {
+		return nil
+	}
+	var terms Items
+	for _, item := range items {
+		if e.terms[item.typ] {
+			terms = append(terms, item)
+		}
+	}
+	chart := e.recognize(terms)
+
+	derives := func(begin, end int) bool {
+		return chart.has(e.start, begin, end)
+	}
+	build := func(begin, end int) *Node {
+		return e.derive(e.start, begin, end, terms, chart, nil)
+	}
+	return selectTrees(terms, derives, build)
+}
+
+// recognize runs the Earley recognizer and returns the spans of the recognized nonterminals.
+// The start symbol is predicted at every position so that partial parses are recognized.
+func (e *Earley) recognize(terms Items) *earleyChart {
+	n := len(terms)
+	size := len(e.names)
+	chart := &earleyChart{n: n, size: size, complete: make([]bool, n*(n+1)*size)}
+	states := make([][]earleyState, n+1)
+	waiting := make([][][]earleyState, n+1) // states waiting for a nonterminal by position
+	predicted := make([]bool, size)
+	// Binarized rules have at most two symbols, so a state has one of three dots.
+	// A state is in the states of position k if its stamp is k+1:
+	stamps := make([]int, len(e.rules)*3*(n+1))
+	stamp := func(s earleyState) *int {
+		return &stamps[(s.rule*3+s.dot)*(n+1)+s.origin]
+	}
+
+	for k := 0; k <= n; k++ {
+		waiting[k] = make([][]earleyState, size)
+		for A := range predicted {
+			predicted[A] = false
+		}
+		add := func(k int, s earleyState) {
+			if p := stamp(s); *p != k+1 {
+				*p = k + 1
+				states[k] = append(states[k], s)
+			}
+		}
+		predict := func(A int) {
+			if predicted[A] {
+				return
+			}
+			predicted[A] = true
+			if k < n {
+				for _, r := range e.terminals[A] {
+					if e.rules[r].term == terms[k].typ {
+						add(k+1, earleyState{rule: r, dot: 1, origin: k})
+					}
+				}
+			}
+			for _, r := range e.byLeft[A] {
+				add(k, earleyState{rule: r, origin: k})
+			}
+		}
+
+		if k < n {
+			predict(e.start)
+		}
+		for i := 0; i < len(states[k]); i++ {
+			s := states[k][i]
+			r := e.rules[s.rule]
+			if r.right == nil || s.dot == len(r.right) {
+				if chart.has(r.left, s.origin, k) {
+					continue
+				}
+				chart.set(r.left, s.origin, k)
+				for _, w := range waiting[s.origin][r.left] {
+					add(k, earleyState{rule: w.rule, dot: w.dot + 1, origin: w.origin})
+				}
+				continue
+			}
+			// Binarized rules have no epsilon rules, so B is completed at a later position,
+			// when the states waiting at k are known:
+			B := r.right[s.dot]
+			waiting[k][B] = append(waiting[k][B], s)
+			predict(B)
+		}
+	}
+	return chart
+}
+
+// derive builds the derivation tree of the nonterminal A over the items begin..end-1.
+// Derivations are chosen like in CFG.BuildTrees: terminal rules before binary rules before
+// unary rules, and binary rules with the rightmost split. Visiting guards against unit cycles.
+func (e *Earley) derive(A, begin, end int, terms Items, chart *earleyChart, visiting []int) *Node {
+	node := NewNode(e.names[A], 0, 0)
+	if end == begin+1 {
+		item := terms[begin]
+		for _, r := range e.terminals[A] {
+			if e.rules[r].term == item.typ {
+				begin, end := item.span()
+				node.left = NewNode(item.val, begin, end)
+				return node
+			}
+		}
+	}
+	for split := end - 1; split > begin; split-- {
+		for _, r := range e.byLeft[A] {
+			right := e.rules[r].right
+			if len(right) == 2 && chart.has(right[0], begin, split) && chart.has(right[1], split, end) {
+				node.left = e.derive(right[0], begin, split, terms, chart, nil)
+				node.right = e.derive(right[1], split, end, terms, chart, nil)
+				return node
+			}
+		}
+	}
+	visiting = append(visiting, A)
+	for _, r := range e.byLeft[A] {
+		right := e.rules[r].right
+		if len(right) == 1 && chart.has(right[0], begin, end) && !contains(visiting, right[0]) {
+			node.left = e.derive(right[0], begin, end, terms, chart, visiting)
+			return node
+		}
+	}
+	return node
+}
+
+// contains tests whether the slice v contains a.
+func contains(v []int, a int) bool {
+	for _, b := range v {
+		if a == b {
+			return true
+		}
+	}
+	return false
+}
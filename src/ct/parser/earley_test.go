@@ -0,0 +1,170 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/criteria"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/parser/production"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// loadCriteria loads the lowercase criteria of the bundled clinical trials.
+func loadCriteria(tb testing.TB) []string {
+	f, err := os.Open("../../../data/input/clinical_trials.csv")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var cs []string
+	for _, record := range records {
+		sections, _ := criteria.Segment(criteria.Normalize(record[4]))
+		for _, section := range sections {
+			items, _ := criteria.ParseBullets(section.Text).Items(1)
+			for _, item := range items {
+				for _, clause := range criteria.SplitClauses(criteria.TrimCriterion(item.Text)) {
+					cs = append(cs, strings.ToLower(clause.Text))
+				}
+			}
+		}
+	}
+	return cs
+}
+
+// leaves returns the terminal values and the score of the tree.
+func leaves(t *Tree) string {
+	var values []string
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		if n.left == nil && n.right == nil {
+			values = append(values, n.val)
+			return
+		}
+		for _, m := range []*Node{n.left, n.right} {
+			if m != nil {
+				visit(m)
+			}
+		}
+	}
+	visit(t.root)
+	return fmt.Sprintf("%.3f %q", t.score, values)
+}
+
+func TestEarley(t *testing.T) {
+	a := assert.New(t)
+
+	items := Items{
+		NewItem(itemVariable, "a1c"),
+		NewItem(itemComparison, "<"),
+		NewItem(itemNumber, "7"),
+		NewItem(itemUnit, "%"),
+		NewItem(itemPunctuation, "."),
+		NewItem(itemComparison, ">"),
+	}
+	earley := NewEarleyGrammar(production.CriterionRules)
+	a.Equal(criterionGrammar.BuildTrees(items).String(), earley.BuildTrees(items).String())
+	a.Nil(earley.BuildTrees(nil))
+
+	// N-ary and epsilon rules:
+	g := NewEarleyGrammar(`
+#nonterminals:
+S -> V Q N U
+Q -> T | ε
+#terminals:
+V -> variable
+T -> comparison
+N -> number
+U -> unit
+`)
+	trees := g.BuildTrees(Items{NewItem(itemVariable, "a1c"), NewItem(itemNumber, "7"), NewItem(itemUnit, "%")})
+	if a.Len(trees, 1) {
+		a.Equal(`{"score":1.000,"tree":{"value":"S","left":{"value":"V","left":{"value":"a1c"}},"right":{"value":"S.3","left":{"value":"N","left":{"value":"7"}},"right":{"value":"U","left":{"value":"%"}}}}}`, trees[0].String())
+	}
+}
+
+func TestLoadGrammar(t *testing.T) {
+	a := assert.New(t)
+
+	fname := "../../resources/grammar/criterion.grammar"
+	g, err := LoadGrammar(fname, "earley")
+	a.NoError(err)
+	a.Equal(NewEarleyGrammar(production.CriterionRules), g)
+	g, err = LoadGrammar(fname, "cyk")
+	a.NoError(err)
+	a.Equal(criterionGrammar, g)
+	_, err = LoadGrammar(fname, "lr")
+	a.Error(err)
+}
+
+// TestEarleyBundledCriteria tests that Earley and CYK parse the bundled criteria to the same trees.
+// The criterion grammar is ambiguous, and CYK chooses among derivations of a span in the map order,
+// so the trees are compared by their scores and terminals.
+func TestEarleyBundledCriteria(t *testing.T) {
+	a := assert.New(t)
+
+	interpreter := NewInterpreter()
+	earley := NewEarleyGrammar(production.CriterionRules)
+
+	cs := loadCriteria(t)
+	a.NotEmpty(cs)
+	for _, s := range cs {
+		list, err := interpreter.items(interpreter.Catalogs(), s)
+		a.NoError(err, s)
+		expected, expectedDs := buildTrees(criterionGrammar, list)
+		actual, actualDs := buildTrees(earley, list)
+		a.Equal(expectedDs, actualDs, s)
+		if a.Len(actual, len(expected), s) {
+			for i := range expected {
+				a.Equal(leaves(expected[i]), leaves(actual[i]), s)
+			}
+		}
+	}
+}
+
+func benchmarkGrammar(b *testing.B, g Grammar, criteria []string) {
+	interpreter := NewInterpreter()
+	var lists []List
+	for _, s := range criteria {
+		list, _ := interpreter.items(interpreter.Catalogs(), s)
+		lists = append(lists, list)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, list := range lists {
+			buildTrees(g, list)
+		}
+	}
+}
+
+// longCriterion returns a criterion with many numbers.
+func longCriterion() []string {
+	return []string{strings.Repeat("platelets >= 100 x10^9/l and hemoglobin >= 9 g/dl and anc 1.5 - 3.0 x10^9/l, ", 4)}
+}
+
+func BenchmarkCYKBundled(b *testing.B) {
+	benchmarkGrammar(b, criterionGrammar, loadCriteria(b))
+}
+
+func BenchmarkEarleyBundled(b *testing.B) {
+	benchmarkGrammar(b, NewEarleyGrammar(production.CriterionRules), loadCriteria(b))
+}
+
+func BenchmarkCYKLong(b *testing.B) {
+	benchmarkGrammar(b, criterionGrammar, longCriterion())
+}
+
+func BenchmarkEarleyLong(b *testing.B) {
+	benchmarkGrammar(b, NewEarleyGrammar(production.CriterionRules), longCriterion())
+}
@@ -0,0 +1,47 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormulaInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input   string
+		name    string
+		unit    string
+		formula formula.Formula
+	}{
+		{"creatinine clearance ≥ 60 ml/min calculated by Cockcroft-Gault", "calculated_creatinine_clearance", "ml/min", formula.CockcroftGault},
+		{"creatinine clearance ≥ 60 ml/min (CKD-EPI)", "calculated_creatinine_clearance", "ml/min", formula.CKDEPI},
+		{"gfr ≥ 45 ml/min/1.73 m2 by MDRD", "glomerular_filtration_rate", "mL/min/1.73_m2", formula.MDRD},
+		{"gfr ≥ 45 ml/min/1.73 m2", "glomerular_filtration_rate", "mL/min/1.73_m2", formula.Unknown},
+		{"bmi ≥ 30 kg/m2 (CKD-EPI)", "bmi", "kg/m2", formula.Unknown},
+		{"upcr < 1 mg/mg", "upcr", "mg/mg", formula.Unknown},
+		{"urine protein/creatinine ratio < 1", "upcr", "mg/mg", formula.Unknown},
+		{"alt > 3× baseline", "alt", "baseline", formula.Unknown},
+		{"alt > 3 times baseline", "alt", "baseline", formula.Unknown},
+	}
+	vs := interpreter.Catalogs().Variables
+	for _, test := range tests {
+		orRs, andRs := interpreter.Interpret(test.input)
+		rs := append(orRs, andRs...)
+		rs.ProcessWith(vs)
+		if !a.Len(rs, 1, test.input) {
+			continue
+		}
+		r := rs[0]
+		a.Equal(test.name, r.Name, test.input)
+		if a.NotNil(r.Unit, test.input) {
+			a.Equal(test.unit, r.Unit.Value, test.input)
+		}
+		a.Equal(test.formula, r.Formula, test.input)
+	}
+}
@@ -2,7 +2,21 @@
 
 package parser
 
+import "fmt"
+
 // Grammar defines the grammar interface to parse items into a parse tree.
 type Grammar interface {
 	BuildTrees(Items) Trees
 }
+
+// LoadGrammar loads the grammar file for the parsing algorithm, 'cyk' or 'earley'.
+func LoadGrammar(fname, algorithm string) (Grammar, error) {
+	switch algorithm {
+	case "cyk":
+		return LoadCFGrammar(fname)
+	case "earley":
+		return LoadEarleyGrammar(fname)
+	default:
+		return nil, fmt.Errorf("unknown parsing algorithm: %q", algorithm)
+	}
+}
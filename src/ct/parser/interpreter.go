@@ -3,10 +3,15 @@
 package parser
 
 import (
+	"fmt"
 	"strings"
 
-	"github.com/facebookresearch/clinical-trial-parser/src/ct/parser/production"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/diagnostics"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/golang/glog"
 )
 
 var interpreter *Interpreter
@@ -23,13 +28,27 @@ func Get() *Interpreter {
 // Interpreter defines the interpreter struct to convert
 // unstructured criteria strings to structured relations.
 type Interpreter struct {
-	parser  *Parser
-	grammar Grammar
+	parser   *Parser
+	catalogs *Catalogs // catalogs of the interpreter, or nil for the default catalogs
 }
 
-// NewInterpreter creates a new interpreter.
+// NewInterpreter creates a new interpreter that uses the default catalogs,
+// which are read when a string is interpreted.
 func NewInterpreter() *Interpreter {
-	return &Interpreter{parser: NewParser(), grammar: NewCFGrammar(production.CriterionRules)}
+	return &Interpreter{parser: NewParser()}
+}
+
+// NewCatalogInterpreter creates a new interpreter that uses the catalogs c.
+func NewCatalogInterpreter(c *Catalogs) *Interpreter {
+	return &Interpreter{parser: NewCatalogParser(c), catalogs: c}
+}
+
+// Catalogs returns the catalogs that the interpreter uses.
+func (i *Interpreter) Catalogs() *Catalogs {
+	if i.catalogs == nil {
+		return DefaultCatalogs()
+	}
+	return i.catalogs
 }
 
 //get the max value in array of int
@@ -51,54 +70,14 @@ func intInSlice(a int, list []int) bool {
 }
 
 // Interpret interprets clinical trial criteria using parse trees and formal grammars.
+// Failures of the parser and the evaluation are logged.
 func (i *Interpreter) Interpret(input string) (relation.Relations, relation.Relations) {
-	listCopy := i.parser.Parse(input)
-	listCopy.FixMissingVariable()
-	var list List
-	for _, listVal := range listCopy {
-		unitCount, compCount, numberCount := 0, 0, 0
-		var markInsert []int
-		listNew := NewItems()
-		for k, item := range listVal {
-			if item.typ == itemVariable {
-				break
-			}
-			if item.typ == itemNumber {
-				numberCount += 1
-				if numberCount > max(compCount, unitCount) {
-					markInsert = append(markInsert, k)
-				}
-			}
-			if item.typ == itemComparison {
-				compCount += 1
-				if compCount > max(numberCount, unitCount) {
-					markInsert = append(markInsert, k)
-				}
-			}
-			if item.typ == itemUnit {
-				unitCount += 1
-				if unitCount > max(numberCount, compCount) {
-					markInsert = append(markInsert, k)
-				}
-			}
-			if item.typ == itemRange {
-				markInsert = append(markInsert, k)
-				break
-			}
+	orRs, andRs, ds := i.Diagnose(input)
+	for _, d := range ds {
+		if d.Stage != diagnostics.Grammar {
+			glog.Errorf("%s: %s: %q\n", d.Stage, d.Reason, input)
 		}
-		for k, item := range listVal {
-			if intInSlice(k, markInsert) {
-				newIt := &Item{typ: itemVariable, val: "IGNORE"}
-				listNew.Add(newIt)
-				listNew.Add(item)
-			} else {
-				listNew.Add(item)
-			}
-		}
-		list = append(list, listNew)
 	}
-	trees := i.buildTrees(list)
-	orRs, andRs := trees.Relations()
 	// 	m := make(map[string]int)
 	// 	for _, listVal := range list {
 	// 		for _, item := range listVal {
@@ -154,15 +133,128 @@ func (i *Interpreter) Interpret(input string) (relation.Relations, relation.Rela
 	return orRs, andRs
 }
 
-// buildTrees builds trees from the parsed items. Trees represent criteria.
-func (i *Interpreter) buildTrees(list List) Trees {
+// Diagnose interprets the input string like Interpret and returns the diagnostics
+// of the parts of the input that are not interpreted: recovered panics of the parser
+// and the evaluation, and items that the grammar parses partially or not at all.
+func (i *Interpreter) Diagnose(input string) (relation.Relations, relation.Relations, diagnostics.Diagnostics) {
+	t := i.interpret(input)
+	return t.OrRelations, t.AndRelations, t.Diagnostics
+}
+
+// interpret interprets the input string and records the parsed items, the parse trees, the
+// unprocessed relations, and the diagnostics in the trace. Interpret, Diagnose, and Trace
+// share it, so that they interpret the input alike.
+func (i *Interpreter) interpret(input string) (t *Trace) {
+	c := i.Catalogs()
+	t = &Trace{Input: input}
+	var err error
+	if t.List, err = i.items(c, input); err != nil {
+		t.Diagnostics = append(t.Diagnostics, diagnostics.New(diagnostics.Parser, err.Error(), input))
+	}
+	var ds diagnostics.Diagnostics
+	t.Trees, ds = buildTrees(c.Grammar, t.List)
+	t.Diagnostics = append(t.Diagnostics, ds...)
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.OrRelations, t.AndRelations = relation.NewRelations(), relation.NewRelations()
+			t.Diagnostics = append(t.Diagnostics, diagnostics.New(diagnostics.Evaluation, fmt.Sprintf("%v", r), input))
+		}
+	}()
+	t.OrRelations, t.AndRelations = t.Trees.RelationsWith(c.Variables)
+	setFormulas(c.Variables, input, t.OrRelations, t.AndRelations)
+	return t
+}
+
+// setFormulas sets the calculation method that the input specifies, e.g., 'CrCl by Cockcroft-Gault',
+// to the relations of derived variables that the method can compute.
+func setFormulas(vs *variables.Variables, input string, rss ...relation.Relations) {
+	f := formula.Detect(input)
+	if f == formula.Unknown {
+		return
+	}
+	for _, rs := range rss {
+		for _, r := range rs {
+			if v := vs.Variable(r.ID); v != nil && v.Formula.Compatible(f) {
+				r.Formula = f
+			}
+		}
+	}
+}
+
+// items parses the input string to the list of criterion items that the grammar is applied to.
+// Missing variables are inferred from units, and leading bounds that precede any variable are
+// marked with placeholder variables unless the variable is a count variable. A parser panic is returned as an error.
+func (i *Interpreter) items(c *Catalogs, input string) (List, error) {
+	listCopy, err := i.parser.parse(c, input)
+	listCopy.FixMissingVariable(c.Units)
+	var list List
+	for _, listVal := range listCopy {
+		unitCount, compCount, numberCount := 0, 0, 0
+		var markInsert []int
+		listNew := NewItems()
+		for k, item := range listVal {
+			if item.typ == itemVariable {
+				if isCount(c.Variables, item.val) {
+					// Bounds that precede a count variable bound the count, e.g., '≤ 2 prior regimens'.
+					markInsert = nil
+				}
+				break
+			}
+			if item.typ == itemNumber {
+				numberCount += 1
+				if numberCount > max(compCount, unitCount) {
+					markInsert = append(markInsert, k)
+				}
+			}
+			if item.typ == itemComparison {
+				compCount += 1
+				if compCount > max(numberCount, unitCount) {
+					markInsert = append(markInsert, k)
+				}
+			}
+			if item.typ == itemUnit {
+				unitCount += 1
+				if unitCount > max(numberCount, compCount) {
+					markInsert = append(markInsert, k)
+				}
+			}
+			if item.typ == itemRange {
+				markInsert = append(markInsert, k)
+				break
+			}
+		}
+		for k, item := range listVal {
+			if intInSlice(k, markInsert) {
+				newIt := &Item{typ: itemVariable, val: "IGNORE"}
+				listNew.Add(newIt)
+				listNew.Add(item)
+			} else {
+				listNew.Add(item)
+			}
+		}
+		list = append(list, listNew)
+	}
+	return list, err
+}
+
+// buildTrees builds trees from the parsed items with the grammar. Trees represent criteria.
+// Items that are parsed partially, or not at all if there are at least two, are diagnosed.
+func buildTrees(grammar Grammar, list List) (Trees, diagnostics.Diagnostics) {
 	trees := NewTrees()
+	var ds diagnostics.Diagnostics
 	for _, items := range list {
-		ts := i.grammar.BuildTrees(items)
+		ts := grammar.BuildTrees(items)
+		switch {
+		case ts.Empty() && items.Len() > 1:
+			ds = append(ds, diagnostics.New(diagnostics.Grammar, "no parse", items.Text()))
+		case !ts.Empty() && !ts[0].Uncovered().Empty():
+			ds = append(ds, diagnostics.New(diagnostics.Grammar, "partial parse", ts[0].Uncovered().Text()))
+		}
 		trees = append(trees, ts...)
 	}
 	trees.Dedupe()
-	return trees
+	return trees, ds
 }
 
 //get unicode index of substring
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -612,3 +614,80 @@ func TestNonCompositeVariableWithSlashInterpreter(t *testing.T) {
 	a.Empty(actualOrRels)
 	a.Equal(expected, actualAndRels)
 }
+
+func TestCatalogInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	newCatalogs := func(alias string) *Catalogs {
+		vs := variables.New()
+		a.NoError(vs.Add("500", variables.Numerical, "hemoglobin", "Hemoglobin", []string{alias}, []string{"0", "30"}, "g/dl", ""))
+		us := units.New()
+		a.NoError(us.Add("600", "g/dl", "g/dL", []string{"g/dl"}, ""))
+		return NewCatalogs(vs, us)
+	}
+	tenantA := NewCatalogInterpreter(newCatalogs("hb"))
+	tenantB := NewCatalogInterpreter(newCatalogs("hgb"))
+
+	input := "hb greater than 10 g/dl"
+	_, andRels := tenantA.Interpret(input)
+	andRels.ProcessWith(tenantA.Catalogs().Variables)
+	if a.Len(andRels, 1) {
+		a.Equal(variables.ID("500"), andRels[0].ID)
+		a.Equal("hemoglobin", andRels[0].Name)
+		a.Equal("10", andRels[0].Lower.Value)
+	}
+
+	// 'hb' is not an alias in the catalogs of tenant B:
+	_, andRels = tenantB.Interpret(input)
+	andRels.ProcessWith(tenantB.Catalogs().Variables)
+	a.Empty(andRels)
+
+	// The package-level catalogs are not used by catalog interpreters:
+	a.NotEqual(variables.Get(), tenantA.Catalogs().Variables)
+	a.Equal(variables.Get(), NewInterpreter().Catalogs().Variables)
+}
+
+func TestPlausibilityBundledCatalogs(t *testing.T) {
+	a := assert.New(t)
+
+	vs, err := variables.Load("../../resources/variables/variables.csv")
+	a.NoError(err)
+	us, err := units.Load("../../resources/units/units.csv")
+	a.NoError(err)
+	catalogInterpreter := NewCatalogInterpreter(NewCatalogs(vs, us))
+
+	tests := []struct {
+		input    string
+		name     string
+		unit     string
+		score    float64
+		warnings int
+	}{
+		{"platelets ≥ 100 x10^9/l", "platelet_count", "cells/l", 1, 0},
+		{"platelets ≥ 100,000/ul", "platelet_count", "cells/ul", 1, 0},
+		{"platelet count ≥ 100", "platelet_count", "k/ul", 1, 1},
+		{"platelet count ≥ 5000000", "platelet_count", "", 0, 1},
+		{"hemoglobin ≥ 9 g/dl", "hb_count", "g/dl", 1, 0},
+		{"hemoglobin ≥ 6 mmol/l", "hb_count", "mmol/l", 1, 0},
+		{"hemoglobin ≥ 9", "hb_count", "g/dl", 1, 0},
+		{"anc ≥ 1500/mm3", "anc", "cells/ul", 1, 0},
+		{"serum creatinine ≤ 1.5 mg/dl", "creatinine_level", "mg/dl", 1, 0},
+	}
+	for _, test := range tests {
+		_, andRels := catalogInterpreter.Interpret(test.input)
+		andRels.ProcessWith(vs)
+		andRels.TransformWith(vs)
+		if !a.Len(andRels, 1, test.input) {
+			continue
+		}
+		r := andRels[0]
+		var unit string
+		if r.Unit != nil {
+			unit = r.Unit.Value
+		}
+		a.Equal(test.name, r.Name, test.input)
+		a.Equal(test.unit, unit, test.input)
+		a.Equal(test.score == 0, r.Score == 0, test.input)
+		a.Len(r.Warnings, test.warnings, test.input)
+	}
+}
@@ -5,9 +5,11 @@ package parser
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
 )
 
 // itemType defines the type of parsed items.
@@ -24,6 +26,7 @@ const (
 	itemRange
 	itemNumber
 	itemUnit
+	itemWindow
 )
 
 // ItemType converts a string to itemType.
@@ -47,6 +50,8 @@ func ItemType(s string) itemType {
 		return itemNumber
 	case "unit":
 		return itemUnit
+	case "window":
+		return itemWindow
 	default:
 		return itemUnknown
 	}
@@ -73,6 +78,8 @@ func (t itemType) String() string {
 		return "number"
 	case itemUnit:
 		return "unit"
+	case itemWindow:
+		return "window"
 	default:
 		return "unknown"
 	}
@@ -83,6 +90,7 @@ type Item struct {
 	typ  itemType
 	val  string
 	pos  Pos
+	end  Pos // end position of the item in the input, or 0 if the item ends at pos+len(name)
 	name string
 }
 
@@ -136,6 +144,14 @@ func (i *Item) Negate() *Item {
 	}
 }
 
+// span returns the start and end positions of the item in the input.
+func (i *Item) span() (int, int) {
+	if i.end == 0 {
+		return int(i.pos), int(i.pos) + len(i.name)
+	}
+	return int(i.pos), int(i.end)
+}
+
 // String returns the string representation of the item.
 func (i *Item) String() string {
 	return fmt.Sprintf("{type:%q,value:%q,pos:%v,name:%q}", i.typ.String(), i.val, i.pos, i.name)
@@ -171,6 +187,16 @@ func (is Items) LastType() itemType {
 	return is[is.Len()-1].typ
 }
 
+// hasCount tests whether the items have a variable that is a count variable in the catalog vs.
+func (is Items) hasCount(vs *variables.Variables) bool {
+	for _, i := range is {
+		if i.typ == itemVariable && isCount(vs, i.val) {
+			return true
+		}
+	}
+	return false
+}
+
 // Get gets the items of type 'typ'.
 func (is Items) Get(typ itemType) set.Set {
 	set := set.New()
@@ -187,11 +213,10 @@ func (is Items) Get(typ itemType) set.Set {
 
 // FixMissingVariable adds missing variable to the items,
 // if it can be inferred from the unit.
-func (is *Items) FixMissingVariable() bool {
+func (is *Items) FixMissingVariable(unitCatalog *units.Units) bool {
 	if !is.Get(itemVariable).Empty() {
 		return true
 	}
-	unitCatalog := units.Get()
 	candidates := set.New()
 	for u := range is.Get(itemUnit) {
 		if v, ok := unitCatalog.Variable(u); ok {
@@ -288,6 +313,18 @@ func (is Items) String() string {
 	return s
 }
 
+// Text returns the criterion text of the items, which omits items without text,
+// such as placeholder variables.
+func (is Items) Text() string {
+	names := make([]string, 0, len(is))
+	for _, i := range is {
+		if len(i.name) > 0 {
+			names = append(names, i.name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
 // List defines a slice of items.
 type List []Items
 
@@ -305,9 +342,22 @@ func (l List) Sort() {
 
 // FixMissingVariable adds missing variables to the list of items,
 // if they can be inferred from the unit info.
-func (l List) FixMissingVariable() {
+func (l List) FixMissingVariable(unitCatalog *units.Units) {
 	for i := 0; i < len(l); i++ {
-		l[i].FixMissingVariable()
+		l[i].FixMissingVariable(unitCatalog)
+	}
+}
+
+// setOffsets maps the positions of the items in the normalized text n to the original text.
+func (l List) setOffsets(n *Normalized) {
+	for _, items := range l {
+		for _, i := range items {
+			begin, end := i.span()
+			i.pos, i.end = Pos(n.Start(begin)), Pos(n.Start(begin))
+			if end > begin {
+				i.end = Pos(n.End(end))
+			}
+		}
 	}
 }
 
@@ -57,6 +57,7 @@ type Lexer struct {
 	width      Pos         // width of last rune read from input
 	tokens     chan *Token // channel of scanned tokens
 	parenDepth int         // nesting depth of ( )
+	last       tokenType   // type of the last emitted token
 }
 
 // NewLexer creates a new lexer for the input string.
@@ -128,6 +129,7 @@ func (l *Lexer) backup() {
 func (l *Lexer) emit(t tokenType) {
 	l.tokens <- NewToken(t, l.start, l.input[l.start:l.pos])
 	l.start = l.pos
+	l.last = t
 }
 
 // swallow skips over the pending input before this point.
@@ -234,6 +236,8 @@ Loop:
 				l.emit(key[word])
 			case text.IsRomanNumeral(word):
 				l.emit(tokenNumber)
+			case l.scanNumberWords(word):
+				l.emit(tokenNumber)
 			default:
 				l.emit(tokenIdentifier)
 			}
@@ -243,6 +247,57 @@ Loop:
 	return lexAction
 }
 
+// scanNumberWords scans a spelled-out number that starts with the word, such as 'twenty five'
+// or 'two-thirds'. Two numbers joined by a hyphen, such as 'one-two', are scanned up to the hyphen
+// so that they are lexed as a range. 'second' is a unit of time if it follows a number, and a
+// number followed by 'of' quantifies the criteria that follow, e.g., 'at least one of the following'.
+func (l *Lexer) scanNumberWords(word string) bool {
+	if _, ok := text.ParseNumberWords(word); !ok {
+		k := strings.IndexByte(word, '-')
+		if k <= 0 {
+			return false
+		}
+		if _, ok := text.ParseNumberWords(word[:k]); !ok {
+			return false
+		}
+		if _, ok := text.ParseNumberWords(word[k+1:]); !ok {
+			return false
+		}
+		l.pos = l.start + Pos(k)
+		return true
+	}
+	if word == "second" && l.last == tokenNumber {
+		return false
+	}
+	end := l.pos
+	for {
+		pos := l.pos
+		l.acceptRun(" ")
+		begin := l.pos
+		for isIdentifierChar(l.next()) {
+		}
+		l.backup()
+		if _, ok := text.ParseNumberWords(l.input[l.start:l.pos]); !ok || begin == pos || l.pos == begin {
+			l.pos = pos
+			if l.followedBy("of") {
+				l.pos = end
+				return false
+			}
+			return true
+		}
+	}
+}
+
+// followedBy tests whether the word follows the current position after spaces.
+func (l *Lexer) followedBy(word string) bool {
+	rest := strings.TrimLeft(l.input[l.pos:], " ")
+	if !strings.HasPrefix(rest, word) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(rest[len(word):])
+	return !isIdentifierChar(r)
+}
+
 // lexNumber scans a number, which can be int, decimal or scientific.
 func lexNumber(l *Lexer) stateFn {
 	if !l.scanNumber() {
@@ -268,9 +323,26 @@ func (l *Lexer) scanNumber() bool {
 	if isPunctuationChar(r) {
 		l.pos -= Pos(w)
 	}
+	l.scanOrdinalSuffix()
 	return true
 }
 
+// scanOrdinalSuffix scans the suffix of an ordinal number, such as '2nd'.
+func (l *Lexer) scanOrdinalSuffix() {
+	if l.pos == l.start || !unicode.IsDigit(l.current()) {
+		return
+	}
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		if rest := l.input[l.pos:]; strings.HasPrefix(rest, suffix) {
+			r, _ := utf8.DecodeRuneInString(rest[len(suffix):])
+			if !isIdentifierChar(r) {
+				l.pos += Pos(len(suffix))
+			}
+			return
+		}
+	}
+}
+
 // scanScientificMultiplier scans the scientific multiplier ('x 10^exp') of the scientific number.
 // The multiply symbol (x|⨯) is optional. Returns true if a valid multiplier is found.
 func (l *Lexer) scanScientificMultiplier() bool {
@@ -0,0 +1,234 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Superscript and subscript digits map to digits.
+const (
+	superscripts = "⁰¹²³⁴⁵⁶⁷⁸⁹"
+	subscripts   = "₀₁₂₃₄₅₆₇₈₉"
+)
+
+// thousandSeparators are the separators of digit groups that are removed from numbers,
+// e.g., '100 000' and '1'000'. Commas are left to the relations to disambiguate.
+const thousandSeparators = " \u00a0\u2009\u202f'’"
+
+// reComparisonSuffix matches the start of the text or a comparison before a number, e.g., '≥' or 'at least'.
+var reComparisonSuffix = regexp.MustCompile(`(?:^|[≥≤<>=≦≧⩽⩾]|\b(?:than|least|most|of|over|under|above|below|exceeding))\s*$`)
+
+// canonical maps runes to their canonical strings for lexing.
+var canonical = map[rune]string{
+	'\u00a0': " ", // no-break space
+	'\u200b': "",  // zero-width space
+	'\ufeff': "",  // zero-width no-break space
+	'\u00ad': "",  // soft hyphen
+	'‐':      "-", // hyphen
+	'‑':      "-", // non-breaking hyphen
+	'‒':      "-", // figure dash
+	'–':      "-", // en dash
+	'—':      "-", // em dash
+	'―':      "-", // horizontal bar
+	'−':      "-", // minus sign
+	'µ':      "u", // micro sign
+	'μ':      "u", // Greek mu
+	'≦':      "≤",
+	'⩽':      "≤",
+	'≧':      "≥",
+	'⩾':      "≥",
+	'⨯':      "×",
+	'✕':      "×",
+	'⁄':      "/", // fraction slash
+	'∕':      "/", // division slash
+	'⁺':      "+",
+	'⁻':      "-",
+}
+
+// Normalized defines the text that is normalized for lexing, and the offsets that map
+// its byte positions back to the original text.
+type Normalized struct {
+	Text   string
+	length int   // length of the original text
+	starts []int // start offset of the original rune of each byte of the text
+	ends   []int // end offset of the original rune of each byte of the text
+}
+
+// Normalize canonicalizes the Unicode and the international numeric notation of the text
+// before it is lexed: full-width characters are converted to ASCII, Unicode spaces to spaces,
+// dashes and minus signs to '-', micro signs to 'u', superscript exponents of 10 to '^'
+// exponents (e.g., '10⁹' to '10^9'), and other super- and subscripts to digits. Thousand
+// separators that are spaces or apostrophes are removed, and other compatibility characters
+// are replaced by their NFKC decomposition, e.g., '½' by '1/2'.
+func Normalize(s string) *Normalized {
+	n := &Normalized{length: len(s)}
+	var b strings.Builder
+	write := func(t string, start, end int) {
+		b.WriteString(t)
+		for k := 0; k < len(t); k++ {
+			n.starts = append(n.starts, start)
+			n.ends = append(n.ends, end)
+		}
+	}
+	separators := thousandSeparatorPositions(s)
+
+	superscript := false // whether the previous rune is a superscript
+	for i := 0; i < len(s); {
+		r, w := utf8.DecodeRuneInString(s[i:])
+		start, end := i, i+w
+		i = end
+		previous := superscript
+		superscript = strings.ContainsRune(superscripts, r) || r == '⁺' || r == '⁻'
+
+		switch {
+		case separators[start]:
+		case r < utf8.RuneSelf:
+			write(string(r), start, end)
+		case superscript:
+			if !previous && strings.HasSuffix(b.String(), "10") {
+				write("^", start, end)
+			}
+			if t, ok := canonical[r]; ok {
+				write(t, start, end)
+			} else {
+				write(string(digit(superscripts, r)), start, end)
+			}
+		case strings.ContainsRune(subscripts, r):
+			write(string(digit(subscripts, r)), start, end)
+		case 0xff01 <= r && r <= 0xff5e:
+			write(string(r-0xfee0), start, end)
+		default:
+			if t, ok := canonical[r]; ok {
+				write(t, start, end)
+				continue
+			}
+			if unicode.Is(unicode.Zs, r) {
+				write(" ", start, end)
+				continue
+			}
+			t := norm.NFKC.String(string(r))
+			if t != string(r) {
+				t = canonicalize(t)
+				if out := b.String(); len(out) > 0 && isDigit(out[len(out)-1]) && len(t) > 0 && isDigit(t[0]) {
+					t = " " + t
+				}
+			}
+			write(t, start, end)
+		}
+	}
+	n.Text = b.String()
+	return n
+}
+
+// Start returns the offset in the original text of the normalized text position.
+func (n *Normalized) Start(pos int) int {
+	if pos >= len(n.starts) {
+		return n.length
+	}
+	return n.starts[pos]
+}
+
+// End returns the offset in the original text of the normalized text end position,
+// which is exclusive.
+func (n *Normalized) End(end int) int {
+	switch {
+	case end <= 0:
+		return n.Start(0)
+	case end > len(n.ends):
+		return n.length
+	}
+	return n.ends[end-1]
+}
+
+// canonicalize replaces the runes of s that have canonical strings.
+func canonicalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if t, ok := canonical[r]; ok && utf8.RuneCountInString(t) == 1 {
+			r, _ = utf8.DecodeRuneInString(t)
+		}
+		return r
+	}, s)
+}
+
+// thousandSeparatorPositions returns the positions of the thousand separators of s.
+// A number with thousand separators starts with one to three digits, followed by groups
+// of three digits that are separated by the same separator, e.g., '1 000 000'. Because
+// spaces also separate numbers, e.g., 'age 18 120 days', digit groups separated by spaces
+// are a number only if it starts the text or follows a comparison and is not followed by
+// a number, e.g., '≥ 100 000/ul'.
+func thousandSeparatorPositions(s string) map[int]bool {
+	positions := make(map[int]bool)
+	for i := 0; i < len(s); {
+		if !isDigit(s[i]) || (i > 0 && (isDigit(s[i-1]) || s[i-1] == '.' || s[i-1] == ',')) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		if j-i > 3 {
+			i = j
+			continue
+		}
+		var separator rune
+		var number []int
+		for j < len(s) {
+			r, w := utf8.DecodeRuneInString(s[j:])
+			if !strings.ContainsRune(thousandSeparators, r) || (separator != 0 && r != separator) {
+				break
+			}
+			k := j + w
+			for k < len(s) && isDigit(s[k]) {
+				k++
+			}
+			if k-(j+w) != 3 {
+				break
+			}
+			separator = r
+			number = append(number, j)
+			j = k
+		}
+		if separator != ' ' || spaceSeparated(s, i, j) {
+			for _, k := range number {
+				positions[k] = true
+			}
+		}
+		i = j
+	}
+	return positions
+}
+
+// spaceSeparated tests whether the digit groups of s[i:j], which are separated by spaces,
+// are a number: it starts the text or follows a comparison, and it is followed by other
+// than the end of the text or a number.
+func spaceSeparated(s string, i, j int) bool {
+	rest := strings.TrimLeft(s[j:], " ")
+	if len(rest) == 0 || isDigit(rest[0]) {
+		return false
+	}
+	return reComparisonSuffix.MatchString(s[:i])
+}
+
+// digit returns the digit of the rune r in the string of digit runes 0-9.
+func digit(digits string, r rune) rune {
+	k := 0
+	for _, d := range digits {
+		if d == r {
+			break
+		}
+		k++
+	}
+	return rune('0' + k)
+}
+
+// isDigit tests whether the byte b is an ASCII digit.
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
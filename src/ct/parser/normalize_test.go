@@ -0,0 +1,213 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// Full-width characters:
+		{"hba1c ≥ 9.0％", "hba1c ≥ 9.0%"},
+		{"ｂｍｉ ＜ ３０", "bmi < 30"},
+		{"age（years）", "age(years)"},
+		{"a\u3000b", "a b"},
+
+		// Spaces:
+		{"18\u00a0years", "18 years"},
+		{"18\u2009years", "18 years"},
+		{"18\u202fyears", "18 years"},
+		{"a\u200bb", "ab"},
+		{"hyper\u00adtension", "hypertension"},
+
+		// Thousand separators:
+		{"100 000/mm3", "100000/mm3"},
+		{"100\u00a0000/mm3", "100000/mm3"},
+		{"100\u202f000/ul", "100000/ul"},
+		{"1\u2009000\u2009000", "1000000"},
+		{"100'000", "100000"},
+		{"100’000", "100000"},
+		{"≥ 1 500 cells", "≥ 1500 cells"},
+		{"1 000'000", "1000'000"},
+		{"18 65", "18 65"},
+		{"18 120", "18 120"},
+		{"age 18 120 days", "age 18 120 days"},
+		{"1 500 2 000", "1 500 2 000"},
+		{"age 18\u00a0120 days", "age 18120 days"},
+		{"1 0000", "1 0000"},
+		{"1234 567", "1234 567"},
+		{"0.5 100", "0.5 100"},
+		{"1,5 100", "1,5 100"},
+		{"100,000", "100,000"},
+		{"patient's 100", "patient's 100"},
+
+		// Superscripts and subscripts:
+		{"10⁹/l", "10^9/l"},
+		{"1.5×10⁹/l", "1.5×10^9/l"},
+		{"100 x 10¹²/l", "100 x 10^12/l"},
+		{"10¹⁰", "10^10"},
+		{"10⁻³", "10^-3"},
+		{"kg/m²", "kg/m2"},
+		{"1.73 m²", "1.73 m2"},
+		{"mm³", "mm3"},
+		{"pao₂/fio₂", "pao2/fio2"},
+
+		// Micro signs:
+		{"µmol/l", "umol/l"},
+		{"μg/l", "ug/l"},
+		{"/µl", "/ul"},
+
+		// Dashes and minus signs:
+		{"18–65", "18-65"},
+		{"18—65", "18-65"},
+		{"18‒65", "18-65"},
+		{"18―65", "18-65"},
+		{"−5", "-5"},
+		{"non‐smoker", "non-smoker"},
+		{"non‑smoker", "non-smoker"},
+
+		// Comparisons and multiplication signs:
+		{"≦ 1.5", "≤ 1.5"},
+		{"≧ 1.5", "≥ 1.5"},
+		{"⩽ 1.5", "≤ 1.5"},
+		{"⩾ 1.5", "≥ 1.5"},
+		{"1.5⨯10⁹", "1.5×10^9"},
+		{"1.5✕10⁹", "1.5×10^9"},
+
+		// Compatibility characters:
+		{"½", "1/2"},
+		{"1½", "1 1/2"},
+		{"¼ dose", "1/4 dose"},
+		{"1⁄2", "1/2"},
+		{"ﬁbrosis", "fibrosis"},
+
+		// Unchanged:
+		{"a1c < 7%", "a1c < 7%"},
+		{"≤ ≥ × °c", "≤ ≥ × °c"},
+		{"ß-hcg α-amylase", "ß-hcg α-amylase"},
+		{"testostérone", "testostérone"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		a.Equal(test.expected, Normalize(test.input).Text, test.input)
+	}
+}
+
+func TestNormalizeOffsets(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input      string
+		normalized string // substring of the normalized text
+		original   string // substring of the input that the normalized substring maps to
+	}{
+		{"hba1c ≥ 9.0％", "%", "％"},
+		{"hba1c ≥ 9.0％", "9.0%", "9.0％"},
+		{"hba1c ≥ 9.0％", "hba1c", "hba1c"},
+		{"ｂｍｉ ＜ ３０", "bmi", "ｂｍｉ"},
+		{"ｂｍｉ ＜ ３０", "30", "３０"},
+		{"platelets ≥ 100 000/µl", "100000", "100 000"},
+		{"platelets ≥ 100 000/µl", "/ul", "/µl"},
+		{"platelets ≥ 100\u202f000/µl", "100000/ul", "100\u202f000/µl"},
+		{"anc ≥ 1.5×10⁹/l", "10^9", "10⁹"},
+		{"anc ≥ 1.5×10⁹/l", "^9", "⁹"},
+		{"anc ≥ 1.5×10⁹/l", "/l", "/l"},
+		{"age 18–65 years", "18-65", "18–65"},
+		{"age 18–65 years", "years", "years"},
+		{"1½ tablets", "1/2", "½"},
+		{"a\u200bb", "ab", "a\u200bb"},
+	}
+	for _, test := range tests {
+		n := Normalize(test.input)
+		k := strings.Index(n.Text, test.normalized)
+		if a.True(k >= 0, test.normalized) {
+			start, end := n.Start(k), n.End(k+len(test.normalized))
+			a.Equal(test.original, test.input[start:end], test.input)
+		}
+		a.Equal(len(test.input), n.Start(len(n.Text)))
+		a.Equal(len(test.input), n.End(len(n.Text)))
+	}
+}
+
+func TestNormalizedLexer(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"hba1c ≥ 9.0％", []string{"hba1c", "≥", "9.0", "%"}},
+		{"anc ≥ 1.5×10⁹/l", []string{"anc", "≥", "1.5×10^9", "/", "l"}},
+		{"anc ≥ 1.5 ⨯ 10⁹/l", []string{"anc", "≥", "1.5 × 10^9", "/", "l"}},
+		{"platelets ≥ 100 000/µl", []string{"platelets", "≥", "100000", "/", "ul"}},
+		{"platelets ≥ 100'000/mm³", []string{"platelets", "≥", "100000", "/", "mm3"}},
+		{"age 18–65 years", []string{"age", "18", "-", "65", "years"}},
+		{"age 18 — 65 years", []string{"age", "18", "-", "65", "years"}},
+		{"creatinine ≦ 1.5 mg/dl", []string{"creatinine", "≤", "1.5", "mg/dl"}},
+		{"temperature ≥ −2 °c", []string{"temperature", "≥", "-2", "°", "c"}},
+		{"bmi\u00a0<\u00a030 kg/m²", []string{"bmi", "<", "30", "kg/m2"}},
+	}
+	for _, test := range tests {
+		var actual []string
+		for _, token := range NewLexer(Normalize(test.input).Text).Drain() {
+			actual = append(actual, token.val)
+		}
+		a.Equal(test.expected, actual, test.input)
+	}
+}
+
+func TestNormalizedInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input    string
+		name     string
+		lower    string
+		upper    string
+		unit     string
+		unitText string // input text of the unit
+	}{
+		{"hba1c ≥ 9.0％", "a1c", "9.0", "", "%", "％"},
+		{"platelet count ≥ 100 000/µl", "platelet_count", "100000", "", "cells/ul", "/µl"},
+		{"platelet count ≥ 100 000/μl", "platelet_count", "100000", "", "cells/ul", "/μl"},
+		{"platelet count ≥ 100\u202f000/µl", "platelet_count", "100000", "", "cells/ul", "/µl"},
+		{"platelet count ≥ 100'000/mm³", "platelet_count", "100000", "", "cells/ul", "mm³"},
+		{"wbc ≥ 3 000/mm3", "wbc", "3000", "", "cells/ul", "mm3"},
+		{"age 18–65 years", "age", "18", "65", "year", "years"},
+		{"age 18—65 years", "age", "18", "65", "year", "years"},
+		{"bmi 18,5 – 30 kg/m²", "bmi", "18,5", "30", "kg/m2", "kg/m²"},
+		{"ｂｍｉ ＜ ３０", "bmi", "", "30", "", ""},
+		{"pao₂/fio₂ < 300", "pf_ratio", "", "300", "", ""},
+	}
+	interpreter := NewInterpreter()
+	for _, test := range tests {
+		orRs, andRs := interpreter.Interpret(test.input)
+		rs := append(orRs, andRs...)
+		if !a.Len(rs, 1, test.input) {
+			continue
+		}
+		r := rs[0]
+		a.Equal(test.name, r.Name, test.input)
+		if len(test.lower) > 0 && a.NotNil(r.Lower, test.input) {
+			a.Equal(test.lower, r.Lower.Value, test.input)
+		}
+		if len(test.upper) > 0 && a.NotNil(r.Upper, test.input) {
+			a.Equal(test.upper, r.Upper.Value, test.input)
+		}
+		if a.NotNil(r.Unit, test.input) {
+			a.Equal(test.unit, r.Unit.Value, test.input)
+			if len(test.unitText) > 0 && a.Len(r.Unit.Start, 1, test.input) && a.Len(r.Unit.End, 1, test.input) {
+				a.Equal(test.unitText, test.input[r.Unit.Start[0]:r.Unit.End[0]], test.input)
+			}
+		}
+	}
+}
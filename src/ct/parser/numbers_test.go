@@ -0,0 +1,122 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberWordsLexer(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input    string
+		expected Tokens
+	}{
+		{"at least eighteen years", Tokens{
+			NewToken(tokenComparison, 0, "at"),
+			NewToken(tokenComparison, 3, "least"),
+			NewToken(tokenNumber, 9, "eighteen"),
+			NewToken(tokenIdentifier, 18, "years"),
+		}},
+		{"twenty five years", Tokens{
+			NewToken(tokenNumber, 0, "twenty five"),
+			NewToken(tokenIdentifier, 12, "years"),
+		}},
+		{"ecog one-two", Tokens{
+			NewToken(tokenIdentifier, 0, "ecog"),
+			NewToken(tokenNumber, 5, "one"),
+			NewToken(tokenDash, 8, "-"),
+			NewToken(tokenNumber, 9, "two"),
+		}},
+		{"two-thirds", Tokens{
+			NewToken(tokenNumber, 0, "two-thirds"),
+		}},
+		{"the 2nd dose", Tokens{
+			NewToken(tokenIdentifier, 0, "the"),
+			NewToken(tokenNumber, 4, "2nd"),
+			NewToken(tokenIdentifier, 8, "dose"),
+		}},
+		{"1 second", Tokens{
+			NewToken(tokenNumber, 0, "1"),
+			NewToken(tokenIdentifier, 2, "second"),
+		}},
+		{"2nduln", Tokens{
+			NewToken(tokenNumber, 0, "2"),
+			NewToken(tokenIdentifier, 1, "nduln"),
+		}},
+		{"one of the following", Tokens{
+			NewToken(tokenIdentifier, 0, "one"),
+			NewToken(tokenIdentifier, 4, "of"),
+			NewToken(tokenIdentifier, 7, "the"),
+			NewToken(tokenIdentifier, 11, "following"),
+		}},
+	}
+	for _, test := range tests {
+		actual := NewLexer(test.input).Drain()
+		a.Equal(test.expected, actual, test.input)
+	}
+}
+
+func TestNumberWordsInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input string
+		name  string
+		lower string
+		upper string
+		value []string
+	}{
+		{"age eighteen to sixty-five years", "age", "18", "65", nil},
+		{"age at least twenty one years", "age", "21", "", nil},
+		{"ecog one-two", "ecog", "1", "2", nil},
+		{"ecog one to two", "ecog", "1", "2", nil},
+		{"ecog zero or one", "ecog", "", "", []string{"0", "1"}},
+		{"alt < three times the uln", "alt", "", "3", nil},
+		{"alt < twice the uln", "alt", "", "2", nil},
+		{"alt < 2.5 x uln", "alt", "", "2.5", nil},
+	}
+	for _, test := range tests {
+		orRs, andRs := interpreter.Interpret(test.input)
+		rs := append(orRs, andRs...)
+		if !a.NotEmpty(rs, test.input) {
+			continue
+		}
+		r := rs[0]
+		a.Equal(test.name, r.Name, test.input)
+		if len(test.lower) > 0 && a.NotNil(r.Lower, test.input) {
+			a.Equal(test.lower, r.Lower.Value, test.input)
+		}
+		if len(test.upper) > 0 && a.NotNil(r.Upper, test.input) {
+			a.Equal(test.upper, r.Upper.Value, test.input)
+		}
+		if test.value != nil {
+			a.Equal(test.value, r.Value, test.input)
+		}
+	}
+}
+
+func TestNumberWordsQuantifierInterpreter(t *testing.T) {
+	a := assert.New(t)
+
+	// The bundled catalog defines the IGNORE variable that the interpreter inserts before unparsed numbers.
+	vs, err := variables.Load("../../resources/variables/variables.csv")
+	a.NoError(err)
+	us, err := units.Load("../../resources/units/units.csv")
+	a.NoError(err)
+	catalogInterpreter := NewCatalogInterpreter(NewCatalogs(vs, us))
+
+	for _, input := range []string{"at least one of the following", "two of the following criteria:"} {
+		orRs, andRs := catalogInterpreter.Interpret(input)
+		orRs.ProcessWith(vs)
+		andRs.ProcessWith(vs)
+		a.Empty(orRs, input)
+		a.Empty(andRs, input)
+	}
+}
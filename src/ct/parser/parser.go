@@ -3,37 +3,75 @@
 package parser
 
 import (
+	"fmt"
 	"strings"
 
-	"github.com/facebookresearch/clinical-trial-parser/src/ct/units"
-	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
 
 	"github.com/golang/glog"
 )
 
+var (
+	// windowPrepositions are the words that start the time window of a count.
+	windowPrepositions = set.New("in", "within", "during", "over")
+	// windowQualifiers are the words that may precede the length of a time window.
+	windowQualifiers = set.New("past", "last", "previous", "preceding", "prior")
+	// timeUnits maps the words of time units to the units of time windows.
+	timeUnits = map[string]string{
+		"day": "day", "days": "day", "week": "week", "weeks": "week",
+		"month": "month", "months": "month", "year": "year", "years": "year",
+	}
+)
+
 // Parser defines the parser logic for parsing clinical trial eligibility criteria.
 type Parser struct {
-	lexer  *Lexer
-	tokens []*Token // lookahead for parser.
+	lexer    *Lexer
+	tokens   []*Token  // lookahead for parser.
+	catalogs *Catalogs // catalogs of the parser, or nil for the default catalogs
+	active   *Catalogs // catalogs used by the current parse
 }
 
-// NewParser creates a new parser.
+// NewParser creates a new parser that uses the default catalogs.
 func NewParser() *Parser {
 	return &Parser{}
 }
 
+// NewCatalogParser creates a new parser that uses the catalogs c.
+func NewCatalogParser(c *Catalogs) *Parser {
+	return &Parser{catalogs: c}
+}
+
 // Parse parses the input string to the list of criterion items.
-func (p *Parser) Parse(input string) (criteria List) {
+func (p *Parser) Parse(input string) List {
+	c := p.catalogs
+	if c == nil {
+		c = DefaultCatalogs()
+	}
+	criteria, err := p.parse(c, input)
+	if err != nil {
+		glog.Errorf("%v: %q\n", err, input)
+	}
+	return criteria
+}
+
+// parse parses the input string to the list of criterion items using the catalogs c.
+// The input is normalized before it is lexed, and the positions of the items refer to
+// the input. If parsing panics, the panic is returned as an error with an empty list.
+func (p *Parser) parse(c *Catalogs, input string) (criteria List, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			glog.Errorf("%v: %q\n", r, input)
 			criteria = NewList()
+			err = fmt.Errorf("%v", r)
 		}
 	}()
-	p.lexer = NewLexer(input)
+	p.active = c
+	normalized := Normalize(input)
+	p.lexer = NewLexer(normalized.Text)
 	p.tokens = make([]*Token, 0)
 	criteria = p.parseSegment(tokenEOF)
 	criteria.TrimItems()
+	criteria.setOffsets(normalized)
 	return
 }
 
@@ -63,6 +101,12 @@ func (p *Parser) parseSegmentWithoutR(tokenEnd tokenType) List {
 
 loop:
 	for {
+		if nodes.hasCount(p.active.Variables) {
+			if n := p.parseWindow(); n.Valid() {
+				nodes.Add(n)
+				continue
+			}
+		}
 		switch p.peek(1).typ {
 		case tokenLeftParenthesis:
 			p.next()
@@ -138,6 +182,12 @@ func (p *Parser) parseSegment(tokenEnd tokenType) List {
 
 loop:
 	for {
+		if nodes.hasCount(p.active.Variables) {
+			if n := p.parseWindow(); n.Valid() {
+				nodes.Add(n)
+				continue
+			}
+		}
 		switch p.peek(1).typ {
 		case tokenLeftParenthesis:
 			p.next()
@@ -244,11 +294,11 @@ func (p *Parser) parseNumber() *Item {
 	if t := p.next(); t.typ == tokenNumber {
 		n := UnknownItem()
 		if p.peek(1).typ == tokenSlash && p.peek(2).typ == tokenNumber {
-			n.Set(itemNumber, t.val+"/"+p.peek(2).val)
+			n.Set(itemNumber, numberValue(t.val)+"/"+numberValue(p.peek(2).val))
 			p.next()
 			p.next()
 		} else {
-			n.Set(itemNumber, t.val)
+			n.Set(itemNumber, numberValue(t.val))
 		}
 		n.pos = t.pos
 		n.name = t.val
@@ -276,8 +326,8 @@ func (p *Parser) parseIdentifier() *Item {
 	unitMatchCnt := 0
 	identifierCnt := 0
 
-	variableCatalog := variables.Get()
-	unitCatalog := units.Get()
+	variableCatalog := p.active.Variables
+	unitCatalog := p.active.Units
 
 	isIdentifier := true
 
@@ -341,6 +391,43 @@ loop:
 	return n
 }
 
+// parseWindow parses the time window of a count, such as 'in the past year' or 'within the last
+// 12 months'. The tokens are consumed only if they compose a window. The value of the window item
+// is the length and the unit of the window, e.g., '12 month'.
+func (p *Parser) parseWindow() *Item {
+	first := p.peek(1)
+	if !windowPrepositions.Contains(first.val) {
+		return UnknownItem()
+	}
+	k := 2
+	if p.peek(k).val == "the" {
+		k++
+	}
+	qualified := windowQualifiers.Contains(p.peek(k).val)
+	if qualified {
+		k++
+	}
+	length := "1"
+	if t := p.peek(k); t.typ == tokenNumber {
+		length = numberValue(t.val)
+		k++
+	} else if !qualified {
+		return UnknownItem()
+	}
+	last := p.peek(k)
+	unit, ok := timeUnits[last.val]
+	if !ok {
+		return UnknownItem()
+	}
+	for i := 0; i < k; i++ {
+		p.next()
+	}
+	n := NewItem(itemWindow, length+" "+unit)
+	n.pos = first.pos
+	n.name = p.lexer.input[first.pos : int(last.pos)+len(last.val)]
+	return n
+}
+
 func (p *Parser) parseComparison() *Item {
 	n := UnknownItem()
 	t := p.next()
@@ -439,11 +526,16 @@ func (p *Parser) parseConjunction() *Item {
 	if t.typ == tokenConjunction {
 		switch t.val {
 		case "or", "and/or":
+			// 'or more' and 'or less' are comparisons, e.g., '2 or more' or '18 years or older'.
+			// The comparison word is consumed with the conjunction; as an item of its own, it would
+			// not be covered by the parse tree and would lower the score of the relation.
 			switch p.peek(1).typ {
 			case tokenLessComparison:
+				p.next()
 				p.hasEqual()
 				n.Set(itemComparison, "≤")
 			case tokenGreaterComparison:
+				p.next()
 				p.hasEqual()
 				n.Set(itemComparison, "≥")
 			default:
@@ -504,6 +596,14 @@ func (p *Parser) hasEqual() bool {
 	return equal
 }
 
+// numberValue returns the numeral of a spelled-out number, or the number as is.
+func numberValue(s string) string {
+	if v, ok := text.ParseNumberWords(s); ok {
+		return v
+	}
+	return s
+}
+
 func containsStrings(s string, subs ...string) bool {
 	for _, si := range subs {
 		if !strings.Contains(s, si) {
@@ -11,7 +11,7 @@ var CriterionRules = `
 S -> C
 C -> C X | R
 X -> O R | R
-R -> V A | A V | V
+R -> V A | A V | V | R K
 V -> V1 V2 | V1
 V2 -> H V1
 A -> L Y | Y Y | B W | B B | B | E
@@ -32,5 +32,6 @@ N -> number
 U -> unit
 D -> range | and
 H -> slash
+K -> window
 
 `
@@ -0,0 +1,336 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+)
+
+// epsilon denotes the empty right-hand side of a production rule.
+const epsilon = "ε"
+
+// StartSymbol is the start symbol of criterion grammars.
+const StartSymbol = "S"
+
+// Production defines a production rule A -> X1 ... Xn. The right-hand side of a terminal
+// rule is an item type, and the right-hand side of an epsilon rule is empty.
+type Production struct {
+	Left     string
+	Right    []string
+	Terminal bool
+	Line     int // line of the rule in the grammar text, or 0 for derived rules
+}
+
+// String returns the rule text of the production.
+func (p Production) String() string {
+	if len(p.Right) == 0 {
+		return p.Left + " -> " + epsilon
+	}
+	return p.Left + " -> " + strings.Join(p.Right, " ")
+}
+
+// Productions defines a slice of production rules.
+type Productions []Production
+
+// ReadProductions reads the production rules from the grammar text. Rules are listed in the
+// '#nonterminals' and '#terminals' sections as 'A -> X1 ... Xn | Y1 ... Ym', and 'ε' denotes
+// an empty right-hand side. Other lines starting with '#' are comments.
+func ReadProductions(s string) (Productions, error) {
+	var ps Productions
+	ruleType := unknownRule
+	for i, line := range strings.Split(s, "\n") {
+		lineno := i + 1
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "#terminals"):
+			ruleType = terminalRule
+			continue
+		case strings.HasPrefix(line, "#nonterminals"):
+			ruleType = nonTerminalRule
+			continue
+		case len(line) == 0 || line[0] == '#':
+			continue
+		case ruleType == unknownRule:
+			return nil, fmt.Errorf("line %d: rule outside of the #nonterminals and #terminals sections: %s", lineno, line)
+		}
+
+		values := strings.Split(line, "->")
+		if len(values) != 2 {
+			return nil, fmt.Errorf("line %d: cannot read production rule: %s", lineno, line)
+		}
+		left := strings.TrimSpace(values[0])
+		if len(left) == 0 || len(strings.Fields(left)) != 1 {
+			return nil, fmt.Errorf("line %d: left-hand side must be one nonterminal: %s", lineno, line)
+		}
+		for _, alternative := range strings.Split(values[1], "|") {
+			symbols := strings.Fields(alternative)
+			switch {
+			case len(symbols) == 0:
+				return nil, fmt.Errorf("line %d: empty alternative, use %s for an empty right-hand side: %s", lineno, epsilon, line)
+			case len(symbols) == 1 && symbols[0] == epsilon:
+				symbols = nil
+			}
+			for _, symbol := range symbols {
+				if symbol == epsilon {
+					return nil, fmt.Errorf("line %d: %s must be the only symbol of an alternative: %s", lineno, epsilon, line)
+				}
+			}
+			if ruleType == terminalRule && len(symbols) != 1 {
+				return nil, fmt.Errorf("line %d: terminal rules must have one item type per alternative: %s", lineno, line)
+			}
+			ps = append(ps, Production{Left: left, Right: symbols, Terminal: ruleType == terminalRule, Line: lineno})
+		}
+	}
+	return ps, nil
+}
+
+// LoadProductions loads the production rules from the grammar file.
+func LoadProductions(fname string) (Productions, error) {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := ReadProductions(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	return ps, nil
+}
+
+// String returns the grammar text of the production rules.
+func (ps Productions) String() string {
+	var b strings.Builder
+	b.WriteString("#nonterminals:\n\n")
+	for _, p := range ps {
+		if !p.Terminal {
+			b.WriteString(p.String() + "\n")
+		}
+	}
+	b.WriteString("\n#terminals:\n\n")
+	for _, p := range ps {
+		if p.Terminal {
+			b.WriteString(p.String() + "\n")
+		}
+	}
+	return b.String()
+}
+
+// nonTerminals returns the set of symbols that are defined by the rules.
+func (ps Productions) nonTerminals() set.Set {
+	s := set.New()
+	for _, p := range ps {
+		s.Add(p.Left)
+	}
+	return s
+}
+
+// nullable returns the set of nonterminals that derive the empty string.
+func (ps Productions) nullable() set.Set {
+	s := set.New()
+	for added := true; added; {
+		added = false
+		for _, p := range ps {
+			if p.Terminal || s.Contains(p.Left) {
+				continue
+			}
+			all := true
+			for _, X := range p.Right {
+				if !s.Contains(X) {
+					all = false
+					break
+				}
+			}
+			if all {
+				s.Add(p.Left)
+				added = true
+			}
+		}
+	}
+	return s
+}
+
+// Validate checks the production rules of a grammar with the start symbol. It reports
+// right-hand side symbols that are not defined, nonterminals that are not reachable from
+// the start symbol, cycles of unit rules (A -> B, B -> A), which make parse trees infinite,
+// and terminals that are not item types.
+func (ps Productions) Validate(start string) []string {
+	var msgs []string
+	defined := ps.nonTerminals()
+	if !defined.Contains(start) {
+		msgs = append(msgs, fmt.Sprintf("start symbol %s is not defined", start))
+	}
+
+	reported := set.New()
+	for _, p := range ps {
+		if p.Terminal {
+			if t := p.Right[0]; ItemType(t) == itemUnknown && t != itemUnknown.String() {
+				msgs = append(msgs, fmt.Sprintf("line %d: unknown terminal %q: %s", p.Line, t, p))
+			}
+			continue
+		}
+		for _, X := range p.Right {
+			if !defined.Contains(X) && !reported.Contains(X) {
+				reported.Add(X)
+				msgs = append(msgs, fmt.Sprintf("line %d: undefined nonterminal %s: %s", p.Line, X, p))
+			}
+		}
+	}
+
+	reachable := set.New(start)
+	for added := true; added; {
+		added = false
+		for _, p := range ps {
+			if p.Terminal || !reachable.Contains(p.Left) {
+				continue
+			}
+			for _, X := range p.Right {
+				if defined.Contains(X) && !reachable.Contains(X) {
+					reachable.Add(X)
+					added = true
+				}
+			}
+		}
+	}
+	for _, A := range defined.Slice() {
+		if !reachable.Contains(A) {
+			msgs = append(msgs, fmt.Sprintf("nonterminal %s is not reachable from %s", A, start))
+		}
+	}
+
+	for _, cycle := range ps.unitCycles() {
+		msgs = append(msgs, fmt.Sprintf("unit cycle: %s", strings.Join(cycle, " -> ")))
+	}
+	return msgs
+}
+
+// unitCycles returns the cycles of unit rules, including rules that become unit rules
+// when nullable symbols are removed. Each cycle is reported once, starting from its
+// smallest nonterminal.
+func (ps Productions) unitCycles() [][]string {
+	nullable := ps.nullable()
+	edges := make(map[string]set.Set)
+	for _, p := range ps {
+		if p.Terminal {
+			continue
+		}
+		for i, X := range p.Right {
+			others := true
+			for j, Y := range p.Right {
+				if i != j && !nullable.Contains(Y) {
+					others = false
+					break
+				}
+			}
+			if others {
+				if _, ok := edges[p.Left]; !ok {
+					edges[p.Left] = set.New()
+				}
+				edges[p.Left].Add(X)
+			}
+		}
+	}
+
+	var cycles [][]string
+	for _, A := range ps.nonTerminals().Slice() {
+		// Find the shortest path from A back to A through nonterminals larger than A:
+		prev := map[string]string{}
+		queue := []string{A}
+		found := false
+		for len(queue) > 0 && !found {
+			B := queue[0]
+			queue = queue[1:]
+			if edges[B] == nil {
+				continue
+			}
+			for _, C := range edges[B].Slice() {
+				if C == A {
+					prev[A] = B
+					found = true
+					break
+				}
+				if _, ok := prev[C]; !ok && C > A {
+					prev[C] = B
+					queue = append(queue, C)
+				}
+			}
+		}
+		if found {
+			cycle := []string{A}
+			for B := prev[A]; B != A; B = prev[B] {
+				cycle = append([]string{B}, cycle...)
+			}
+			cycles = append(cycles, append([]string{A}, cycle...))
+		}
+	}
+	return cycles
+}
+
+// Binarize converts the production rules to the binary form that CYK parsing requires:
+// epsilon rules are removed by adding the rules without the nullable symbols, and rules
+// with more than two right-hand side symbols A -> X1 X2 ... Xn are split into
+// A -> X1 A.1, A.1 -> X2 A.2, ..., A.(n-2) -> X(n-1) Xn. Rules that are binary already
+// are kept as is, so the trees of a binary grammar do not change.
+func (ps Productions) Binarize() Productions {
+	nullable := ps.nullable()
+	var expanded Productions
+	seen := set.New()
+	add := func(p Production) {
+		if len(p.Right) == 0 || (len(p.Right) == 1 && p.Right[0] == p.Left && !p.Terminal) {
+			return
+		}
+		key := fmt.Sprintf("%t %s", p.Terminal, p)
+		if !seen.Contains(key) {
+			seen.Add(key)
+			expanded = append(expanded, p)
+		}
+	}
+	for _, p := range ps {
+		if p.Terminal {
+			add(p)
+			continue
+		}
+		for _, right := range withoutNullable(p.Right, nullable) {
+			add(Production{Left: p.Left, Right: right, Line: p.Line})
+		}
+	}
+
+	var binary Productions
+	counts := make(map[string]int)
+	for _, p := range expanded {
+		if p.Terminal || len(p.Right) <= 2 {
+			binary = append(binary, p)
+			continue
+		}
+		left := p.Left
+		for i := 0; i < len(p.Right)-2; i++ {
+			counts[p.Left]++
+			next := fmt.Sprintf("%s.%d", p.Left, counts[p.Left])
+			binary = append(binary, Production{Left: left, Right: []string{p.Right[i], next}, Line: p.Line})
+			left = next
+		}
+		binary = append(binary, Production{Left: left, Right: p.Right[len(p.Right)-2:], Line: p.Line})
+	}
+	return binary
+}
+
+// withoutNullable returns the right-hand sides derived from right by removing
+// any subset of its nullable symbols, starting with right itself.
+func withoutNullable(right []string, nullable set.Set) [][]string {
+	rights := [][]string{{}}
+	for _, X := range right {
+		n := len(rights)
+		for i := 0; i < n; i++ {
+			with := append(append([]string{}, rights[i]...), X)
+			if nullable.Contains(X) {
+				rights = append(rights, rights[i])
+			}
+			rights[i] = with
+		}
+	}
+	return rights
+}
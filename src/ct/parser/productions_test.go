@@ -0,0 +1,123 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/parser/production"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadProductions(t *testing.T) {
+	a := assert.New(t)
+
+	ps, err := ReadProductions("#nonterminals:\nS -> A B C | ε\n#terminals:\nA -> number | unit\n")
+	a.NoError(err)
+	expected := Productions{
+		{Left: "S", Right: []string{"A", "B", "C"}, Line: 2},
+		{Left: "S", Line: 2},
+		{Left: "A", Right: []string{"number"}, Terminal: true, Line: 4},
+		{Left: "A", Right: []string{"unit"}, Terminal: true, Line: 4},
+	}
+	a.Equal(expected, ps)
+
+	for _, s := range []string{
+		"S -> A",
+		"#nonterminals:\nS A",
+		"#nonterminals:\nS B -> A",
+		"#nonterminals:\nS -> A |",
+		"#nonterminals:\nS -> A ε",
+		"#terminals:\nA -> number unit",
+	} {
+		_, err := ReadProductions(s)
+		a.Error(err, s)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	a := assert.New(t)
+
+	for _, rules := range []string{production.CriterionRules, production.TestRules} {
+		ps, err := ReadProductions(rules)
+		a.NoError(err)
+		a.Empty(ps.Validate(StartSymbol))
+	}
+
+	ps, err := ReadProductions(`
+#nonterminals:
+S -> A Q
+A -> B C
+B -> A
+C -> ε
+Z -> A
+#terminals:
+B -> number | numbers
+`)
+	a.NoError(err)
+	expected := []string{
+		"line 3: undefined nonterminal Q: S -> A Q",
+		`line 9: unknown terminal "numbers": B -> numbers`,
+		"nonterminal Z is not reachable from S",
+		"unit cycle: A -> B -> A",
+	}
+	a.Equal(expected, ps.Validate(StartSymbol))
+	msgs := ps.Validate("X")
+	a.Equal("start symbol X is not defined", msgs[0])
+	a.Contains(msgs, "nonterminal S is not reachable from X")
+}
+
+func TestBinarize(t *testing.T) {
+	a := assert.New(t)
+
+	ps, err := ReadProductions(`
+#nonterminals:
+S -> A B C D
+B -> ε | N
+#terminals:
+A -> variable
+C -> comparison
+D -> number
+N -> unit
+`)
+	a.NoError(err)
+	expected := `#nonterminals:
+
+S -> A S.1
+S.1 -> B S.2
+S.2 -> C D
+S -> A S.3
+S.3 -> C D
+B -> N
+
+#terminals:
+
+A -> variable
+C -> comparison
+D -> number
+N -> unit
+`
+	a.Equal(expected, ps.Binarize().String())
+
+	// Binary grammars are not changed:
+	ps, err = ReadProductions(production.CriterionRules)
+	a.NoError(err)
+	a.Equal(ps, ps.Binarize())
+}
+
+func TestLoadCFGrammar(t *testing.T) {
+	a := assert.New(t)
+
+	g, err := LoadCFGrammar("../../resources/grammar/criterion.grammar")
+	a.NoError(err)
+	a.Equal(NewCFGrammar(production.CriterionRules), g)
+
+	items := Items{
+		NewItem(itemVariable, "a1c"),
+		NewItem(itemComparison, "<"),
+		NewItem(itemNumber, "7"),
+		NewItem(itemUnit, "%"),
+	}
+	a.Equal(criterionGrammar.BuildTrees(items).String(), g.BuildTrees(items).String())
+}
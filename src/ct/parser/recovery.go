@@ -0,0 +1,66 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+// selectTrees selects the parse trees of the terms from the spans of terms begin..end-1 that
+// the start symbol derives. If the start symbol derives all terms, the tree of the full parse
+// is returned. Otherwise, the criterion is recovered from its sub-parses: the non-overlapping
+// spans of at least two terms that cover the most terms are selected, preferring fewer spans.
+// The trees are scored by the fraction of terms covered, and they record the terms not covered.
+func selectTrees(terms Items, derives func(begin, end int) bool, build func(begin, end int) *Node) Trees {
+	dim := len(terms)
+	if dim == 0 {
+		return NewTrees()
+	}
+
+	// best[j] is the best cover of the terms 0..j-1, whose last span starts at start[j] or -1 if
+	// the term j-1 is not covered:
+	type cover struct{ covered, spans int }
+	best := make([]cover, dim+1)
+	start := make([]int, dim+1)
+	for j := 1; j <= dim; j++ {
+		best[j] = best[j-1]
+		start[j] = -1
+		for i := 0; i < j-1; i++ {
+			if !derives(i, j) {
+				continue
+			}
+			c := cover{covered: best[i].covered + j - i, spans: best[i].spans + 1}
+			if c.covered > best[j].covered || (c.covered == best[j].covered && c.spans < best[j].spans) {
+				best[j] = c
+				start[j] = i
+			}
+		}
+	}
+
+	var spans [][2]int
+	covered := make([]bool, dim)
+	for j := dim; j > 0; {
+		if i := start[j]; i >= 0 {
+			spans = append([][2]int{{i, j}}, spans...)
+			for k := i; k < j; k++ {
+				covered[k] = true
+			}
+			j = i
+		} else {
+			j--
+		}
+	}
+	var uncovered Items
+	for k, item := range terms {
+		if !covered[k] {
+			uncovered = append(uncovered, item)
+		}
+	}
+
+	trees := NewTrees()
+	score := 1.0 - 0.5*float64(dim-best[dim].covered)/float64(dim)
+	for _, s := range spans {
+		if node := build(s[0], s[1]); node.Size() > 1 {
+			tree := NewTree(node, score)
+			tree.uncovered = uncovered
+			trees = append(trees, tree)
+		}
+	}
+	return trees
+}
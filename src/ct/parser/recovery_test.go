@@ -0,0 +1,80 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/diagnostics"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/parser/production"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialParse(t *testing.T) {
+	a := assert.New(t)
+
+	interpreter := NewInterpreter()
+	list, err := interpreter.items(interpreter.Catalogs(), "hba1c > 7 % and or , bmi < 30")
+	a.NoError(err)
+	if !a.Len(list, 1) {
+		return
+	}
+	items := list[0]
+	a.Equal(9, items.Len())
+
+	for _, g := range []Grammar{criterionGrammar, NewEarleyGrammar(production.CriterionRules)} {
+		trees := g.BuildTrees(items)
+		if a.Len(trees, 2) {
+			a.Equal(`0.889 ["a1c" ">" "7" "%"]`, leaves(trees[0]))
+			a.Equal(`0.889 ["bmi" "<" "30"]`, leaves(trees[1]))
+			for _, tree := range trees {
+				a.InDelta(1.0-0.5*2.0/9.0, tree.score, 1e-9)
+				a.Equal("and or", tree.Uncovered().Text())
+			}
+		}
+	}
+
+	trees := criterionGrammar.BuildTrees(items[:4])
+	if a.Len(trees, 1) {
+		a.Equal(1.0, trees[0].score)
+		a.Empty(trees[0].Uncovered())
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	a := assert.New(t)
+
+	interpreter := NewInterpreter()
+
+	_, andRs, ds := interpreter.Diagnose("hba1c > 7 % and or , bmi < 30")
+	a.Len(andRs, 2)
+	if a.Len(ds, 1) {
+		a.Equal(diagnostics.Grammar, ds[0].Stage)
+		a.Equal("partial parse", ds[0].Reason)
+		a.Equal("and or", ds[0].Text)
+	}
+
+	orRs, andRs, ds := interpreter.Diagnose("or and")
+	a.Empty(orRs)
+	a.Empty(andRs)
+	if a.Len(ds, 1) {
+		a.Equal(diagnostics.Grammar, ds[0].Stage)
+		a.Equal("no parse", ds[0].Reason)
+		a.Equal("or and", ds[0].Text)
+	}
+
+	_, _, ds = interpreter.Diagnose("age > 18 years")
+	a.Empty(ds)
+
+	// Catalogs without a variable catalog make the parser panic:
+	broken := NewCatalogInterpreter(&Catalogs{Grammar: criterionGrammar})
+	orRs, andRs, ds = broken.Diagnose("age > 18 years")
+	a.Empty(orRs)
+	a.Empty(andRs)
+	if a.Len(ds, 1) {
+		a.Equal(diagnostics.Parser, ds[0].Stage)
+		a.NotEmpty(ds[0].Reason)
+		a.Equal("age > 18 years", ds[0].Text)
+	}
+}
@@ -3,10 +3,7 @@
 package parser
 
 import (
-	"strings"
-
 	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
-	"github.com/facebookresearch/clinical-trial-parser/src/common/util/slice"
 
 	"github.com/golang/glog"
 )
@@ -28,74 +25,49 @@ const (
 	nonTerminalRule
 )
 
-// LoadRules loads the grammar production rules from the string.
+// LoadRules loads the grammar production rules from the string. Rules that are not
+// in the binary form are binarized. It fails if a rule cannot be read.
 func LoadRules(s string) *Rules {
+	ps, err := ReadProductions(s)
+	if err != nil {
+		glog.Fatalf("Cannot read production rules: %v\n", err)
+	}
+	return newRules(ps.Binarize())
+}
+
+// newRules creates the rule tables of the binarized production rules.
+func newRules(ps Productions) *Rules {
 	terminalRules := map[itemType]set.Set{}
 	unaryRules := map[Element]set.Set{}
 	binaryRules := map[Element]set.Set{}
 	nonTerminalSet := set.New()
 
-	ruleType := unknownRule
-
-	lines := strings.Split(s, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		switch {
-		case strings.HasPrefix(line, "#terminals"):
-			ruleType = terminalRule
-		case strings.HasPrefix(line, "#nonterminals"):
-			ruleType = nonTerminalRule
-		}
-
-		if ruleType == unknownRule || len(line) == 0 || line[0] == '#' {
-			continue
-		}
-
-		values := strings.Split(line, "->")
-		if len(values) != 2 {
-			continue
-		}
-		slice.TrimSpace(values)
-		A := values[0]
-		list := strings.Split(values[1], "|")
-		slice.TrimSpace(list)
-
+	for _, p := range ps {
+		A := p.Left
 		nonTerminalSet.Add(A)
 
-		switch ruleType {
-		case terminalRule:
-			for _, s := range list {
-				a := ItemType(s)
-				if _, ok := terminalRules[a]; !ok {
-					terminalRules[a] = set.New()
-				}
-				terminalRules[a].Add(A)
+		switch {
+		case p.Terminal:
+			a := ItemType(p.Right[0])
+			if _, ok := terminalRules[a]; !ok {
+				terminalRules[a] = set.New()
+			}
+			terminalRules[a].Add(A)
+		case len(p.Right) == 1:
+			e := NewUnary(p.Right[0])
+			if _, ok := unaryRules[e]; !ok {
+				unaryRules[e] = set.New()
 			}
-		case nonTerminalRule:
-			for _, a := range list {
-				symbols := strings.Fields(a)
-				slice.TrimSpace(symbols)
-				switch len(symbols) {
-				case 0:
-					glog.Fatalf("Cannot read production rule: %s\n", line)
-				case 1:
-					e := NewUnary(symbols[0])
-					if _, ok := unaryRules[e]; !ok {
-						unaryRules[e] = set.New()
-					}
-					unaryRules[e].Add(A)
-					nonTerminalSet.Add(e.leftNonTerminal)
-				default:
-					e := NewBinary(symbols[0], symbols[1])
-					if _, ok := binaryRules[e]; !ok {
-						binaryRules[e] = set.New()
-					}
-					binaryRules[e].Add(A)
-					nonTerminalSet.Add(e.leftNonTerminal)
-					nonTerminalSet.Add(e.rightNonTerminal)
-				}
+			unaryRules[e].Add(A)
+			nonTerminalSet.Add(e.leftNonTerminal)
+		default:
+			e := NewBinary(p.Right[0], p.Right[1])
+			if _, ok := binaryRules[e]; !ok {
+				binaryRules[e] = set.New()
 			}
+			binaryRules[e].Add(A)
+			nonTerminalSet.Add(e.leftNonTerminal)
+			nonTerminalSet.Add(e.rightNonTerminal)
 		}
 	}
 
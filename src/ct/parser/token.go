@@ -33,6 +33,50 @@ const (
 	tokenGreaterComparison                  // greater than comparison token
 )
 
+// String converts tokenType to string.
+func (t tokenType) String() string {
+	switch t {
+	case tokenError:
+		return "error"
+	case tokenEOF:
+		return "eof"
+	case tokenChar:
+		return "char"
+	case tokenSpace:
+		return "space"
+	case tokenIdentifier:
+		return "identifier"
+	case tokenNumber:
+		return "number"
+	case tokenUnit:
+		return "unit"
+	case tokenLeftParenthesis:
+		return "left_parenthesis"
+	case tokenRightParenthesis:
+		return "right_parenthesis"
+	case tokenDash:
+		return "dash"
+	case tokenSlash:
+		return "slash"
+	case tokenPunctuation:
+		return "punctuation"
+	case tokenKeyword:
+		return "keyword"
+	case tokenConjunction:
+		return "conjunction"
+	case tokenNegation:
+		return "negation"
+	case tokenComparison:
+		return "comparison"
+	case tokenLessComparison:
+		return "less_comparison"
+	case tokenGreaterComparison:
+		return "greater_comparison"
+	default:
+		return "unknown"
+	}
+}
+
 // Pos is the rune position of the token in the string.
 type Pos int
 
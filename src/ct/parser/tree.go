@@ -4,9 +4,9 @@ package parser
 
 import (
 	"fmt"
-	"log"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
@@ -15,8 +15,9 @@ import (
 
 // Tree defines the parse tree.
 type Tree struct {
-	root  *Node
-	score float64
+	root      *Node
+	score     float64
+	uncovered Items // items of a partial parse that no tree of the items covers
 }
 
 // NewTree creates a new parse tree.
@@ -32,6 +33,11 @@ func (t *Tree) Size() int {
 	return t.root.Size()
 }
 
+// Uncovered returns the items that are not parsed if the tree is a partial parse.
+func (t *Tree) Uncovered() Items {
+	return t.uncovered
+}
+
 // Contains returns true if the tree t contains the tree v as a sub-tree or they are same.
 func (t *Tree) Contains(v *Tree) bool {
 	return t.root.Contains(v.root)
@@ -42,9 +48,9 @@ func (t *Tree) String() string {
 	return fmt.Sprintf("{%q:%.3f,%q:%s}", "score", t.score, "tree", t.root.String())
 }
 
-// Relations converts the tree to 'or' and 'and' relations.
-func (t *Tree) Relations() (relation.Relations, relation.Relations) {
-	orRels, andRels := t.root.EvalRelations()
+// Relations converts the tree to 'or' and 'and' relations. Variable IDs are looked up in vs.
+func (t *Tree) Relations(vs *variables.Variables) (relation.Relations, relation.Relations) {
+	orRels, andRels := t.root.EvalRelations(vs)
 	orRels.SetScore(t.score)
 	orRels.Sort()
 	andRels.SetScore(t.score)
@@ -99,10 +105,15 @@ func (ts Trees) String() string {
 
 // Relations converts the trees to 'or' and 'and' relations.
 func (ts Trees) Relations() (relation.Relations, relation.Relations) {
+	return ts.RelationsWith(variables.Get())
+}
+
+// RelationsWith converts the trees to 'or' and 'and' relations using the variable catalog vs.
+func (ts Trees) RelationsWith(vs *variables.Variables) (relation.Relations, relation.Relations) {
 	orRels := relation.NewRelations()
 	andRels := relation.NewRelations()
 	for _, t := range ts {
-		or, and := t.Relations()
+		or, and := t.Relations(vs)
 		orRels = append(orRels, or...)
 		andRels = append(andRels, and...)
 	}
@@ -254,7 +265,6 @@ func (n *Node) EvalUnit() *relation.Unit {
 			return
 		}
 		if n.left != nil {
-			log.Printf("%+v", n.left)
 			m := n.left
 			if m.val == "U" {
 				unit.Value = m.left.val
@@ -339,8 +349,31 @@ func (n *Node) EvalRange() *relation.Limit {
 	return l
 }
 
+// EvalWindow evaluates and returns the time window stored in the terminal leaf.
+func (n *Node) EvalWindow() *relation.Window {
+	leaf := n.left
+	w := &relation.Window{Start: leaf.pos, End: leaf.width}
+	if k := strings.IndexByte(leaf.val, ' '); k > 0 {
+		w.Value, w.Unit = leaf.val[:k], leaf.val[k+1:]
+	}
+	return w
+}
+
 // EvalRelation evaluates and returns the relation stored in the parse node based on the production rules.
-func (n *Node) EvalRelation() (*relation.Relation, error) {
+// The variable ID of the relation is looked up in vs.
+func (n *Node) EvalRelation(vs *variables.Variables) (*relation.Relation, error) {
+	if n.left != nil && n.left.val == "R" {
+		r, err := n.left.EvalRelation(vs)
+		if err == nil && n.right != nil && n.right.val == "K" {
+			r.Window = n.right.EvalWindow()
+			if r.Lower == nil && r.Upper == nil {
+				// A count without bounds occurs at least once in the window, e.g., 'seizures in the past year'.
+				r.Lower = &relation.Limit{Incl: true, Value: "1"}
+			}
+		}
+		return r, err
+	}
+
 	r := relation.New()
 
 	left := n.left
@@ -356,7 +389,7 @@ func (n *Node) EvalRelation() (*relation.Relation, error) {
 	r.Name = left.EvalVariable()
 	r.Start = left.EvalStart()
 	r.End = left.EvalEnd()
-	r.ID, _ = variables.Get().ID(r.Name)
+	r.ID, _ = vs.ID(r.Name)
 
 	// Check that the attribute node A exists:
 
@@ -419,17 +452,17 @@ func (n *Node) EvalRelation() (*relation.Relation, error) {
 }
 
 // EvalRelations evaluates and returns the 'or' and 'and' relations stored in the parse node.
-func (n *Node) EvalRelations() (relation.Relations, relation.Relations) {
+func (n *Node) EvalRelations(vs *variables.Variables) (relation.Relations, relation.Relations) {
 	if n.left == nil {
 		return relation.NewRelations(), relation.NewRelations()
 	}
 	switch {
 	case n.left.val == "C" && n.right == nil:
-		return n.left.EvalRelations()
+		return n.left.EvalRelations(vs)
 	case n.left.val == "R" && n.right == nil:
 		orRels := relation.NewRelations()
 		andRels := relation.NewRelations()
-		r, _ := n.left.EvalRelation()
+		r, _ := n.left.EvalRelation(vs)
 		andRels = append(andRels, r)
 		return orRels, andRels
 	default:
@@ -441,8 +474,8 @@ func (n *Node) EvalRelations() (relation.Relations, relation.Relations) {
 		} else {
 			m = m.left
 		}
-		orRels, andRels := n.left.EvalRelations()
-		if r, err := m.EvalRelation(); err == nil {
+		orRels, andRels := n.left.EvalRelations(vs)
+		if r, err := m.EvalRelation(vs); err == nil {
 			switch conj {
 			case "or":
 				orRels = append(orRels, r)
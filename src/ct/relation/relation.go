@@ -14,6 +14,7 @@ import (
 	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/slice"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
 
 	"github.com/golang/glog"
@@ -23,8 +24,37 @@ var (
 	reMissingZero = regexp.MustCompile(`,00$`)
 	reRadixComma  = regexp.MustCompile(`^\d{1,2},\d$`)
 	reTimes       = regexp.MustCompile(`\s*(x|×)\s*`)
+
+	// days are the lengths of units of time in days.
+	days = map[string]float64{"day": 1, "week": 7, "month": 30, "year": 365}
+
+	// magnitudes are the sizes of laboratory units in the base unit of their quantity, so that values
+	// can be converted between units that do not depend on the analyte, e.g., 1 k/ul is 1000 cells/ul.
+	// Mass and molar concentrations are different quantities, because converting between them
+	// depends on the molar mass of the analyte.
+	magnitudes = map[string]magnitude{
+		"cells/ul": {"count", 1},
+		"k/ul":     {"count", 1e3},
+		"cells/l":  {"count", 1e-6},
+		"g/l":      {"mass", 1},
+		"g/dl":     {"mass", 10},
+		"mg/dl":    {"mass", 1e-2},
+		"mg/l":     {"mass", 1e-3},
+		"umol/l":   {"molar", 1},
+		"mmol/l":   {"molar", 1e3},
+	}
+
+	// relativeUnits are the units of bounds relative to reference values, e.g., '1.5 x ULN',
+	// whose plausibility does not depend on the unit of the variable.
+	relativeUnits = set.New("uln", "lln", "baseline")
 )
 
+// magnitude defines the quantity of a unit and its size in the base unit of the quantity.
+type magnitude struct {
+	quantity string
+	size     float64
+}
+
 // Limit defines a lower or upper bound of a numerical relation.
 type Limit struct {
 	Incl  bool   `json:"incl"`  // True if limit is inclusive
@@ -39,19 +69,71 @@ type Unit struct {
 	End   []int  `json:"end"`   // end position of limit bound
 }
 
-// Relation defines a boolean, nominal, ordinal, or numerical criterion.
+// Window defines the time window of a count relation, e.g., 'in the past 12 months'.
+type Window struct {
+	Value string `json:"value"` // length of the window in the unit
+	Unit  string `json:"unit"`  // unit of time: day, week, month, or year
+	Start int    `json:"start"` // start position of the window
+	End   int    `json:"end"`   // end position of the window
+}
+
+// Relation defines a boolean, nominal, ordinal, numerical, or count criterion.
 type Relation struct {
-	ID           variables.ID   `json:"id,omitempty"`
-	Name         string         `json:"name"`            // Relation name, typically the variable name
-	DisplayName  string         `json:"-"`               // Variable display name
-	Unit         *Unit          `json:"unit,omitempty"`  // Variable unit
-	Value        []string       `json:"value,omitempty"` // Valid values of categorical relation
-	Lower        *Limit         `json:"lower,omitempty"` // Lower bound of numerical relation condition
-	Upper        *Limit         `json:"upper,omitempty"` // Upper bound of numerical relation condition
-	VariableType variables.Type `json:"variable_type"`   // Type of relation
-	Score        float64        `json:"score"`           // Confidence estimate of the relation representation being correct
-	Start        int            `json:"start"`           // Start position in current input string
-	End          int            `json:"end"`             // End position in current input string
+	ID           variables.ID    `json:"id,omitempty"`
+	Name         string          `json:"name"`                   // Relation name, typically the variable name
+	DisplayName  string          `json:"-"`                      // Variable display name
+	Unit         *Unit           `json:"unit,omitempty"`         // Variable unit
+	Value        []string        `json:"value,omitempty"`        // Valid values of categorical relation
+	Lower        *Limit          `json:"lower,omitempty"`        // Lower bound of numerical relation condition
+	Upper        *Limit          `json:"upper,omitempty"`        // Upper bound of numerical relation condition
+	Concept      *variables.Code `json:"concept,omitempty"`      // Concept that a count relation counts
+	Window       *Window         `json:"window,omitempty"`       // Time window of a count relation
+	Formula      formula.Formula `json:"formula,omitempty"`      // Calculation method of a derived variable specified by the criterion
+	Condition    Relations       `json:"condition,omitempty"`    // Relations that the relation applies to, e.g., women of childbearing potential
+	Warnings     []string        `json:"warnings,omitempty"`     // Reasons why the relation may be incorrect, e.g., an implausible value
+	VariableType variables.Type  `json:"variable_type"`          // Type of relation
+	Score        float64         `json:"score"`                  // Confidence estimate of the relation representation being correct
+	Start        int             `json:"start"`                  // Start position in current input string
+	End          int             `json:"end"`                    // End position in current input string
+	Clause       int             `json:"clause_index,omitempty"` // Index of the clause of the criterion that the relation is parsed from
+	ClauseStart  int             `json:"clause_start,omitempty"` // Start position of the clause in the criterion
+	ClauseEnd    int             `json:"clause_end,omitempty"`   // End position of the clause in the criterion, or 0 if the criterion is not split
+}
+
+// SetClause records the clause that the relation is parsed from. Positions in the clause
+// are shifted by the clause start to positions in the criterion.
+func (r *Relation) SetClause(index, start, end int) {
+	r.Clause = index
+	r.ClauseStart = start
+	r.ClauseEnd = end
+	for _, c := range r.Condition {
+		c.SetClause(index, start, end)
+	}
+	if start == 0 {
+		return
+	}
+	r.Start += start
+	r.End += start
+	for _, l := range []*Limit{r.Lower, r.Upper} {
+		if l != nil {
+			shift(l.Start, start)
+			shift(l.End, start)
+		}
+	}
+	if r.Unit != nil {
+		shift(r.Unit.Start, start)
+		shift(r.Unit.End, start)
+	}
+	if r.Window != nil {
+		r.Window.Start += start
+		r.Window.End += start
+	}
+}
+
+func shift(positions []int, offset int) {
+	for i := range positions {
+		positions[i] += offset
+	}
 }
 
 // Relations defines a slice of relations.
@@ -91,7 +173,7 @@ func (r *Relation) JSON() string {
 
 // HumanReadable converts the relation to the human readable form.
 func (r *Relation) HumanReadable() string {
-	if r.VariableType == variables.Numerical {
+	if r.VariableType == variables.Numerical || r.VariableType == variables.Count {
 		var s string
 		if r.Lower != nil {
 			s = r.DisplayName
@@ -119,9 +201,12 @@ func (r *Relation) HumanReadable() string {
 			}
 			s += r.Upper.Value
 		}
-		if r.Unit.Value != "" {
+		if r.Unit != nil && r.Unit.Value != "" {
 			s += " " + r.Unit.Value
 		}
+		if r.Window != nil {
+			s += fmt.Sprintf(" in the past %s %s", r.Window.Value, r.Window.Unit)
+		}
 		return s
 	}
 	return text.Join(text.Titles(r.Value), ", ", " or ")
@@ -157,7 +242,7 @@ func (r *Relation) Normalize(valueRange []string) {
 		}
 		r.Lower = nil
 		r.Upper = nil
-	case variables.Numerical:
+	case variables.Numerical, variables.Count:
 		if r.Lower != nil || r.Upper != nil {
 			r.Value = nil
 		}
@@ -188,7 +273,7 @@ func (r *Relation) Valid() bool {
 		if len(r.Value) == 0 {
 			return false
 		}
-	case variables.Numerical:
+	case variables.Numerical, variables.Count:
 		if r.Lower == nil && r.Upper == nil {
 			return false
 		}
@@ -244,42 +329,116 @@ func (r *Relation) Negate(valueRange []string) {
 }
 
 // Transform transforms criteria relations by converting parsed values to strings of valid literals.
-// If a valid literal cannot be inferred, the confidence score of the relation is set to zero.
+// If a valid literal cannot be inferred or the value is not plausible, the confidence score of
+// the relation is set to zero and the reason is added to the warnings of the relation.
+// A missing unit is inferred from the magnitude of the values; see checkPlausibility.
 // Indifferent nominal relations are removed by setting the confidence score to zero.
 func (r *Relation) Transform() {
-	variableCatalog := variables.Get()
-	v := variableCatalog.Variable(r.ID)
+	r.TransformWith(variables.Get())
+}
+
+// TransformWith transforms the relation like Transform using the variable catalog vs.
+func (r *Relation) TransformWith(vs *variables.Variables) {
+	v := vs.Variable(r.ID)
 	switch r.VariableType {
 	case variables.Boolean:
 		if r.ID != variables.Zero && text.IsYesNo(r.Value) {
 			r.Score = 0
 		}
-	case variables.Numerical:
-		if r.Lower != nil {
-			if s, err := transform(v, r.Lower.Value); err == nil {
-				r.Lower.Value = s
-			} else {
-				r.Score = 0
+	case variables.Numerical, variables.Count:
+		var vals []float64
+		valid := true
+		for _, l := range []*Limit{r.Lower, r.Upper} {
+			if l == nil {
+				continue
+			}
+			s, err := transform(v.Name, l.Value)
+			if err != nil {
+				r.warn(err.Error())
+				valid = false
+				continue
 			}
+			l.Value = s
+			val, _ := strconv.ParseFloat(s, 64)
+			vals = append(vals, val)
 		}
-		if r.Upper != nil {
-			if s, err := transform(v, r.Upper.Value); err == nil {
-				r.Upper.Value = s
-			} else {
-				r.Score = 0
+		if valid && len(vals) > 0 {
+			r.checkPlausibility(v, vals)
+		}
+	}
+}
+
+// checkPlausibility checks that the values of the bounds are plausible in the unit of the relation.
+// Values of variables without plausible ranges per unit are checked against the valid range of
+// the variable. If the criterion does not specify the unit and the values are not plausible in
+// the default unit, the unit is inferred from the magnitude of the values: for example, a platelet
+// count '≥ 100' is in k/ul and '≥ 100,000' in cells/ul. A unit is inferred only if the values are
+// plausible in exactly one allowed unit, and the inferred unit is added to the warnings. A unit
+// that the criterion specifies but the variable does not allow scores the relation zero.
+func (r *Relation) checkPlausibility(v *variables.Variable, vals []float64) {
+	if len(v.Units) == 0 {
+		for _, val := range vals {
+			if !v.InRange(val) {
+				r.warn(fmt.Sprintf("value %g not in valid range of variable: %s", val, v.Name))
+				return
+			}
+		}
+		return
+	}
+	var unit string
+	if r.Unit != nil {
+		unit = r.Unit.Value
+	}
+	if relativeUnits.Contains(unit) {
+		return
+	}
+	if r.Unit != nil && len(r.Unit.Start) > 0 {
+		// The criterion specifies the unit.
+		u, ok := v.UnitRange(unit)
+		if !ok {
+			r.warn(fmt.Sprintf("unit %q not allowed for variable: %s", unit, v.Name))
+			return
+		}
+		for _, val := range vals {
+			if !u.Contains(val) {
+				r.warn(fmt.Sprintf("value %g %s not plausible for variable: %s", val, unit, v.Name))
+				return
 			}
 		}
+		return
+	}
+	units := v.PlausibleUnits(vals...)
+	for _, u := range units {
+		if u == unit {
+			return
+		}
+	}
+	switch {
+	case len(units) == 0:
+		r.warn(fmt.Sprintf("values %v not plausible in any unit of variable: %s", vals, v.Name))
+	case len(units) == 1:
+		r.Unit = &Unit{Value: units[0]}
+		r.Warnings = append(r.Warnings, fmt.Sprintf("unit inferred from magnitude: %s", units[0]))
+	case len(unit) > 0:
+		r.warn(fmt.Sprintf("values %v not plausible in default unit %s of variable: %s", vals, unit, v.Name))
 	}
 }
 
+// warn adds the reason to the warnings of the relation and sets the confidence score to zero.
+func (r *Relation) warn(reason string) {
+	r.Warnings = append(r.Warnings, reason)
+	r.Score = 0
+}
+
 // transform replaces the radix comma by dot, adds a missing zero (e.g., 150,00 -> 150,000),
 // and removes the thousand commas. If the string value cannot be converted to a float literal,
-// non-nil error is returned.
-func transform(v *variables.Variable, s string) (string, error) {
+// non-nil error is returned. The missing zero is not added to the values of the variable wbc.
+func transform(name, s string) (string, error) {
+	value := s
 	if reRadixComma.MatchString(s) {
 		s = strings.Replace(s, ",", ".", 1)
 	} else {
-		if v.Name != "wbc" { // For wbc, 100,00 may mean 10,000.
+		if name != "wbc" { // For wbc, 100,00 may mean 10,000.
 			s = reMissingZero.ReplaceAllString(s, "000")
 		}
 		s = strings.Replace(s, ",", "", -1)
@@ -288,24 +447,27 @@ func transform(v *variables.Variable, s string) (string, error) {
 	if len(values) == 2 {
 		s = values[0] + text.NormalizeScientificMultiplier(values[1])
 	}
-	val, err := strconv.ParseFloat(s, 64)
-	if err == nil && !v.InRange(val) {
-		err = fmt.Errorf("value %q not in valid range of variable: %s", s, v.Name)
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return s, fmt.Errorf("value %q is not a number", value)
 	}
-	return s, err
+	return s, nil
 }
 
 // Split splits the combination relation into individual relations. Because such relations may not have
 // a valid ID, the split operation needs to be done before validation.
 func (r *Relation) Split() Relations {
-	variableCatalog := variables.Get()
+	return r.SplitWith(variables.Get())
+}
+
+// SplitWith splits the combination relation like Split using the variable catalog vs.
+func (r *Relation) SplitWith(vs *variables.Variables) Relations {
 	names := strings.Split(r.Name, "/")
 	if len(names) != 2 {
 		return Relations{r}
 	}
 	slice.TrimSpace(names)
-	id0, ok0 := variableCatalog.ID(names[0])
-	id1, ok1 := variableCatalog.ID(names[1])
+	id0, ok0 := vs.ID(names[0])
+	id1, ok1 := vs.ID(names[1])
 	if !(ok0 && ok1) {
 		return Relations{r}
 	}
@@ -340,7 +502,7 @@ func (r *Relation) Split() Relations {
 
 // Less compares two numerical relations by their limits.
 func (r *Relation) Less(q *Relation) bool {
-	if r.ID != q.ID || r.VariableType != variables.Numerical {
+	if r.ID != q.ID || (r.VariableType != variables.Numerical && r.VariableType != variables.Count) {
 		return false
 	}
 	var rval string
@@ -360,6 +522,129 @@ func (r *Relation) Less(q *Relation) bool {
 	return rval < qval
 }
 
+// Evaluate tests whether the values of the patient satisfy the relation. A relation whose condition
+// the patient does not satisfy, e.g., contraception of women of childbearing potential, is satisfied.
+// Boolean, nominal, and ordinal relations are satisfied if the patient has one of their values.
+// The value of a derived variable is computed by the formula that the criterion specifies,
+// or else by the formula of the variable, unless the patient has the value. Bounds relative
+// to the baseline, such as 'ALT > 3× baseline', are multiplied by the baseline value of the patient,
+// and bounds relative to the ULN or LLN by the limit of normal of the patient. Bounds in other units
+// than the default unit of the variable, or its first allowed unit if it has no default unit,
+// which the patient values are in, are converted to it.
+// An error is returned if the relation cannot be evaluated, e.g., a value or a limit of normal is
+// missing or the unit of the bounds cannot be converted.
+func (r *Relation) Evaluate(p *formula.Patient, vs *variables.Variables) (bool, error) {
+	for _, c := range r.Condition {
+		ok, err := c.Evaluate(p, vs)
+		if err != nil {
+			return false, fmt.Errorf("%s: condition: %v", r.Name, err)
+		}
+		if !ok {
+			return true, nil
+		}
+	}
+	switch r.VariableType {
+	case variables.Boolean, variables.Nominal, variables.Ordinal:
+		val, ok := p.Category(r.Name)
+		if !ok {
+			return false, fmt.Errorf("%s: missing value", r.Name)
+		}
+		return set.New(r.Value...).Contains(val), nil
+	case variables.Numerical, variables.Count:
+	default:
+		return false, fmt.Errorf("%s: cannot evaluate %s relation", r.Name, r.VariableType)
+	}
+	if r.Lower == nil && r.Upper == nil {
+		return false, fmt.Errorf("%s: relation has no bounds", r.Name)
+	}
+	val, err := r.value(p, vs)
+	if err != nil {
+		return false, err
+	}
+	scale := 1.0
+	if r.Unit != nil {
+		var refs map[string]float64
+		switch r.Unit.Value {
+		case "baseline":
+			refs = p.Baselines
+		case "uln":
+			refs = p.ULN
+		case "lln":
+			refs = p.LLN
+		}
+		if refs != nil {
+			ref, ok := refs[r.Name]
+			if !ok {
+				return false, fmt.Errorf("%s: missing %s value", r.Name, r.Unit.Value)
+			}
+			scale = ref
+		} else if v := vs.Variable(r.ID); v != nil {
+			unit := v.UnitName
+			if len(unit) == 0 && len(v.Units) > 0 {
+				unit = v.Units[0].Unit
+			}
+			if scale, err = convert(r.Unit.Value, unit); err != nil {
+				return false, fmt.Errorf("%s: %v", r.Name, err)
+			}
+		}
+	}
+	if r.Lower != nil {
+		b, err := strconv.ParseFloat(r.Lower.Value, 64)
+		if err != nil {
+			return false, fmt.Errorf("%s: bad lower bound: %v", r.Name, err)
+		}
+		if b *= scale; val < b || (val == b && !r.Lower.Incl) {
+			return false, nil
+		}
+	}
+	if r.Upper != nil {
+		b, err := strconv.ParseFloat(r.Upper.Value, 64)
+		if err != nil {
+			return false, fmt.Errorf("%s: bad upper bound: %v", r.Name, err)
+		}
+		if b *= scale; val > b || (val == b && !r.Upper.Incl) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// convert returns the factor that converts values in the unit from to the unit to. Values of
+// variables without a default unit and values without a unit are not converted.
+func convert(from, to string) (float64, error) {
+	if len(from) == 0 || len(to) == 0 || from == to {
+		return 1, nil
+	}
+	if d1, ok1 := days[from]; ok1 {
+		if d2, ok2 := days[to]; ok2 {
+			return d1 / d2, nil
+		}
+	}
+	if m1, ok1 := magnitudes[from]; ok1 {
+		if m2, ok2 := magnitudes[to]; ok2 && m1.quantity == m2.quantity {
+			return m1.size / m2.size, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+}
+
+// value returns the value of the relation variable for the patient.
+func (r *Relation) value(p *formula.Patient, vs *variables.Variables) (float64, error) {
+	f := r.Formula
+	if f == formula.Unknown {
+		if val, ok := p.Values[r.Name]; ok {
+			return val, nil
+		}
+		if v := vs.Variable(r.ID); v != nil {
+			f = v.Formula
+		}
+	}
+	if f == formula.Unknown {
+		return 0, fmt.Errorf("%s: missing value", r.Name)
+	}
+	return f.Compute(p)
+}
+
 // JSON converts the the relations slice to the json string.
 func (rs Relations) JSON() string {
 	b, err := json.Marshal(rs)
@@ -377,6 +662,18 @@ func (rs Relations) SetScore(score float64) {
 	}
 }
 
+// SetClause records the clause that the relations are parsed from.
+// Positions of a relation that occurs more than once are shifted only once.
+func (rs Relations) SetClause(index, start, end int) {
+	seen := make(map[*Relation]bool)
+	for _, r := range rs {
+		if !seen[r] {
+			seen[r] = true
+			r.SetClause(index, start, end)
+		}
+	}
+}
+
 // MinScore returns the minimum score of the relations.
 func (rs Relations) MinScore() float64 {
 	if len(rs) == 0 {
@@ -414,12 +711,14 @@ func (rs *Relations) Dedupe() {
 	*rs = a
 }
 
-// setRelationFields sets the relation variable and unit fields.
-func (rs Relations) setRelationFields() {
-	variableCatalog := variables.Get()
+// setRelationFields sets the relation variable and unit fields, and the concepts of count relations.
+func (rs Relations) setRelationFields(vs *variables.Variables) {
 	for _, r := range rs {
-		if v := variableCatalog.Variable(r.ID); v != nil {
+		if v := vs.Variable(r.ID); v != nil {
 			r.SetVariableFields(v)
+			if c, ok := v.Concept(); ok {
+				r.Concept = &c
+			}
 			if r.Unit != nil {
 				if r.Unit.Value == "" {
 					r.SetUnitField(v)
@@ -430,19 +729,18 @@ func (rs Relations) setRelationFields() {
 }
 
 // split splits the multi-variable relation to the individual relations.
-func (rs *Relations) split() {
+func (rs *Relations) split(vs *variables.Variables) {
 	rels := NewRelations()
 	for _, r := range *rs {
-		rels = append(rels, r.Split()...)
+		rels = append(rels, r.SplitWith(vs)...)
 	}
 	*rs = rels
 }
 
 // Normalize normalizes the relation.
-func (rs Relations) normalize() {
-	variableCatalog := variables.Get()
+func (rs Relations) normalize(vs *variables.Variables) {
 	for _, r := range rs {
-		if v := variableCatalog.Variable(r.ID); v != nil {
+		if v := vs.Variable(r.ID); v != nil {
 			r.Normalize(v.Range)
 		}
 	}
@@ -462,26 +760,40 @@ func (rs *Relations) validate() {
 // Process splits the relations if needed, sets the correct types,
 // normalizes and removes invalid relations.
 func (rs *Relations) Process() {
-	rs.split()
-	rs.setRelationFields()
-	rs.normalize()
+	rs.ProcessWith(variables.Get())
+}
+
+// ProcessWith processes the relations like Process using the variable catalog vs.
+func (rs *Relations) ProcessWith(vs *variables.Variables) {
+	rs.split(vs)
+	rs.setRelationFields(vs)
+	rs.normalize(vs)
 	rs.validate()
 	rs.Sort()
 }
 
 // Negate negates the relations.
 func (rs Relations) Negate() {
-	variableCatalog := variables.Get()
+	rs.NegateWith(variables.Get())
+}
+
+// NegateWith negates the relations using the value ranges of the variable catalog vs.
+func (rs Relations) NegateWith(vs *variables.Variables) {
 	for _, r := range rs {
-		valueRange := variableCatalog.Variable(r.ID).Range
+		valueRange := vs.Variable(r.ID).Range
 		r.Negate(valueRange)
 	}
 }
 
 // Transform transforms the relations.
 func (rs Relations) Transform() {
+	rs.TransformWith(variables.Get())
+}
+
+// TransformWith transforms the relations using the variable catalog vs.
+func (rs Relations) TransformWith(vs *variables.Variables) {
 	for _, r := range rs {
-		r.Transform()
+		r.TransformWith(vs)
 	}
 }
 
@@ -5,6 +5,7 @@ package relation
 import (
 	"testing"
 
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
 
 	"github.com/stretchr/testify/assert"
@@ -56,7 +57,8 @@ func TestTransformBadValue(t *testing.T) {
 	a := assert.New(t)
 
 	actual := Relation{ID: variables.Zero, Upper: &Limit{Incl: true, Value: "xyz"}, VariableType: variables.Numerical, Score: 1}
-	expected := Relation{ID: variables.Zero, Upper: &Limit{Incl: true, Value: "xyz"}, VariableType: variables.Numerical, Score: 0}
+	expected := Relation{ID: variables.Zero, Upper: &Limit{Incl: true, Value: "xyz"}, VariableType: variables.Numerical, Score: 0,
+		Warnings: []string{`value "xyz" is not a number`}}
 	actual.Transform()
 	a.Equal(expected, actual)
 }
@@ -87,3 +89,235 @@ func TestTransformScientificFormValue(t *testing.T) {
 	actual.Transform()
 	a.Equal(expected, actual)
 }
+
+func TestTransformPlausibility(t *testing.T) {
+	a := assert.New(t)
+
+	// unit creates the unit of the criterion, or a default unit without positions if not given.
+	unit := func(value string, given bool) *Unit {
+		if given {
+			return &Unit{Value: value, Start: []int{0}, End: []int{1}}
+		}
+		return &Unit{Value: value}
+	}
+	tests := []struct {
+		id       variables.ID
+		value    string
+		unit     *Unit
+		expected string
+		score    float64
+		warnings int
+	}{
+		{"405", "100", nil, "k/ul", 1, 1},
+		{"405", "100,000", nil, "cells/ul", 1, 1},
+		{"405", "100", unit("k/ul", true), "k/ul", 1, 0},
+		{"405", "100", unit("cells/ul", true), "cells/ul", 0, 1},
+		{"405", "100", unit("/mm3", true), "/mm3", 0, 1},
+		{"405", "5e15", nil, "", 0, 1},
+		{"415", "133", unit("mg/dl", false), "umol/l", 1, 1},
+		{"415", "1.5", unit("mg/dl", false), "mg/dl", 1, 0},
+		{"415", "1.5", unit("uln", true), "uln", 1, 0},
+		{"403", "9", unit("g/dl", false), "g/dl", 1, 0},
+		{"403", "90", unit("g/dl", false), "g/l", 1, 1},
+		{"403", "9", unit("", false), "", 1, 0},
+		{"403", "6", unit("mmol/l", true), "mmol/l", 1, 0},
+		{"412", "1000", nil, "", 1, 0},
+	}
+	vs := variables.DefaultCatalog()
+	for _, test := range tests {
+		r := &Relation{ID: test.id, Lower: &Limit{Incl: true, Value: test.value}, Unit: test.unit, VariableType: variables.Numerical, Score: 1}
+		r.TransformWith(vs)
+		var actual string
+		if r.Unit != nil {
+			actual = r.Unit.Value
+		}
+		a.Equal(test.expected, actual, test.value)
+		a.Equal(test.score, r.Score, test.value)
+		a.Len(r.Warnings, test.warnings, test.value)
+	}
+}
+
+func TestSetClause(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Relation{
+		Name:  "a",
+		Start: 0, End: 3,
+		Lower: &Limit{Incl: true, Value: "18", Start: []int{4, 7}, End: []int{7, 9}},
+		Unit:  &Unit{Value: "years", Start: []int{10}, End: []int{15}},
+	}
+	rs := Relations{r, r}
+	rs.SetClause(1, 20, 35)
+	a.Equal(1, r.Clause)
+	a.Equal(20, r.ClauseStart)
+	a.Equal(35, r.ClauseEnd)
+	a.Equal(20, r.Start)
+	a.Equal(23, r.End)
+	a.Equal([]int{24, 27}, r.Lower.Start)
+	a.Equal([]int{27, 29}, r.Lower.End)
+	a.Equal([]int{30}, r.Unit.Start)
+	a.Equal([]int{35}, r.Unit.End)
+	a.Contains(r.JSON(), `"clause_index":1,"clause_start":20,"clause_end":35`)
+
+	// Relations of criteria that are not split do not record clauses.
+	r = &Relation{Name: "a", Start: 0, End: 3}
+	a.NotContains(r.JSON(), "clause_")
+}
+
+func TestCountRelation(t *testing.T) {
+	a := assert.New(t)
+
+	r := &Relation{
+		ID:           "852",
+		Name:         "hospitalizations",
+		DisplayName:  "Hospitalizations",
+		Value:        []string{"2"},
+		Upper:        &Limit{Incl: true, Value: "2"},
+		Window:       &Window{Value: "12", Unit: "month", Start: 20, End: 43},
+		VariableType: variables.Count,
+	}
+	a.True(r.Valid())
+	r.Normalize(nil)
+	a.Nil(r.Value)
+	a.Equal("Hospitalizations ≤ 2 in the past 12 month", r.HumanReadable())
+
+	r.SetClause(1, 10, 60)
+	a.Equal(30, r.Window.Start)
+	a.Equal(53, r.Window.End)
+
+	r.Upper = nil
+	a.False(r.Valid())
+}
+
+func TestEvaluate(t *testing.T) {
+	a := assert.New(t)
+
+	vs := variables.New()
+	vs.Add("416", variables.Numerical, "calculated_creatinine_clearance", "", []string{"creatinine clearance"}, nil, "ml/min", "")
+	vs.Variable("416").Formula = formula.CockcroftGault
+	vs.Add("412", variables.Numerical, "alt", "", []string{"alt"}, nil, "", "")
+
+	crcl := func(f formula.Formula) *Relation {
+		return &Relation{
+			ID:           "416",
+			Name:         "calculated_creatinine_clearance",
+			Lower:        &Limit{Incl: true, Value: "60"},
+			Formula:      f,
+			VariableType: variables.Numerical,
+		}
+	}
+	alt := &Relation{
+		ID:           "412",
+		Name:         "alt",
+		Unit:         &Unit{Value: "baseline"},
+		Upper:        &Limit{Incl: false, Value: "3"},
+		VariableType: variables.Numerical,
+	}
+	// Cockcroft-Gault: 43.2 ml/min, CKD-EPI: 40.0 ml/min/1.73 m2.
+	p := formula.NewPatient(formula.Male).Set(formula.Age, 70).Set(formula.Weight, 80).Set(formula.Creatinine, 1.8)
+
+	tests := []struct {
+		relation *Relation
+		patient  *formula.Patient
+		expected bool
+	}{
+		{crcl(formula.Unknown), formula.NewPatient(formula.Male).Set(formula.Age, 70).Set(formula.Weight, 80).Set(formula.Creatinine, 1), true},
+		{crcl(formula.Unknown), p, false},
+		{crcl(formula.CKDEPI), formula.NewPatient(formula.Male).Set(formula.Age, 40).Set(formula.Creatinine, 1), true},
+		{crcl(formula.CKDEPI), p, false},
+		{crcl(formula.Unknown), formula.NewPatient(formula.Male).Set("calculated_creatinine_clearance", 60), true},
+		{alt, formula.NewPatient(formula.Male).Set("alt", 100).SetBaseline("alt", 40), true},
+		{alt, formula.NewPatient(formula.Male).Set("alt", 120).SetBaseline("alt", 40), false},
+	}
+	for i, test := range tests {
+		actual, err := test.relation.Evaluate(test.patient, vs)
+		if a.NoError(err, i) {
+			a.Equal(test.expected, actual, i)
+		}
+	}
+
+	_, err := alt.Evaluate(formula.NewPatient(formula.Male).Set("alt", 100), vs)
+	a.Error(err)
+	_, err = crcl(formula.Unknown).Evaluate(formula.NewPatient(formula.Male).Set(formula.Age, 70), vs)
+	a.Error(err)
+	_, err = alt.Evaluate(formula.NewPatient(formula.Male).SetBaseline("alt", 40), vs)
+	a.Error(err)
+}
+
+func TestEvaluateUnits(t *testing.T) {
+	a := assert.New(t)
+
+	vs := variables.DefaultCatalog()
+	relation := func(id variables.ID, name, unit string, upper string) *Relation {
+		return &Relation{
+			ID:           id,
+			Name:         name,
+			Unit:         &Unit{Value: unit},
+			Upper:        &Limit{Incl: true, Value: upper},
+			VariableType: variables.Numerical,
+		}
+	}
+	alt := relation("412", "alt", "uln", "3")
+	creatinine := relation("415", "creatinine_level", "umol/l", "133")
+	platelets := relation("405", "platelet_count", "cells/l", "100e9")
+
+	tests := []struct {
+		relation *Relation
+		patient  *formula.Patient
+		expected bool
+	}{
+		{alt, formula.NewPatient(formula.Male).Set("alt", 40).SetULN("alt", 40), true},
+		{alt, formula.NewPatient(formula.Male).Set("alt", 130).SetULN("alt", 40), false},
+		{relation("415", "creatinine_level", "mg/dl", "1.5"), formula.NewPatient(formula.Male).Set("creatinine_level", 2), false},
+		{platelets, formula.NewPatient(formula.Male).Set("platelet_count", 150), false},
+		{platelets, formula.NewPatient(formula.Male).Set("platelet_count", 90), true},
+	}
+	for i, test := range tests {
+		actual, err := test.relation.Evaluate(test.patient, vs)
+		if a.NoError(err, i) {
+			a.Equal(test.expected, actual, i)
+		}
+	}
+
+	// The ULN of the patient is missing:
+	_, err := alt.Evaluate(formula.NewPatient(formula.Male).Set("alt", 40), vs)
+	a.Error(err)
+	// Converting mass to molar concentrations depends on the molar mass of creatinine:
+	_, err = creatinine.Evaluate(formula.NewPatient(formula.Male).Set("creatinine_level", 2), vs)
+	a.Error(err)
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	a := assert.New(t)
+
+	vs := variables.DefaultCatalog()
+	r := &Relation{
+		ID:           "212",
+		Name:         "pregnant",
+		Value:        []string{"no"},
+		VariableType: variables.Boolean,
+		Start:        10,
+		End:          18,
+		Condition:    Relations{{ID: "210", Name: "sex", Value: []string{"female"}, VariableType: variables.Nominal, Start: 0, End: 5}},
+	}
+	for _, test := range []struct {
+		patient  *formula.Patient
+		expected bool
+	}{
+		{formula.NewPatient(formula.Male), true},
+		{formula.NewPatient(formula.Female).SetCategory("pregnant", "no"), true},
+		{formula.NewPatient(formula.Female).SetCategory("pregnant", "yes"), false},
+	} {
+		actual, err := r.Evaluate(test.patient, vs)
+		if a.NoError(err) {
+			a.Equal(test.expected, actual)
+		}
+	}
+	_, err := r.Evaluate(formula.NewPatient(formula.Female), vs)
+	a.Error(err)
+
+	r.SetClause(1, 20, 40)
+	a.Equal(30, r.Start)
+	a.Equal(20, r.Condition[0].Start)
+	a.Equal(40, r.Condition[0].ClauseEnd)
+}
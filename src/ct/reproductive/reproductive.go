@@ -0,0 +1,240 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package reproductive
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/eligibility"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+)
+
+// Names of the reproductive status variables.
+const (
+	Sex                   = "sex"
+	ChildbearingPotential = "childbearing_potential"
+	Pregnant              = "pregnant"
+	Lactating             = "lactating"
+	Contraception         = "contraception"
+	ContraceptionDuration = "contraception_duration"
+)
+
+// pattern defines a phrase of a value of a reproductive status variable. Phrases without
+// a variable name are masked only, so that patterns that follow do not match them.
+type pattern struct {
+	name  string
+	value string
+	re    *regexp.Regexp
+}
+
+// patterns are matched in order and each phrase is matched once: negated phrases
+// precede the phrases that they contain, e.g., 'non-pregnant' precedes 'pregnant'.
+var patterns = []pattern{
+	{ChildbearingPotential, "no", regexp.MustCompile(`\b(?:not?|non)[- ]?(?:of\s+)?child[- ]?bearing potential|\bwithout child[- ]?bearing potential|\bpost-?menopausal|\bsurgically steril\w*|\b(?:permanently\s+)?sterili[sz]ed\b`)},
+	{ChildbearingPotential, "yes", regexp.MustCompile(`\bchild[- ]?bearing (?:potential|age)|\breproductive potential|\bwocbp\b|\b(?:able|capable) (?:to|of) (?:become|becoming|get|getting) pregnant|\bpre-?menopausal`)},
+	{Pregnant, "yes", regexp.MustCompile(`\bpositive\s+(?:[\w-]+\s+){0,2}pregnancy tests?`)},
+	{Pregnant, "no", regexp.MustCompile(`\bnegative\s+(?:[\w-]+\s+){0,2}pregnancy tests?|\b(?:not|non-?)\s?pregnant\b`)},
+	{"", "", regexp.MustCompile(`\b(?:[\w-]+\s+)?pregnancy tests?|\b(?:to\s+)?(?:exclude|rule out)\s+pregnancy|\b(?:never|previously|ever)[- ](?:been\s+)?pregnant`)},
+	{"", "", regexp.MustCompile(`\b(?:prevent\w*|avoid\w*|not\s+(?:to\s+)?(?:become|get))\s+(?:a\s+)?(?:becoming\s+)?pregnan(?:t|cy)\b|\bpregnancy[- ](?:induced|related|associated)\b|\b(?:during|after|before|following|throughout)\s+(?:a\s+)?(?:previous\s+|prior\s+)?pregnanc(?:y|ies)\b`)},
+	// Pregnancy is the state of the subject if it is pregnant, the clause is pregnancy, e.g., 'pregnancy or lactation',
+	// or pregnancy is qualified as current, e.g., 'known pregnancy'.
+	{Pregnant, "yes", regexp.MustCompile(`\bpregnant\b|^\s*pregnancy(?:\s*$|\s*[,;:.(/]|\s+(?:or|and)\b)|\b(?:current|known|ongoing|suspected|confirmed)\s+pregnancy\b`)},
+	{Lactating, "no", regexp.MustCompile(`\b(?:not|non-?)\s?(?:lactating|breast[- ]?feeding|nursing)\b`)},
+	{"", "", regexp.MustCompile(`\bnursing (?:homes?|facilit\w*|staff|care)\b`)},
+	{Lactating, "yes", regexp.MustCompile(`\b(?:lactating|lactation|breast[- ]?feeding|nursing)\b`)},
+	{Contraception, "no", regexp.MustCompile(`\b(?:unwilling|not willing|refus\w*|unable|not agree\w*|not using|no)\s+(?:[\w-]+\s+){0,4}?(?:contracepti\w*|birth control)`)},
+	{Contraception, "yes", regexp.MustCompile(`\bcontracepti(?:on|ves?)\b|\bbirth control\b|\bbarrier methods?\b|\bcondoms?\b|\babstinen(?:ce|t)\b`)},
+}
+
+var (
+	reFemale  = regexp.MustCompile(`\b(?:women|woman|females?|girls?)\b`)
+	reMale    = regexp.MustCompile(`\b(?:men|man|males?|boys?)\b`)
+	rePartner = regexp.MustCompile(`\bpartners?\b`)
+	// reQualifier matches the words that qualify the partner, e.g., 'their female' in 'their female partners'.
+	reQualifier = regexp.MustCompile(`(?:\b(?:their|his|her|the|a|any|female|male|woman|women|man|men)\s+)*$`)
+
+	// reDuration matches the duration of contraception after the last dose, e.g., 'for 6 months after the last dose'.
+	reDuration = regexp.MustCompile(`\b(?:for|during|until|through)\s+(?:at\s+least\s+|a\s+minimum\s+of\s+|a\s+period\s+of\s+|up\s+to\s+|at\s+)?` +
+		`(\d+|[a-z]+(?:[- ][a-z]+)?)\s*(?:\(\d+\)\s*)?(days?|weeks?|months?|years?)\s+` +
+		`(?:after|following|post|from)\s+(?:the\s+)?(?:last|final|end of|completion of|discontinuation of|study drug|study treatment)\b`)
+
+	// reRequirement matches the words that make contraception a requirement rather than, e.g., its definition.
+	reRequirement = regexp.MustCompile(`\b(?:must|should|shall|agree\w*|willing|unwilling|required?|requirement)\b`)
+	// rePopulation matches the clauses that only state the population, e.g., 'postmenopausal women'
+	// or 'women aged 18-65 years', after the phrases of the population are masked.
+	rePopulation = regexp.MustCompile(`^[\s,\d≥≤<>=\-]*(?:(?:only|all|adults?|healthy|born|as|a|an|the|are|is|be|must|eligible|patients?|subjects?|participants?|volunteers?|persons?|people|individuals?|who|of|or|and|aged?|ages|years?|old|older|over|between|to|yrs)\b[\s,\d≥≤<>=\-]*)*$`)
+)
+
+// match defines a matched phrase of a variable value.
+type match struct {
+	value      string
+	start, end int
+}
+
+// Extract extracts the reproductive status relations from the lowercase clause of a criterion of
+// the eligibility type: sex, childbearing potential, pregnancy, lactation, contraception, and the
+// duration of contraception after the last dose. The sex and childbearing potential that qualify
+// requirements of pregnancy, lactation, and contraception, e.g., 'women of childbearing potential
+// must use contraception', are the conditions of the requirements. Relations of exclusion criteria
+// are negated except for the duration of contraception, so that all relations are to be satisfied.
+// Only relations of variables in the catalog vs are extracted.
+func Extract(vs *variables.Variables, clause string, t eligibility.Type) relation.Relations {
+	matches := make(map[string]match)
+	masked := []byte(clause)
+	for _, p := range patterns {
+		for _, loc := range p.re.FindAllStringIndex(string(masked), -1) {
+			if _, ok := matches[p.name]; !ok && len(p.name) > 0 {
+				matches[p.name] = match{p.value, loc[0], loc[1]}
+			}
+			for i := loc[0]; i < loc[1]; i++ {
+				masked[i] = ' '
+			}
+		}
+	}
+
+	// The sex and childbearing potential of partners do not apply to the patient.
+	subject := clause
+	if loc := rePartner.FindStringIndex(clause); loc != nil {
+		subject = reQualifier.ReplaceAllString(clause[:loc[0]], "")
+		if m, ok := matches[ChildbearingPotential]; ok && m.start >= loc[0] {
+			delete(matches, ChildbearingPotential)
+		}
+	}
+
+	population := func() relation.Relations {
+		rs := relation.NewRelations()
+		if r := sex(vs, subject); r != nil {
+			rs = append(rs, r)
+		}
+		if m, ok := matches[ChildbearingPotential]; ok {
+			if r := categorical(vs, ChildbearingPotential, m); r != nil {
+				rs = append(rs, r)
+			}
+		}
+		return rs
+	}
+
+	if m, ok := matches[Contraception]; ok && m.value == "yes" && !reRequirement.MatchString(clause) && population().Empty() {
+		delete(matches, Contraception)
+	}
+	requirements := relation.NewRelations()
+	for _, name := range []string{Pregnant, Lactating, Contraception} {
+		if m, ok := matches[name]; ok {
+			if r := categorical(vs, name, m); r != nil {
+				requirements = append(requirements, r)
+			}
+		}
+	}
+	if requirements.Empty() {
+		// The population restricts the patients only if the clause does not qualify it otherwise,
+		// e.g., 'qtc ≥ 470 ms in females' does not restrict the sex.
+		rest := reFemale.ReplaceAllString(reMale.ReplaceAllString(string(masked), ""), "")
+		if !rePopulation.MatchString(rest) {
+			return relation.NewRelations()
+		}
+		rs := population()
+		if t == eligibility.Exclusion {
+			rs.NegateWith(vs)
+		}
+		return rs
+	}
+	if t == eligibility.Exclusion {
+		requirements.NegateWith(vs)
+	}
+	if _, ok := matches[Contraception]; ok {
+		if r := duration(vs, clause); r != nil {
+			requirements = append(requirements, r)
+		}
+	}
+	for _, r := range requirements {
+		if c := population(); !c.Empty() {
+			r.Condition = c
+		}
+	}
+	return requirements
+}
+
+// categorical creates a boolean relation of the matched value of the variable.
+func categorical(vs *variables.Variables, name string, m match) *relation.Relation {
+	v := variable(vs, name)
+	if v == nil {
+		return nil
+	}
+	r := relation.NewCategorical(v, []string{m.value}, 1)
+	r.Start, r.End = m.start, m.end
+	return r
+}
+
+// sex creates a nominal relation of the sex that the subject of the clause mentions.
+func sex(vs *variables.Variables, subject string) *relation.Relation {
+	v := variable(vs, Sex)
+	if v == nil {
+		return nil
+	}
+	var values []string
+	start, end := len(subject), 0
+	for _, s := range []struct {
+		value string
+		re    *regexp.Regexp
+	}{{"female", reFemale}, {"male", reMale}} {
+		if loc := s.re.FindStringIndex(subject); loc != nil {
+			values = append(values, s.value)
+			if loc[0] < start {
+				start = loc[0]
+			}
+			if loc[1] > end {
+				end = loc[1]
+			}
+		}
+	}
+	if len(values) != 1 {
+		// Criteria of both sexes do not restrict the sex.
+		return nil
+	}
+	r := relation.NewCategorical(v, values, 1)
+	r.Start, r.End = start, end
+	return r
+}
+
+// duration creates a numerical relation of the minimum duration of contraception after the last dose.
+func duration(vs *variables.Variables, clause string) *relation.Relation {
+	v := variable(vs, ContraceptionDuration)
+	if v == nil {
+		return nil
+	}
+	loc := reDuration.FindStringSubmatchIndex(clause)
+	if loc == nil {
+		return nil
+	}
+	value := clause[loc[2]:loc[3]]
+	if !text.IsNumber(value) {
+		var ok bool
+		if value, ok = text.ParseNumberWords(value); !ok {
+			return nil
+		}
+	}
+	unit := strings.TrimSuffix(clause[loc[4]:loc[5]], "s")
+	return &relation.Relation{
+		ID:           v.ID,
+		Name:         v.Name,
+		DisplayName:  v.Display,
+		VariableType: v.Kind,
+		Lower:        &relation.Limit{Incl: true, Value: value, Start: []int{loc[2]}, End: []int{loc[3]}},
+		Unit:         &relation.Unit{Value: unit, Start: []int{loc[4]}, End: []int{loc[5]}},
+		Score:        1,
+		Start:        loc[0],
+		End:          loc[1],
+	}
+}
+
+// variable returns the variable of the name in the catalog, or nil if the catalog does not have it.
+func variable(vs *variables.Variables, name string) *variables.Variable {
+	id, ok := vs.ID(name)
+	if !ok {
+		return nil
+	}
+	return vs.Variable(id)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package reproductive
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/eligibility"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// summary summarizes the relation as 'name=values' or 'name>=bound unit', followed by its conditions.
+func summary(r *relation.Relation) string {
+	var s string
+	if r.Lower != nil {
+		s = r.Name + ">=" + r.Lower.Value + " " + r.Unit.Value
+	} else {
+		s = r.Name + "="
+		for i, v := range r.Value {
+			if i > 0 {
+				s += "|"
+			}
+			s += v
+		}
+	}
+	for _, c := range r.Condition {
+		s += " if " + summary(c)
+	}
+	return s
+}
+
+func TestExtract(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		input    string
+		t        eligibility.Type
+		expected []string
+	}{
+		{"women of childbearing potential must use highly effective contraception during the study and for 6 months after the last dose", eligibility.Inclusion, []string{
+			"contraception=yes if sex=female if childbearing_potential=yes",
+			"contraception_duration>=6 month if sex=female if childbearing_potential=yes",
+		}},
+		{"wocbp must have a negative serum pregnancy test within 7 days of the first dose", eligibility.Inclusion, []string{
+			"pregnant=no if childbearing_potential=yes",
+		}},
+		{"pregnant or breastfeeding women", eligibility.Exclusion, []string{
+			"pregnant=no if sex=female",
+			"lactating=no if sex=female",
+		}},
+		{"women who are pregnant, planning to become pregnant, or nursing", eligibility.Exclusion, []string{
+			"pregnant=no if sex=female",
+			"lactating=no if sex=female",
+		}},
+		{"women of childbearing potential unwilling to use adequate contraception for ninety days following the last dose", eligibility.Exclusion, []string{
+			"contraception=yes if sex=female if childbearing_potential=yes",
+			"contraception_duration>=90 day if sex=female if childbearing_potential=yes",
+		}},
+		{"male patients with female partners of childbearing potential must agree to use condoms", eligibility.Inclusion, []string{
+			"contraception=yes if sex=male",
+		}},
+		{"postmenopausal women", eligibility.Inclusion, []string{
+			"sex=female",
+			"childbearing_potential=no",
+		}},
+		{"male subjects", eligibility.Exclusion, []string{
+			"sex=female",
+		}},
+		{"men must be willing to use a double-barrier contraception until at 5 months after the last dose of study drug", eligibility.Inclusion, []string{
+			"contraception=yes if sex=male",
+			"contraception_duration>=5 month if sex=male",
+		}},
+		{"women aged 18-65 years", eligibility.Inclusion, []string{
+			"sex=female",
+		}},
+		{"male or female aged 18 years or older", eligibility.Inclusion, nil},
+		{"qtc interval ≥ 470 ms in females", eligibility.Exclusion, nil},
+		{"a urine pregnancy test to exclude pregnancy will be performed prior to study initiation", eligibility.Inclusion, nil},
+		{"never-pregnant female donors", eligibility.Inclusion, nil},
+		{"periodic abstinence and withdrawal are not acceptable methods of contraception", eligibility.Inclusion, nil},
+		{"residents of nursing homes", eligibility.Exclusion, nil},
+		{"women of childbearing potential must agree to use adequate contraception to prevent pregnancy", eligibility.Inclusion, []string{
+			"contraception=yes if sex=female if childbearing_potential=yes",
+		}},
+		{"women must agree to avoid pregnancy during the study", eligibility.Inclusion, nil},
+		{"history of pregnancy-induced hypertension", eligibility.Exclusion, nil},
+		{"gestational diabetes during pregnancy", eligibility.Exclusion, nil},
+		{"pregnancy or lactation", eligibility.Exclusion, []string{
+			"pregnant=no",
+			"lactating=no",
+		}},
+		{"pregnancy", eligibility.Exclusion, []string{
+			"pregnant=no",
+		}},
+		{"known pregnancy", eligibility.Exclusion, []string{
+			"pregnant=no",
+		}},
+		{"patient is pregnant", eligibility.Exclusion, []string{
+			"pregnant=no",
+		}},
+		{"hemoglobin ≥ 9 g/dl", eligibility.Inclusion, nil},
+	}
+	vs := variables.DefaultCatalog()
+	for _, test := range tests {
+		rs := Extract(vs, test.input, test.t)
+		var actual []string
+		for _, r := range rs {
+			actual = append(actual, summary(r))
+		}
+		a.Equal(test.expected, actual, test.input)
+	}
+}
+
+func TestExtractPositions(t *testing.T) {
+	a := assert.New(t)
+
+	input := "women of childbearing potential must use contraception for 3 months after the last dose"
+	rs := Extract(variables.DefaultCatalog(), input, eligibility.Inclusion)
+	if !a.Len(rs, 2) {
+		return
+	}
+	a.Equal("contraception", input[rs[0].Start:rs[0].End])
+	a.Equal("childbearing potential", input[rs[0].Condition[1].Start:rs[0].Condition[1].End])
+	a.Equal("3", input[rs[1].Lower.Start[0]:rs[1].Lower.End[0]])
+	a.Equal("months", input[rs[1].Unit.Start[0]:rs[1].Unit.End[0]])
+}
+
+func TestExtractWithoutVariables(t *testing.T) {
+	a := assert.New(t)
+
+	a.Empty(Extract(variables.New(), "pregnant or breastfeeding women", eligibility.Exclusion))
+}
+
+func TestEvaluate(t *testing.T) {
+	a := assert.New(t)
+
+	vs := variables.DefaultCatalog()
+	input := "women of childbearing potential must use contraception for 6 months after the last dose"
+	rs := Extract(vs, input, eligibility.Inclusion)
+
+	tests := []struct {
+		patient  *formula.Patient
+		expected bool
+	}{
+		{formula.NewPatient(formula.Male), true},
+		{formula.NewPatient(formula.Female).SetCategory(ChildbearingPotential, "no"), true},
+		{formula.NewPatient(formula.Female).SetCategory(ChildbearingPotential, "yes").SetCategory(Contraception, "no"), false},
+		{formula.NewPatient(formula.Female).SetCategory(ChildbearingPotential, "yes").SetCategory(Contraception, "yes").Set(ContraceptionDuration, 200), true},
+		{formula.NewPatient(formula.Female).SetCategory(ChildbearingPotential, "yes").SetCategory(Contraception, "yes").Set(ContraceptionDuration, 90), false},
+	}
+	for i, test := range tests {
+		actual := true
+		for _, r := range rs {
+			ok, err := r.Evaluate(test.patient, vs)
+			a.NoError(err, i)
+			if actual = ok; !actual {
+				break
+			}
+		}
+		a.Equal(test.expected, actual, i)
+	}
+
+	_, err := rs[0].Evaluate(formula.NewPatient(formula.UnknownSex), vs)
+	a.Error(err)
+}
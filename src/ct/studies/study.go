@@ -5,10 +5,14 @@ import (
 	"strings"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
-	"github.com/facebookresearch/clinical-trial-parser/src/common/util/slice"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/criteria"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/diagnostics"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/eligibility"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/parser"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/reproductive"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
 )
 
 type Study struct {
@@ -19,12 +23,16 @@ type Study struct {
 	InclusionCriteria   criteria.Criteria `json:"-"`
 	ExclusionCriteria   criteria.Criteria `json:"-"`
 	CriteriaCnt         int               `json:"criteria_count"`
+	Strategy            criteria.Strategy `json:"-"` // Section segmentation strategy
 }
 
 type ParsedStudy struct {
-	Id             string                  `json:"study_id,omitempty"`
-	CriteriaCnt    int                     `json:"criteria_count"`
-	ParsedCriteria criteria.ParsedCriteria `json:"parsed_criteria,omitempty"`
+	Id              string                  `json:"study_id,omitempty"`
+	CriteriaCnt     int                     `json:"criteria_count"`
+	SectionStrategy string                  `json:"section_strategy,omitempty"`
+	Cohorts         []string                `json:"cohorts,omitempty"`
+	CatalogVersion  string                  `json:"catalog_version,omitempty"` // version of the variable and unit catalogs
+	ParsedCriteria  criteria.ParsedCriteria `json:"parsed_criteria,omitempty"`
 }
 
 func NewStudy(id, name string, conditions []string, eligibilityCriteria string) *Study {
@@ -59,94 +67,205 @@ func (s *Study) CriteriaCount() int {
 	return s.CriteriaCnt
 }
 
-// Parse parses eligibility criteria text to relations for the study s.
+// SectionStrategy returns the strategy used to segment the eligibility criteria into sections.
+func (s *Study) SectionStrategy() criteria.Strategy {
+	return s.Strategy
+}
+
+// Parse parses eligibility criteria text to relations for the study s
+// using the active package-level catalogs.
 func (s *Study) Parse() *Study {
-	interpreter := parser.Get()
+	return s.ParseWith(parser.DefaultCatalogs())
+}
 
-	inclusions, exclusions := s.Criteria()
+// ParseWith parses eligibility criteria text to relations for the study s using the catalogs c.
+func (s *Study) ParseWith(c *parser.Catalogs) *Study {
+	interpreter := parser.NewCatalogInterpreter(c)
+
+	inclusions, exclusions := s.Items()
 	s.CriteriaCnt = len(inclusions) + len(exclusions)
 
 	// Parse inclusion criteria:
 	inclusionCriteria := criteria.NewCriteria()
-	for index, inclusion := range inclusions {
-		lowercase := strings.ToLower(inclusion)
-		orRelations, andRelations := interpreter.Interpret(lowercase)
-
-		orRelations.Process()
-		andRelations.Process()
-
-		if !andRelations.Empty() {
-			criterion := criteria.NewCriterion(inclusion, andRelations.MinScore(), andRelations, index)
-			inclusionCriteria = append(inclusionCriteria, criterion)
-		} else {
-			criterion := criteria.NewCriterion(inclusion, orRelations.MinScore(), orRelations, index)
-			inclusionCriteria = append(inclusionCriteria, criterion)
-		}
+	for index, item := range inclusions {
+		relations, disjunctions, ds := parseClauses(interpreter, item.Text, eligibility.Inclusion)
+		criterion := criteria.NewCriterion(item.Text, relations.MinScore(), relations, index)
+		criterion.SetItem(item)
+		criterion.Diagnostics = ds
+		criterion.Disjunctions = disjunctions
+		inclusionCriteria = append(inclusionCriteria, criterion)
 	}
 	s.InclusionCriteria = inclusionCriteria
 
 	// Parse exclusion criteria:
 	exclusionCriteria := criteria.NewCriteria()
-	for index, exclusion := range exclusions {
-		lowercase := strings.ToLower(exclusion)
-		orRelations, andRelations := interpreter.Interpret(lowercase)
-		orRelations.Process()
-		andRelations.Process()
-		orRelations.Negate()
-		andRelations.Negate()
-
-		if !orRelations.Empty() {
-			criterion := criteria.NewCriterion(exclusion, orRelations.MinScore(), orRelations, index)
-			exclusionCriteria = append(exclusionCriteria, criterion)
-		} else {
-			criterion := criteria.NewCriterion(exclusion, andRelations.MinScore(), andRelations, index)
-			exclusionCriteria = append(exclusionCriteria, criterion)
-		}
-
+	for index, item := range exclusions {
+		relations, disjunctions, ds := parseClauses(interpreter, item.Text, eligibility.Exclusion)
+		criterion := criteria.NewCriterion(item.Text, relations.MinScore(), relations, index)
+		criterion.SetItem(item)
+		criterion.Diagnostics = ds
+		criterion.Disjunctions = disjunctions
+		exclusionCriteria = append(exclusionCriteria, criterion)
 	}
 
 	s.ExclusionCriteria = exclusionCriteria
-	s.Transform()
+	s.TransformWith(c.Variables)
 
 	return s
 }
 
+// parseClauses splits the criterion into clauses and parses each clause separately.
+// Inclusion clauses are parsed to conjoined relations if possible and exclusion clauses
+// to disjoined relations, which are negated. Reproductive status relations are extracted
+// from each clause and added to the relations. If the criterion is split, each relation records
+// the clause it is parsed from, and its positions refer to the lowercase criterion. The relations of
+// clauses that are alternatives, i.e., disjoined inclusion relations or negated conjoined exclusion
+// relations, are also returned as disjunctions. The diagnostics of the clauses that are not parsed
+// completely are returned with the relations.
+func parseClauses(interpreter *parser.Interpreter, criterion string, t eligibility.Type) (relation.Relations, []relation.Relations, diagnostics.Diagnostics) {
+	vs := interpreter.Catalogs().Variables
+	relations := relation.NewRelations()
+	var disjunctions []relation.Relations
+	var ds diagnostics.Diagnostics
+	clauses := criteria.SplitClauses(criterion)
+	for index, clause := range clauses {
+		lowercase := strings.ToLower(clause.Text)
+		orRelations, andRelations, clauseDs := interpreter.Diagnose(lowercase)
+		clauseDs.SetClause(index)
+		ds = append(ds, clauseDs...)
+		orRelations.ProcessWith(vs)
+		andRelations.ProcessWith(vs)
+
+		var rs relation.Relations
+		disjoined := false
+		if t == eligibility.Exclusion {
+			orRelations.NegateWith(vs)
+			andRelations.NegateWith(vs)
+			rs = orRelations
+			if rs.Empty() {
+				rs = andRelations
+				disjoined = true
+			}
+		} else {
+			rs = andRelations
+			if rs.Empty() {
+				rs = orRelations
+				disjoined = true
+			}
+		}
+		if disjoined && len(rs) > 1 {
+			disjunctions = append(disjunctions, rs)
+		}
+
+		rs = append(rs, reproductive.Extract(vs, lowercase, t)...)
+
+		if len(clauses) > 1 {
+			start := len(strings.ToLower(criterion[:clause.Start]))
+			rs.SetClause(index, start, start+len(lowercase))
+		}
+		relations = append(relations, rs...)
+	}
+	return relations, disjunctions, ds
+}
+
 // Criteria extracts inclusion and exclusion criteria from the eligibility criteria string.
+// The strategy used to segment the criteria into sections is recorded in the study.
 func (s *Study) Criteria() ([]string, []string) {
-	eligibilityCriteria := criteria.Normalize(s.EligibilityCriteria)
-
-	// Parse inclusion criteria:
-	var inclusions []string
-	inclusionList := criteria.ExtractInclusionCriteria(eligibilityCriteria)
-	for _, s := range inclusionList {
-		inclusions = append(inclusions, criteria.Split(s)...)
+	inclusionItems, exclusionItems := s.Items()
+	inclusions := make([]string, len(inclusionItems))
+	for i, item := range inclusionItems {
+		inclusions[i] = item.Text
+	}
+	exclusions := make([]string, len(exclusionItems))
+	for i, item := range exclusionItems {
+		exclusions[i] = item.Text
 	}
+	return inclusions, exclusions
+}
 
-	for i, c := range inclusions {
-		inclusions[i] = criteria.TrimCriterion(c)
+// Items extracts inclusion and exclusion criteria from the eligibility criteria string
+// together with the list headers and 'any/all of the following' groups of the criteria.
+// The strategy used to segment the criteria into sections is recorded in the study.
+func (s *Study) Items() ([]criteria.Item, []criteria.Item) {
+	eligibilityCriteria := criteria.Normalize(s.EligibilityCriteria)
+	sections, strategy := criteria.Segment(eligibilityCriteria)
+	s.Strategy = strategy
+
+	groupID := 1
+	items := func(t eligibility.Type) []criteria.Item {
+		var items []criteria.Item
+		for _, section := range sections {
+			if section.Type != t {
+				continue
+			}
+			var v []criteria.Item
+			v, groupID = criteria.ParseBullets(section.Text).Items(groupID)
+			for _, item := range v {
+				if len(item.Cohort) == 0 {
+					item.Cohort = section.Cohort
+				}
+				if item.Text = criteria.TrimCriterion(item.Text); len(item.Text) > 0 {
+					items = append(items, item)
+				}
+			}
+		}
+		return items
 	}
-	inclusions = slice.RemoveEmpty(inclusions)
 
-	// Parse exclusion criteria:
-	var exclusions []string
-	exclusionList := criteria.ExtractExclusionCriteria(eligibilityCriteria)
-	for _, s := range exclusionList {
-		exclusions = append(exclusions, criteria.Split(s)...)
+	return items(eligibility.Inclusion), items(eligibility.Exclusion)
+}
+
+// Cohorts returns the sorted cohorts or arms that the parsed criteria are restricted to.
+// A study without cohort-specific criteria has no cohorts.
+func (s *Study) Cohorts() []string {
+	cohorts := set.New()
+	for _, cs := range []criteria.Criteria{s.InclusionCriteria, s.ExclusionCriteria} {
+		for _, c := range cs {
+			if len(c.Cohort) > 0 {
+				cohorts.Add(c.Cohort)
+			}
+		}
 	}
+	return cohorts.Slice()
+}
 
-	for i, c := range exclusions {
-		exclusions[i] = criteria.TrimCriterion(c)
+// Eligible tests whether the patient is eligible for the cohort of the study: the patient meets the
+// inclusion criteria and is not excluded by the exclusion criteria that apply to the cohort, which
+// are evaluated with the variable catalog vs; see criteria.Criteria.Evaluate. An empty cohort selects
+// the criteria that apply to all cohorts. An error is returned if the eligibility cannot be decided,
+// e.g., a value of the patient that a criterion depends on is missing.
+func (s *Study) Eligible(p *formula.Patient, cohort string, vs *variables.Variables) (bool, error) {
+	inclusions, exclusions := s.CohortCriteria(cohort)
+	included, err1 := inclusions.Evaluate(p, vs, eligibility.Inclusion)
+	notExcluded, err2 := exclusions.Evaluate(p, vs, eligibility.Exclusion)
+	switch {
+	case err1 == nil && !included, err2 == nil && !notExcluded:
+		return false, nil
+	case err1 != nil:
+		return false, err1
+	case err2 != nil:
+		return false, err2
 	}
-	exclusions = slice.RemoveEmpty(exclusions)
+	return true, nil
+}
 
-	return inclusions, exclusions
+// CohortCriteria returns the inclusion and exclusion criteria that determine the eligibility
+// of a participant enrolling into the cohort: the criteria of the cohort and the criteria
+// that apply to all cohorts.
+func (s *Study) CohortCriteria(cohort string) (criteria.Criteria, criteria.Criteria) {
+	return s.InclusionCriteria.Cohort(cohort), s.ExclusionCriteria.Cohort(cohort)
 }
 
 // Transform transforms criteria relations by converting parsed values to strings of valid literals.
 // If a valid literal cannot be inferred, the confidence score of the relation is set to zero.
 func (s *Study) Transform() {
-	s.InclusionCriteria.Relations().Transform()
-	s.ExclusionCriteria.Relations().Transform()
+	s.TransformWith(variables.Get())
+}
+
+// TransformWith transforms criteria relations like Transform using the variable catalog vs.
+func (s *Study) TransformWith(vs *variables.Variables) {
+	s.InclusionCriteria.Relations().TransformWith(vs)
+	s.ExclusionCriteria.Relations().TransformWith(vs)
 }
 
 // Relations returns the string representation of the parsed criteria.
@@ -162,9 +281,11 @@ func (s *Study) Relations() criteria.ParsedCriteria {
 		log.Printf("========test%+v", c)
 		if len(relationR) > 0 {
 			p := criteria.NewParsedCriterion("inclusion", "", c.ClusterID, c.String(), "", relationR)
+			p.SetCriterion(c)
 			pc = append(pc, p)
 		} else {
 			p := criteria.NewParsedCriterion("inclusion", "", c.ClusterID, c.String(), "", relation.Relations{})
+			p.SetCriterion(c)
 			pc = append(pc, p)
 		}
 		cid++
@@ -173,9 +294,11 @@ func (s *Study) Relations() criteria.ParsedCriteria {
 		relationR := c.Relations()
 		if len(relationR) > 0 {
 			p := criteria.NewParsedCriterion("exclusion", "", c.ClusterID, c.String(), "", relationR)
+			p.SetCriterion(c)
 			pc = append(pc, p)
 		} else {
 			p := criteria.NewParsedCriterion("exclusion", "", c.ClusterID, c.String(), "", relation.Relations{})
+			p.SetCriterion(c)
 			pc = append(pc, p)
 		}
 		cid++
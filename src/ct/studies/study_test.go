@@ -5,7 +5,9 @@ package studies
 import (
 	"testing"
 
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
 	"github.com/facebookresearch/clinical-trial-parser/src/ct/relation"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/variables"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -162,3 +164,71 @@ func TestOrdinalNegationCriteriaParse(t *testing.T) {
 	actualExclusions.SetScore(0)
 	a.Equal(expectedExclusions, actualExclusions)
 }
+
+func TestEligible(t *testing.T) {
+	a := assert.New(t)
+
+	input := `Inclusion Criteria:
+
+            - Aged 18 years or older.
+            - ECOG performance status 0-1 (Cohort A only)
+            - ECOG performance status 0-2 (Cohort B only)
+            - Weight ≥ 50 kg or BMI ≥ 18 kg/m2
+            - Any of the following:
+                - HbA1c > 6.5%
+                - BMI ≥ 30 kg/m2
+
+            Exclusion Criteria:
+
+            - Platelet count < 100,000/mm3`
+
+	study := NewStudy("ID012345", "Better Health for Everybody", nil, input)
+	study.Parse()
+	a.Equal([]string{"cohort a", "cohort b"}, study.Cohorts())
+	a.Len(study.InclusionCriteria[3].Disjunctions, 1)
+
+	// patient creates a patient who meets the criteria of cohort A with the value of the variable changed.
+	patient := func(name string, val float64) *formula.Patient {
+		p := formula.NewPatient(formula.Male).Set("age", 50).Set("weight", 80).Set("bmi", 25).Set("a1c", 7).Set("platelet_count", 150)
+		p.SetCategory("ecog", "1")
+		if len(name) > 0 {
+			p.Set(name, val)
+		}
+		return p
+	}
+	ecog2 := patient("", 0).SetCategory("ecog", "2")
+
+	vs := variables.Get()
+	tests := []struct {
+		patient  *formula.Patient
+		cohort   string
+		expected bool
+	}{
+		{patient("", 0), "cohort a", true},
+		{patient("", 0), "cohort b", true},
+		{ecog2, "cohort a", false},
+		{ecog2, "cohort b", true},
+		{ecog2, "", true},
+		{patient("age", 17), "cohort a", false},
+		{patient("weight", 45), "cohort a", true},
+		{patient("a1c", 6), "cohort a", false},
+		{patient("a1c", 6).Set("bmi", 31), "cohort a", true},
+		{patient("platelet_count", 90), "cohort a", false},
+	}
+	for i, test := range tests {
+		actual, err := study.Eligible(test.patient, test.cohort, vs)
+		if a.NoError(err, i) {
+			a.Equal(test.expected, actual, i)
+		}
+	}
+
+	// The age of the patient is missing:
+	p := patient("", 0)
+	delete(p.Values, "age")
+	_, err := study.Eligible(p, "cohort a", vs)
+	a.Error(err)
+	// The patient is excluded regardless of the age:
+	eligible, err := study.Eligible(p.Set("platelet_count", 90), "cohort a", vs)
+	a.NoError(err)
+	a.False(eligible)
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
+	"sync/atomic"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/param"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/trie"
@@ -16,24 +18,29 @@ import (
 	"github.com/golang/glog"
 )
 
-var catalog *Units
+// catalog holds the active *Units. It is swapped atomically so that
+// readers always see a complete catalog while a new one is activated.
+var catalog atomic.Value
 
 func init() {
-	catalog = DefaultCatalog()
+	catalog.Store(DefaultCatalog())
 }
 
+// Set activates the catalog.
 func Set(d *Units) {
-	catalog = d
+	catalog.Store(d)
 }
 
+// Get returns the active catalog.
 func Get() *Units {
-	return catalog
+	return catalog.Load().(*Units)
 }
 
 type Units struct {
 	ids        map[string]ID // map from unit name to unit id.
 	units      map[ID]*Unit
 	variables  map[string]string
+	aliases    map[ID][]string
 	dictionary *trie.Trie
 }
 
@@ -42,6 +49,7 @@ func New() *Units {
 		ids:        make(map[string]ID),
 		units:      make(map[ID]*Unit),
 		variables:  make(map[string]string),
+		aliases:    make(map[ID][]string),
 		dictionary: trie.New(),
 	}
 }
@@ -55,6 +63,21 @@ func (us *Units) Unit(id ID) *Unit {
 	return us.units[id]
 }
 
+// All returns the units sorted by id.
+func (us *Units) All() []*Unit {
+	v := make([]*Unit, 0, len(us.units))
+	for _, u := range us.units {
+		v = append(v, u)
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].ID < v[j].ID })
+	return v
+}
+
+// Aliases returns the aliases of the unit id.
+func (us *Units) Aliases(id ID) []string {
+	return us.aliases[id]
+}
+
 //ID returns the ID of the variable name.
 func (us *Units) ID(name string) (ID, bool) {
 	id, ok := us.ids[name]
@@ -89,6 +112,7 @@ func (us *Units) Add(id ID, name string, display string, aliases []string, vname
 	u := NewUnit(id, name, display, vname)
 	us.ids[name] = id
 	us.units[id] = u
+	us.aliases[id] = aliases
 	if len(vname) > 0 {
 		us.variables[name] = vname
 	}
@@ -163,7 +187,7 @@ func DefaultCatalog() *Units {
 	catalog.Add("304", "week", "week", aliases, "")
 
 	aliases = []string{"month"}
-	catalog.Add("305", "month", "month*", aliases, "")
+	catalog.Add("305", "month", "month", aliases, "")
 
 	aliases = []string{"year*"}
 	catalog.Add("306", "year", "year", aliases, "")
@@ -183,15 +207,30 @@ func DefaultCatalog() *Units {
 	aliases = []string{"ng/ml"}
 	catalog.Add("405", "ng/ml", "ng/ml", aliases, "")
 
+	aliases = []string{"g/l"}
+	catalog.Add("406", "g/l", "g/l", aliases, "")
+
 	aliases = []string{"mg/dl"}
 	catalog.Add("407", "mg/dl", "mg/dl", aliases, "")
 
+	aliases = []string{"k/ul"}
+	catalog.Add("409", "k/ul", "k/ul", aliases, "")
+
 	aliases = []string{"cells/ul", "/ul", "mm3"}
 	catalog.Add("410", "cells/ul", "cells/ul", aliases, "")
 
+	aliases = []string{"umol/l"}
+	catalog.Add("412", "umol/l", "umol/l", aliases, "")
+
+	aliases = []string{"mmol/l"}
+	catalog.Add("413", "mmol/l", "mmol/l", aliases, "")
+
 	aliases = []string{"ml/min/1"}
 	catalog.Add("414", "mL/min/1.73_m2", "mL/min/1.73 m2", aliases, "")
 
+	aliases = []string{"mg/mg", "g/g"}
+	catalog.Add("418", "mg/mg", "mg/mg", aliases, "upcr")
+
 	aliases = []string{"cells/l", "/l"}
 	catalog.Add("416", "cells/l", "cells/L", aliases, "")
 
@@ -213,6 +252,9 @@ func DefaultCatalog() *Units {
 	aliases = []string{"lln", "lower limit of normal", "lower limits of normal"}
 	catalog.Add("604", "lln", "lln", aliases, "")
 
+	aliases = []string{"baseline", "baseline value", "baseline level"}
+	catalog.Add("606", "baseline", "baseline", aliases, "")
+
 	aliases = []string{"years old", "year old"}
 	catalog.Add("199", "years old", "years old", aliases, "")
 
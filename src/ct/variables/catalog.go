@@ -0,0 +1,212 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package variables
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
+
+	"github.com/golang/glog"
+	"golang.org/x/text/language"
+)
+
+// SchemaVersion is the version of the JSON variable catalog format.
+// The legacy CSV catalog is version 1.
+const SchemaVersion = 2
+
+// Catalog defines the JSON variable catalog.
+type Catalog struct {
+	SchemaVersion int     `json:"schema_version"`
+	Version       string  `json:"version,omitempty"` // version of the catalog content
+	Variables     []Entry `json:"variables"`
+}
+
+// Entry defines a variable of the JSON catalog.
+type Entry struct {
+	ID          string       `json:"id"`
+	Type        string       `json:"type"`
+	Name        string       `json:"name"`
+	Display     string       `json:"display,omitempty"`
+	Synonyms    []Synonym    `json:"synonyms,omitempty"`
+	Values      []ValueLabel `json:"values,omitempty"` // value range of nominal and ordinal variables
+	Range       []float64    `json:"range,omitempty"`  // value range [min, max] of numerical variables
+	DefaultUnit string       `json:"default_unit,omitempty"`
+	Units       []UnitRange  `json:"units,omitempty"`
+	Codes       []Code       `json:"codes,omitempty"`
+	Formula     string       `json:"formula,omitempty"`   // formula of derived numerical variables, e.g., 'ckd-epi'
+	Extractor   string       `json:"extractor,omitempty"` // extractor that sets the variable, e.g., 'reproductive'
+	Question    string       `json:"question,omitempty"`
+	Version     string       `json:"version,omitempty"`
+	Deprecated  bool         `json:"deprecated,omitempty"`
+	ReplacedBy  string       `json:"replaced_by,omitempty"`
+}
+
+// ValueLabel defines a nominal or ordinal value and its label.
+type ValueLabel struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+}
+
+// LoadCatalog loads variables from a JSON catalog file.
+func LoadCatalog(fname string) (*Variables, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	variables, err := ReadCatalog(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	glog.Infof("Number of variables loaded: %d (catalog version: %q)\n", variables.Size(), variables.Version)
+
+	return variables, nil
+}
+
+// ReadCatalog reads variables from a JSON catalog.
+func ReadCatalog(r io.Reader) (*Variables, error) {
+	var c Catalog
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version: %d, expected %d", c.SchemaVersion, SchemaVersion)
+	}
+
+	variables := New()
+	variables.Version = c.Version
+	for _, e := range c.Variables {
+		v, err := e.Variable()
+		if err != nil {
+			return nil, err
+		}
+		aliases := make([]string, 0, len(e.Synonyms))
+		for _, s := range e.Synonyms {
+			aliases = append(aliases, s.Text)
+		}
+		if err := variables.AddVariable(v, aliases, e.Question); err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range variables.variables {
+		if len(v.ReplacedBy) == 0 {
+			continue
+		}
+		if _, ok := variables.variables[v.ReplacedBy]; !ok {
+			return nil, fmt.Errorf("variable %s (%s): unknown replacement variable: %s", v.ID, v.Name, v.ReplacedBy)
+		}
+	}
+	return variables, nil
+}
+
+// Variable validates the entry and converts it to a variable.
+func (e Entry) Variable() (*Variable, error) {
+	fail := func(format string, a ...interface{}) (*Variable, error) {
+		return nil, fmt.Errorf("variable %s (%s): %s", e.ID, e.Name, fmt.Sprintf(format, a...))
+	}
+
+	if len(e.ID) == 0 || len(e.Name) == 0 {
+		return fail("missing id or name")
+	}
+	kind := ParseType(e.Type)
+	if kind == Unknown {
+		return fail("unknown variable type: %q", e.Type)
+	}
+
+	v := NewVariable(ID(e.ID), kind, e.Name, e.Display, nil, nil, e.DefaultUnit)
+	v.Version = e.Version
+	v.Extractor = e.Extractor
+	v.Deprecated = e.Deprecated
+	v.ReplacedBy = ID(e.ReplacedBy)
+	if len(v.ReplacedBy) > 0 && !v.Deprecated {
+		return fail("replaced variable is not deprecated")
+	}
+
+	for _, s := range e.Synonyms {
+		if len(s.Text) == 0 {
+			return fail("empty synonym")
+		}
+		if len(s.Lang) > 0 {
+			if _, err := language.Parse(s.Lang); err != nil {
+				return fail("synonym %q: bad language tag: %q", s.Text, s.Lang)
+			}
+		}
+	}
+	v.Synonyms = e.Synonyms
+
+	switch kind {
+	case Nominal, Ordinal:
+		if len(e.Range) > 0 || len(e.Units) > 0 {
+			return fail("range and units apply to numerical variables only")
+		}
+		for _, val := range e.Values {
+			if len(val.Value) == 0 {
+				return fail("empty value")
+			}
+			if _, ok := v.ValueLabels[val.Value]; ok {
+				return fail("duplicate value: %q", val.Value)
+			}
+			if kind == Ordinal {
+				if _, err := strconv.Atoi(val.Value); err != nil {
+					return fail("ordinal value is not an integer: %q", val.Value)
+				}
+			}
+			if v.ValueLabels == nil {
+				v.ValueLabels = make(map[string]string)
+			}
+			v.ValueLabels[val.Value] = val.Label
+			v.Range = append(v.Range, val.Value)
+		}
+	case Numerical, Count:
+		if len(e.Values) > 0 {
+			return fail("values apply to nominal and ordinal variables only")
+		}
+		switch len(e.Range) {
+		case 0:
+		case 2:
+			if e.Range[0] > e.Range[1] {
+				return fail("bad range: %v", e.Range)
+			}
+			v.NumRange = e.Range
+		default:
+			return fail("range must have a min and a max: %v", e.Range)
+		}
+		if err := ValidateUnitRanges(e.Units); err != nil {
+			return fail("%v", err)
+		}
+		v.Units = e.Units
+		if len(e.DefaultUnit) > 0 && !v.AllowsUnit(e.DefaultUnit) {
+			return fail("default unit is not allowed: %q", e.DefaultUnit)
+		}
+	default:
+		if len(e.Values) > 0 || len(e.Range) > 0 || len(e.Units) > 0 {
+			return fail("values, range, and units do not apply to %s variables", kind)
+		}
+	}
+
+	for _, c := range e.Codes {
+		if err := c.Validate(); err != nil {
+			return fail("%v", err)
+		}
+	}
+	v.Codes = e.Codes
+
+	if len(e.Formula) > 0 {
+		if kind != Numerical {
+			return fail("formulas apply to numerical variables only")
+		}
+		if v.Formula = formula.ParseFormula(e.Formula); v.Formula == formula.Unknown {
+			return fail("unknown formula: %q", e.Formula)
+		}
+	}
+
+	return v, nil
+}
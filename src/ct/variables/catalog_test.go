@@ -0,0 +1,219 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package variables
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	a := assert.New(t)
+
+	vs, err := Load("../../resources/variables/variables.example.json")
+	a.NoError(err)
+	a.Equal("2021.1", vs.Version)
+	a.Equal(7, vs.Size())
+
+	ecog := vs.Variable("100")
+	a.Equal([]string{"0", "1", "2", "3", "4"}, ecog.Range)
+	a.Equal("Fully active", ecog.ValueLabel("0"))
+	a.Equal("5", ecog.ValueLabel("5"))
+	code, ok := ecog.Code(LOINC)
+	a.True(ok)
+	a.Equal("89247-1", code.Code)
+	a.Equal("What is your ECOG performance status?", vs.Question("100"))
+
+	age := vs.Variable("200")
+	a.Equal([]float64{0, 120}, age.NumRange)
+	code, ok = age.Code(SNOMEDCT)
+	a.True(ok)
+	a.Equal("397669002", code.Code)
+	a.True(age.AllowsUnit("month"))
+	a.False(age.AllowsUnit("kg"))
+	a.True(age.Plausible(60, "month"))
+	a.False(age.Plausible(150, "year"))
+
+	name, ok := vs.Get("estado funcional ecog")
+	a.True(ok)
+	a.Equal("ecog", name)
+
+	hospitalizations := vs.Variable("852")
+	a.Equal(Count, hospitalizations.Kind)
+	a.Equal([]float64{0, 365}, hospitalizations.NumRange)
+	concept, ok := hospitalizations.Concept()
+	a.True(ok)
+	a.Equal(Code{System: MeSH, Code: "D006760", Display: "Hospitalization"}, concept)
+	_, ok = age.Concept()
+	a.False(ok)
+
+	bmi := vs.Variable("203")
+	a.Equal(formula.BMI, bmi.Formula)
+	a.True(bmi.Derived())
+	a.False(age.Derived())
+
+	deprecated := vs.Variable("1405")
+	a.True(deprecated.Deprecated)
+	a.Equal(ID("405"), deprecated.ReplacedBy)
+	a.False(vs.Match("thrombocytes"))
+}
+
+func TestReadCatalogValidation(t *testing.T) {
+	a := assert.New(t)
+
+	tests := map[string]string{
+		`{"schema_version": 1, "variables": []}`:                                                                                               "schema version",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numeric", "name": "a"}]}`:                                                    "unknown variable type",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "ordinal", "name": "a", "values": [{"value": "x"}]}]}`:                        "not an integer",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "ordinal", "name": "a", "values": [{"value": "1"}, {"value": "1"}]}]}`:        "duplicate value",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a", "range": [2, 1]}]}`:                                 "bad range",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a", "default_unit": "kg", "units": [{"unit": "g"}]}]}`:  "default unit",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a", "units": [{"unit": "g", "min": 2, "max": 1}]}]}`:    "plausible range",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a", "codes": [{"system": "LOINC", "code": "123"}]}]}`:   "invalid LOINC code",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a", "codes": [{"system": "ICD", "code": "123"}]}]}`:     "unknown code system",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a", "synonyms": [{"text": "a", "lang": "english!"}]}]}`: "language tag",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a", "deprecated": true, "replaced_by": "2"}]}`:          "unknown replacement",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a"}, {"id": "1", "type": "numerical", "name": "b"}]}`:   "duplicate variable id",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a", "unit": "kg"}]}`:                                    "unknown field",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "count", "name": "a", "range": [2, 1]}]}`:                                     "bad range",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "count", "name": "a", "codes": [{"system": "MeSH", "code": "E02"}]}]}`:        "invalid MeSH code",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "numerical", "name": "a", "formula": "apache"}]}`:                             "unknown formula",
+		`{"schema_version": 2, "variables": [{"id": "1", "type": "ordinal", "name": "a", "formula": "bmi"}]}`:                                  "numerical variables only",
+	}
+	for input, expected := range tests {
+		_, err := ReadCatalog(strings.NewReader(input))
+		if a.Error(err, input) {
+			a.Contains(err.Error(), expected, input)
+		}
+	}
+}
+
+func TestParseCodeSystem(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(LOINC, ParseCodeSystem("loinc"))
+	a.Equal(SNOMEDCT, ParseCodeSystem("SNOMED CT"))
+	a.Equal(SNOMEDCT, ParseCodeSystem("snomed-ct"))
+	a.Equal(UMLS, ParseCodeSystem("UMLS CUI"))
+	a.Equal(MeSH, ParseCodeSystem("mesh"))
+	a.Equal(UnknownSystem, ParseCodeSystem("ICD-10"))
+}
+
+func TestParseCodes(t *testing.T) {
+	a := assert.New(t)
+
+	codes, err := ParseCodes("MeSH:D006760|UMLS: C0019993")
+	a.NoError(err)
+	a.Equal([]Code{{System: MeSH, Code: "D006760"}, {System: UMLS, Code: "C0019993"}}, codes)
+
+	codes, err = ParseCodes("")
+	a.NoError(err)
+	a.Empty(codes)
+
+	for _, input := range []string{"D006760", "ICD:A01", "MeSH:E02.760"} {
+		_, err := ParseCodes(input)
+		a.Error(err, input)
+	}
+}
+
+func TestLoadCountVariables(t *testing.T) {
+	a := assert.New(t)
+
+	vs, err := Load("../../resources/variables/variables.csv")
+	a.NoError(err)
+	id, ok := vs.ID("hospitalizations")
+	if a.True(ok) {
+		v := vs.Variable(id)
+		a.Equal(Count, v.Kind)
+		a.True(v.InRange(3))
+		a.False(v.InRange(-1))
+		concept, ok := v.Concept()
+		a.True(ok)
+		a.Equal("D006760", concept.Code)
+	}
+	name, ok := vs.Get("prior systemic regimens")
+	a.True(ok)
+	a.Equal("prior_therapy_lines", name)
+}
+
+func TestLoadDerivedVariables(t *testing.T) {
+	a := assert.New(t)
+
+	vs, err := Load("../../resources/variables/variables.csv")
+	a.NoError(err)
+	tests := map[string]formula.Formula{
+		"bmi":                             formula.BMI,
+		"calculated_creatinine_clearance": formula.CockcroftGault,
+		"glomerular_filtration_rate":      formula.CKDEPI,
+		"upcr":                            formula.UPCR,
+		"pf_ratio":                        formula.PFRatio,
+		"weight":                          formula.Unknown,
+	}
+	for name, expected := range tests {
+		id, ok := vs.ID(name)
+		if a.True(ok, name) {
+			a.Equal(expected, vs.Variable(id).Formula, name)
+		}
+	}
+	name, ok := vs.Get("urine protein/creatinine ratio")
+	a.True(ok)
+	a.Equal("upcr", name)
+}
+
+func TestParseUnitRanges(t *testing.T) {
+	a := assert.New(t)
+
+	units, err := ParseUnitRanges("k/ul:1:2000| cells/l:1e9: |g/dl")
+	if a.NoError(err) && a.Len(units, 3) {
+		a.Equal("k/ul", units[0].Unit)
+		a.Equal(1.0, *units[0].Min)
+		a.Equal(2000.0, *units[0].Max)
+		a.Equal(1e9, *units[1].Min)
+		a.Nil(units[1].Max)
+		a.Equal(UnitRange{Unit: "g/dl"}, units[2])
+	}
+	units, err = ParseUnitRanges("")
+	a.NoError(err)
+	a.Empty(units)
+
+	for _, input := range []string{"k/ul:1", "k/ul:a:2000", "k/ul:2000:1", "k/ul:1:2|k/ul:3:4", ":1:2"} {
+		_, err := ParseUnitRanges(input)
+		a.Error(err, input)
+	}
+}
+
+func TestLoadUnitRanges(t *testing.T) {
+	a := assert.New(t)
+
+	vs, err := Load("../../resources/variables/variables.csv")
+	a.NoError(err)
+	id, ok := vs.ID("platelet_count")
+	if !a.True(ok) {
+		return
+	}
+	v := vs.Variable(id)
+	a.Equal([]string{"k/ul"}, v.PlausibleUnits(100))
+	a.Equal([]string{"cells/ul"}, v.PlausibleUnits(50000, 100000))
+	a.Empty(v.PlausibleUnits(100, 100000))
+	a.True(v.Plausible(1.5e11, "cells/l"))
+	a.False(v.Plausible(100, "cells/ul"))
+}
+
+func TestLoadExtractorVariables(t *testing.T) {
+	a := assert.New(t)
+
+	vs, err := Load("../../resources/variables/variables.csv")
+	a.NoError(err)
+	for _, name := range []string{"sex", "childbearing_potential", "pregnant", "lactating", "contraception", "contraception_duration"} {
+		id, ok := vs.ID(name)
+		if a.True(ok, name) {
+			a.Equal("reproductive", vs.Variable(id).Extractor, name)
+		}
+	}
+	id, _ := vs.ID("bmi")
+	a.Empty(vs.Variable(id).Extractor)
+}
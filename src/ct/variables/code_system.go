@@ -0,0 +1,107 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package variables
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/param"
+)
+
+// CodeSystem defines an external vocabulary of variable codes.
+type CodeSystem string
+
+const (
+	// UnknownSystem is an unsupported vocabulary.
+	UnknownSystem CodeSystem = ""
+	// LOINC codes laboratory and clinical observations, e.g., '26515-7'.
+	LOINC CodeSystem = "LOINC"
+	// SNOMEDCT codes clinical concepts by SCTIDs, e.g., '423740007'.
+	SNOMEDCT CodeSystem = "SNOMEDCT"
+	// UMLS codes concepts by CUIs, e.g., 'C0032181'.
+	UMLS CodeSystem = "UMLS"
+	// MeSH codes concepts by descriptor UIs, e.g., 'D006760', which identify
+	// the concepts in the MeSH taxonomy.
+	MeSH CodeSystem = "MeSH"
+)
+
+var codeFormats = map[CodeSystem]*regexp.Regexp{
+	LOINC:    regexp.MustCompile(`^(?:\d{1,7}-\d|LP\d{3,}-\d|LA\d{3,}-\d)$`),
+	SNOMEDCT: regexp.MustCompile(`^[1-9]\d{5,17}$`),
+	UMLS:     regexp.MustCompile(`^C\d{7}$`),
+	MeSH:     regexp.MustCompile(`^D\d{6}(?:\d{3})?$`),
+}
+
+// ParseCodeSystem converts the string to the code system. Case, spaces,
+// hyphens, and underscores are ignored, e.g., 'SNOMED CT' is SNOMEDCT.
+func ParseCodeSystem(s string) CodeSystem {
+	s = strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(s))
+	switch s {
+	case "LOINC":
+		return LOINC
+	case "SNOMEDCT", "SNOMED", "SCT":
+		return SNOMEDCT
+	case "UMLS", "CUI", "UMLSCUI":
+		return UMLS
+	case "MESH", "MSH":
+		return MeSH
+	default:
+		return UnknownSystem
+	}
+}
+
+// String returns the string representation of the code system.
+func (s CodeSystem) String() string {
+	return string(s)
+}
+
+// UnmarshalJSON converts the json string to the code system.
+func (s *CodeSystem) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if *s = ParseCodeSystem(v); *s == UnknownSystem {
+		return fmt.Errorf("unknown code system: %q", v)
+	}
+	return nil
+}
+
+// ParseCodes converts the codes of the legacy CSV catalog to codes. Codes are separated
+// by '|' and have the form 'system:code', e.g., 'MeSH:D006760|UMLS:C0019993'.
+func ParseCodes(s string) ([]Code, error) {
+	var codes []Code
+	for _, a := range strings.Split(s, param.FieldSep) {
+		if a = strings.TrimSpace(a); len(a) == 0 {
+			continue
+		}
+		k := strings.IndexByte(a, ':')
+		if k < 0 {
+			return nil, fmt.Errorf("bad code: %q, expected 'system:code'", a)
+		}
+		c := Code{System: ParseCodeSystem(a[:k]), Code: strings.TrimSpace(a[k+1:])}
+		if c.System == UnknownSystem {
+			return nil, fmt.Errorf("unknown code system: %q", a[:k])
+		}
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+		codes = append(codes, c)
+	}
+	return codes, nil
+}
+
+// Validate checks that the code has the format of its code system.
+func (c Code) Validate() error {
+	format, ok := codeFormats[c.System]
+	if !ok {
+		return fmt.Errorf("unknown code system: %q", c.System)
+	}
+	if !format.MatchString(c.Code) {
+		return fmt.Errorf("invalid %s code: %q", c.System, c.Code)
+	}
+	return nil
+}
@@ -2,15 +2,54 @@
 
 package variables
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/param"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
+)
+
 // Variable defines the variable schema with the relevant fields.
 type Variable struct {
-	ID       ID        // variable id
-	Kind     Type      // variable type
-	Name     string    // variable name
-	Display  string    // variable display name
-	Range    []string  // value range for nominal and ordinal variables
-	NumRange []float64 // value range for numerical variables
-	UnitName string    // variable default unit
+	ID          ID                // variable id
+	Kind        Type              // variable type
+	Name        string            // variable name
+	Display     string            // variable display name
+	Range       []string          // value range for nominal and ordinal variables
+	NumRange    []float64         // value range for numerical variables
+	UnitName    string            // variable default unit
+	Codes       []Code            // codes of the variable in external vocabularies
+	Formula     formula.Formula   // formula that computes a derived variable from other variables
+	Units       []UnitRange       // allowed units and their plausible ranges, any unit is allowed if empty
+	Extractor   string            // extractor that sets the variable instead of alias matching, e.g., 'reproductive'
+	Synonyms    []Synonym         // language-tagged synonyms
+	ValueLabels map[string]string // labels of nominal and ordinal values, e.g., ECOG '0': 'Fully active'
+	Version     string            // version of the variable definition
+	Deprecated  bool              // true if the variable should no longer be matched
+	ReplacedBy  ID                // id of the variable that replaces a deprecated variable
+}
+
+// Code defines a code of a variable in an external vocabulary.
+type Code struct {
+	System  CodeSystem `json:"system"`
+	Code    string     `json:"code"`
+	Display string     `json:"display,omitempty"`
+}
+
+// UnitRange defines an allowed unit of a variable and the plausible range of values in the unit.
+// A nil bound is unbounded.
+type UnitRange struct {
+	Unit string   `json:"unit"`
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+}
+
+// Synonym defines a synonym of a variable and its BCP 47 language tag, e.g., 'en' or 'es'.
+type Synonym struct {
+	Text string `json:"text"`
+	Lang string `json:"lang,omitempty"`
 }
 
 // New creates a new variable.
@@ -20,8 +59,158 @@ func NewVariable(id ID, kind Type, name, display string, bounds []string, numBou
 
 // InRange return true if val is in the valid range or the range is not specified.
 func (v *Variable) InRange(val float64) bool {
-	if len(v.NumRange) == 0 || v.Kind != Numerical {
+	if len(v.NumRange) == 0 || (v.Kind != Numerical && v.Kind != Count) {
 		return true
 	}
 	return v.NumRange[0] <= val && val <= v.NumRange[1]
 }
+
+// Code returns the code of the variable in the vocabulary system.
+func (v *Variable) Code(system CodeSystem) (Code, bool) {
+	for _, c := range v.Codes {
+		if c.System == system {
+			return c, true
+		}
+	}
+	return Code{}, false
+}
+
+// Concept returns the MeSH code of the concept that a count variable counts. The code
+// is the descriptor UI of the concept in the MeSH taxonomy.
+func (v *Variable) Concept() (Code, bool) {
+	if v.Kind != Count {
+		return Code{}, false
+	}
+	return v.Code(MeSH)
+}
+
+// Derived returns true if the variable is computed from other variables by a formula,
+// e.g., the body mass index from the weight and height.
+func (v *Variable) Derived() bool {
+	return v.Formula != formula.Unknown
+}
+
+// AllowsUnit returns true if the unit is allowed for the variable or the allowed units are not specified.
+func (v *Variable) AllowsUnit(unit string) bool {
+	if len(v.Units) == 0 {
+		return true
+	}
+	_, ok := v.UnitRange(unit)
+	return ok
+}
+
+// UnitRange returns the allowed unit and its plausible range.
+func (v *Variable) UnitRange(unit string) (UnitRange, bool) {
+	for _, u := range v.Units {
+		if u.Unit == unit {
+			return u, true
+		}
+	}
+	return UnitRange{}, false
+}
+
+// Plausible returns true if val in the unit is in the plausible range of the unit.
+// Values in units without a range are plausible.
+func (v *Variable) Plausible(val float64, unit string) bool {
+	u, ok := v.UnitRange(unit)
+	if !ok {
+		return true
+	}
+	return u.Contains(val)
+}
+
+// PlausibleUnits returns the allowed units, in the order of the catalog, in which all values
+// are plausible. The magnitude of values whose unit is not known implies these units.
+func (v *Variable) PlausibleUnits(vals ...float64) []string {
+	var units []string
+	for _, u := range v.Units {
+		ok := true
+		for _, val := range vals {
+			if !u.Contains(val) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			units = append(units, u.Unit)
+		}
+	}
+	return units
+}
+
+// ValueLabel returns the label of the nominal or ordinal value, or the value if it has no label.
+func (v *Variable) ValueLabel(val string) string {
+	if label, ok := v.ValueLabels[val]; ok {
+		return label
+	}
+	return val
+}
+
+// Contains returns true if val is in the range.
+func (u UnitRange) Contains(val float64) bool {
+	if u.Min != nil && val < *u.Min {
+		return false
+	}
+	if u.Max != nil && val > *u.Max {
+		return false
+	}
+	return true
+}
+
+// ParseUnitRanges converts the units of the legacy CSV catalog to unit ranges. Units are separated
+// by '|' and have the form 'unit:min:max', e.g., 'k/ul:1:2000|cells/ul:1000:2000000'.
+// An empty min or max is unbounded, and a unit without bounds allows any value.
+func ParseUnitRanges(s string) ([]UnitRange, error) {
+	var units []UnitRange
+	for _, a := range strings.Split(s, param.FieldSep) {
+		if a = strings.TrimSpace(a); len(a) == 0 {
+			continue
+		}
+		fields := strings.Split(a, ":")
+		if len(fields) != 1 && len(fields) != 3 {
+			return nil, fmt.Errorf("bad unit range: %q, expected 'unit:min:max'", a)
+		}
+		u := UnitRange{Unit: strings.TrimSpace(fields[0])}
+		if len(fields) == 3 {
+			var err error
+			if u.Min, err = parseBound(fields[1]); err != nil {
+				return nil, fmt.Errorf("bad unit range: %q: %v", a, err)
+			}
+			if u.Max, err = parseBound(fields[2]); err != nil {
+				return nil, fmt.Errorf("bad unit range: %q: %v", a, err)
+			}
+		}
+		units = append(units, u)
+	}
+	return units, ValidateUnitRanges(units)
+}
+
+// parseBound converts a bound of a unit range to a float, or nil if the bound is empty.
+func parseBound(s string) (*float64, error) {
+	if s = strings.TrimSpace(s); len(s) == 0 {
+		return nil, nil
+	}
+	val, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &val, nil
+}
+
+// ValidateUnitRanges checks that the units are not empty or duplicate and that their ranges are in order.
+func ValidateUnitRanges(units []UnitRange) error {
+	seen := make(map[string]bool)
+	for _, u := range units {
+		if len(u.Unit) == 0 {
+			return fmt.Errorf("empty unit")
+		}
+		if seen[u.Unit] {
+			return fmt.Errorf("duplicate unit: %q", u.Unit)
+		}
+		seen[u.Unit] = true
+		if u.Min != nil && u.Max != nil && *u.Min > *u.Max {
+			return fmt.Errorf("bad plausible range of unit %q: [%g, %g]", u.Unit, *u.Min, *u.Max)
+		}
+	}
+	return nil
+}
@@ -20,13 +20,16 @@ const (
 	Ordinal Type = "ordinal"
 	// Numerical (interval) type of variable
 	Numerical Type = "numerical"
+	// Count type of variable, which counts occurrences of a nominal concept,
+	// e.g., prior lines of therapy
+	Count Type = "count"
 )
 
 // ParseType converts the string to the variable type.
 func ParseType(s string) Type {
 	s = strings.ToLower(strings.TrimSpace(s))
 	switch s {
-	case "boolean", "nominal", "ordinal", "numerical":
+	case "boolean", "nominal", "ordinal", "numerical", "count":
 		return Type(s)
 	default:
 		return Unknown
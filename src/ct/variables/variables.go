@@ -7,28 +7,36 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/param"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/trie"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+	"github.com/facebookresearch/clinical-trial-parser/src/ct/formula"
 
 	"github.com/golang/glog"
 )
 
-var catalog *Variables
+// catalog holds the active *Variables. It is swapped atomically so that
+// readers always see a complete catalog while a new one is activated.
+var catalog atomic.Value
 
 func init() {
-	catalog = DefaultCatalog()
+	catalog.Store(DefaultCatalog())
 }
 
+// Set activates the catalog.
 func Set(d *Variables) {
-	catalog = d
+	catalog.Store(d)
 }
 
+// Get returns the active catalog.
 func Get() *Variables {
-	return catalog
+	return catalog.Load().(*Variables)
 }
 
 // Variables defines a collection of variables.
@@ -37,7 +45,9 @@ type Variables struct {
 	variables  map[ID]*Variable
 	units      map[ID]string // default unit names
 	questions  map[ID]string
+	aliases    map[ID][]string
 	dictionary *trie.Trie
+	Version    string // catalog version, empty for CSV catalogs
 }
 
 func New() *Variables {
@@ -46,6 +56,7 @@ func New() *Variables {
 		variables:  make(map[ID]*Variable),
 		units:      make(map[ID]string),
 		questions:  make(map[ID]string),
+		aliases:    make(map[ID][]string),
 		dictionary: trie.New(),
 	}
 }
@@ -65,6 +76,21 @@ func (vs *Variables) Variable(id ID) *Variable {
 	return vs.variables[id]
 }
 
+// All returns the variables sorted by id.
+func (vs *Variables) All() []*Variable {
+	v := make([]*Variable, 0, len(vs.variables))
+	for _, variable := range vs.variables {
+		v = append(v, variable)
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].ID < v[j].ID })
+	return v
+}
+
+// Aliases returns the aliases of the variable id.
+func (vs *Variables) Aliases(id ID) []string {
+	return vs.aliases[id]
+}
+
 // Question returns the question associated with the variable id.
 func (vs *Variables) Question(id ID) string {
 	return vs.questions[id]
@@ -91,7 +117,7 @@ func (vs *Variables) Add(id ID, kind Type, name string, display string, aliases
 		return fmt.Errorf("duplicate variable name: %s (id: %s)", name, id)
 	}
 	var numBounds []float64
-	if kind == Numerical && len(bounds) == 2 {
+	if (kind == Numerical || kind == Count) && len(bounds) == 2 {
 		if low, err := strconv.ParseFloat(bounds[0], 64); err == nil {
 			numBounds = append(numBounds, low)
 		} else {
@@ -105,19 +131,40 @@ func (vs *Variables) Add(id ID, kind Type, name string, display string, aliases
 		bounds = nil
 	}
 	v := NewVariable(id, kind, name, display, bounds, numBounds, unitName)
-	vs.ids[name] = id
-	vs.variables[id] = v
-	vs.units[id] = unitName
-	vs.questions[id] = question
+	return vs.AddVariable(v, aliases, question)
+}
+
+// AddVariable adds the variable, its aliases, and its question to the catalog.
+// Aliases of deprecated variables are not matched.
+func (vs *Variables) AddVariable(v *Variable, aliases []string, question string) error {
+	if _, ok := vs.variables[v.ID]; ok {
+		return fmt.Errorf("duplicate variable id: %s (name: %s)", v.ID, v.Name)
+	}
+	if _, ok := vs.ids[v.Name]; ok {
+		return fmt.Errorf("duplicate variable name: %s (id: %s)", v.Name, v.ID)
+	}
+	vs.ids[v.Name] = v.ID
+	vs.variables[v.ID] = v
+	vs.units[v.ID] = v.UnitName
+	vs.questions[v.ID] = question
+	vs.aliases[v.ID] = aliases
+	if v.Deprecated {
+		return nil
+	}
 	for _, a := range aliases {
 		vals := text.CustomizeSlash(a)
-		vs.dictionary.Put(name, vals...)
+		vs.dictionary.Put(v.Name, vals...)
 	}
 	return nil
 }
 
-// Load loads variables from a file.​
+// Load loads variables from a file. Files with the '.json' extension are
+// JSON catalogs, other files are legacy CSV catalogs.
 func Load(fname string) (*Variables, error) {
+	if strings.EqualFold(filepath.Ext(fname), ".json") {
+		return LoadCatalog(fname)
+	}
+
 	f, err := os.Open(fname)
 	if err != nil {
 		return nil, err
@@ -127,6 +174,7 @@ func Load(fname string) (*Variables, error) {
 	variables := New()
 	r := csv.NewReader(f)
 	r.Comment = rune(param.Comment)
+	r.FieldsPerRecord = -1
 
 	for {
 		line, err := r.Read()
@@ -150,6 +198,40 @@ func Load(fname string) (*Variables, error) {
 		if err := variables.Add(id, kind, name, display, aliases, bounds, defaultUnit, question); err != nil {
 			return nil, fmt.Errorf("%s: %v", fname, err)
 		}
+		if len(line) > 8 {
+			codes, err := ParseCodes(line[8])
+			if err != nil {
+				return nil, fmt.Errorf("%s: variable %s (%s): %v", fname, id, name, err)
+			}
+			variables.variables[id].Codes = codes
+		}
+		if len(line) > 9 && len(line[9]) > 0 {
+			f := formula.ParseFormula(line[9])
+			if f == formula.Unknown {
+				return nil, fmt.Errorf("%s: variable %s (%s): unknown formula: %q", fname, id, name, line[9])
+			}
+			if kind != Numerical {
+				return nil, fmt.Errorf("%s: variable %s (%s): formulas apply to numerical variables only", fname, id, name)
+			}
+			variables.variables[id].Formula = f
+		}
+		if len(line) > 10 && len(line[10]) > 0 {
+			if kind != Numerical && kind != Count {
+				return nil, fmt.Errorf("%s: variable %s (%s): units apply to numerical and count variables only", fname, id, name)
+			}
+			units, err := ParseUnitRanges(line[10])
+			if err != nil {
+				return nil, fmt.Errorf("%s: variable %s (%s): %v", fname, id, name, err)
+			}
+			v := variables.variables[id]
+			v.Units = units
+			if len(defaultUnit) > 0 && !v.AllowsUnit(defaultUnit) {
+				return nil, fmt.Errorf("%s: variable %s (%s): default unit is not allowed: %q", fname, id, name, defaultUnit)
+			}
+		}
+		if len(line) > 11 {
+			variables.variables[id].Extractor = strings.TrimSpace(line[11])
+		}
 	}
 	glog.Infof("Number of variables loaded: %d\n", variables.Size())
 
@@ -182,33 +264,50 @@ func DefaultCatalog() *Variables {
 
 	aliases = []string{"bmi", "body mass index"}
 	catalog.Add("203", Numerical, "bmi", "", aliases, nil, "", "")
+	catalog.Variable("203").Formula = formula.BMI
 
 	aliases = []string{"life expectancy"}
 	catalog.Add("206", Numerical, "life_expectancy", "", aliases, nil, "", "")
 
+	// Reproductive status variables are extracted by the reproductive package, not matched by aliases.
+	aliases = []string{}
+	catalog.Add("210", Nominal, "sex", "", aliases, []string{"female", "male"}, "", "")
+	catalog.Add("211", Boolean, "childbearing_potential", "", aliases, []string{"yes", "no"}, "", "")
+	catalog.Add("212", Boolean, "pregnant", "", aliases, []string{"yes", "no"}, "", "")
+	catalog.Add("213", Boolean, "lactating", "", aliases, []string{"yes", "no"}, "", "")
+	catalog.Add("214", Boolean, "contraception", "", aliases, []string{"yes", "no"}, "", "")
+	catalog.Add("215", Numerical, "contraception_duration", "", aliases, nil, "day", "")
+	for _, id := range []ID{"210", "211", "212", "213", "214", "215"} {
+		catalog.Variable(id).Extractor = "reproductive"
+	}
+
 	aliases = []string{"systolic blood pressure", "systolic", "sbp"}
 	catalog.Add("300", Numerical, "sbp", "", aliases, nil, "", "")
 
 	aliases = []string{"diastolic blood pressure", "diastolic", "dbp"}
 	catalog.Add("301", Numerical, "dbp", "", aliases, nil, "", "")
 
-	aliases = []string{"SBP/DBP", "blood pressure", "bp"}
+	aliases = []string{"sbp/dbp", "blood pressure", "bp"}
 	catalog.Add("302", Numerical, "sbp/dbp", "", aliases, nil, "", "")
 
 	aliases = []string{"a1c", "hba1c", "hgba1c", "hemoglobin a1c"}
 	catalog.Add("400", Numerical, "a1c", "", aliases, nil, "", "")
 
 	aliases = []string{"hemoglobin count", "hb count"}
-	catalog.Add("403", Numerical, "hb_count", "", aliases, nil, "", "")
+	catalog.Add("403", Numerical, "hb_count", "", aliases, nil, "g/dl", "")
+	catalog.Variable("403").Units = unitRanges("g/dl:3:25|g/l:30:250|mmol/l:1.8:16")
 
 	aliases = []string{"wbc", "white blood cell count", "white blood cell", "leukocytes", "leucocytes"}
 	catalog.Add("404", Numerical, "wbc", "", aliases, nil, "", "")
+	catalog.Variable("404").Units = unitRanges("k/ul:0.5:200|cells/ul:500:200000|cells/l:5e8:2e11")
 
 	aliases = []string{"platelet count", "platelet"}
 	catalog.Add("405", Numerical, "platelet_count", "", aliases, nil, "", "")
+	catalog.Variable("405").Units = unitRanges("k/ul:1:2000|cells/ul:1000:2000000|cells/l:1e9:2e12")
 
 	aliases = []string{"absolute neutrophil count"}
 	catalog.Add("408", Numerical, "anc", "", aliases, nil, "", "")
+	catalog.Variable("408").Units = unitRanges("k/ul:0.1:50|cells/ul:100:100000|cells/l:1e8:1e11")
 
 	aliases = []string{"aspartate aminotransferase", "ast", "sgot"}
 	catalog.Add("411", Numerical, "ast", "", aliases, nil, "", "")
@@ -222,6 +321,22 @@ func DefaultCatalog() *Variables {
 	aliases = []string{"ast/alt ratio", "sgot/sgpt ratio"}
 	catalog.Add("414", Numerical, "ast/alt_ratio", "", aliases, nil, "", "")
 
+	aliases = []string{"creatinine level", "creatinine", "serum creatinine"}
+	catalog.Add("415", Numerical, "creatinine_level", "", aliases, nil, "mg/dl", "")
+	catalog.Variable("415").Units = unitRanges("mg/dl:0.1:15|umol/l:20:1800")
+
+	aliases = []string{"calculated creatinine clearance", "creatinine clearance", "crcl"}
+	catalog.Add("416", Numerical, "calculated_creatinine_clearance", "", aliases, nil, "ml/min", "")
+	catalog.Variable("416").Formula = formula.CockcroftGault
+
+	aliases = []string{"estimated glomerular filtration rate", "glomerular filtration rate", "gfr"}
+	catalog.Add("418", Numerical, "glomerular_filtration_rate", "", aliases, nil, "mL/min/1.73_m2", "")
+	catalog.Variable("418").Formula = formula.CKDEPI
+
+	aliases = []string{"upcr", "urine protein/creatinine ratio", "urine protein to creatinine ratio"}
+	catalog.Add("424", Numerical, "upcr", "", aliases, nil, "mg/mg", "")
+	catalog.Variable("424").Formula = formula.UPCR
+
 	aliases = []string{"plasma total cholesterol", "total cholesterol", "serum cholesterol", "cholesterol"}
 	catalog.Add("500", Numerical, "total_cholesterol", "", aliases, nil, "", "")
 
@@ -239,6 +354,24 @@ func DefaultCatalog() *Variables {
 
 	aliases = []string{"p/f ratio", "pao2/fio2", "pao2/fio2 ratio"}
 	catalog.Add("904", Numerical, "pf_ratio", "", aliases, nil, "mmhg", "")
+	catalog.Variable("904").Formula = formula.PFRatio
+
+	aliases = []string{"prior lines of therapy", "lines of therapy", "prior regimens", "prior systemic regimens", "prior systemic therapies"}
+	catalog.Add("850", Count, "prior_therapy_lines", "", aliases, nil, "", "")
+	catalog.Variable("850").Codes = []Code{{System: MeSH, Code: "D004358"}}
+
+	aliases = []string{"hospitalizations", "hospitalization", "hospital admissions"}
+	catalog.Add("852", Count, "hospitalizations", "", aliases, nil, "", "")
+	catalog.Variable("852").Codes = []Code{{System: MeSH, Code: "D006760"}}
 
 	return catalog
 }
+
+// unitRanges converts the units of the default catalog to unit ranges.
+func unitRanges(s string) []UnitRange {
+	units, err := ParseUnitRanges(s)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	return units
+}
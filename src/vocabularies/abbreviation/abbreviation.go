@@ -0,0 +1,133 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+// Package abbreviation detects and expands abbreviations (short forms)
+// in eligibility criteria.
+package abbreviation
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/param"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
+
+	"github.com/golang/glog"
+)
+
+var reWord = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}'-]*`)
+
+// Dictionary maps lowercase short forms to long forms.
+type Dictionary map[string]string
+
+// NewDictionary creates a new abbreviation dictionary.
+func NewDictionary() Dictionary {
+	return make(Dictionary)
+}
+
+// Load loads abbreviation dictionaries from tab-separated files with
+// the short form in the first column and the long form in the second column.
+// Lines beginning with '#' are comments.
+func Load(fnames ...string) Dictionary {
+	d := NewDictionary()
+	for _, fname := range fnames {
+		file, err := os.Open(fname)
+		if err != nil {
+			glog.Fatal(err)
+		}
+
+		scanner := bufio.NewScanner(file)
+		lineCnt := 0
+		for scanner.Scan() {
+			lineCnt++
+			line := strings.TrimSpace(scanner.Text())
+			if len(line) == 0 || line[0] == param.Comment {
+				continue
+			}
+			values := strings.Split(line, "\t")
+			if len(values) != 2 {
+				glog.Fatalf("%s: Expected two columns: line %d: '%s'\n", fname, lineCnt, line)
+			}
+			d.Add(values[0], values[1])
+		}
+		if err := scanner.Err(); err != nil {
+			glog.Fatal(err)
+		}
+		file.Close()
+		glog.Infof("%s: %d, abbreviations: %d\n", fname, lineCnt, d.Size())
+	}
+	return d
+}
+
+// Add adds the short form and its long form to the dictionary.
+func (d Dictionary) Add(shortForm, longForm string) {
+	shortForm = strings.ToLower(strings.TrimSpace(shortForm))
+	longForm = text.NormalizeWhitespace(strings.ToLower(strings.TrimSpace(longForm)))
+	if len(shortForm) > 0 && len(longForm) > 0 {
+		d[shortForm] = longForm
+	}
+}
+
+// Get returns the long form of the short form.
+func (d Dictionary) Get(shortForm string) (string, bool) {
+	longForm, ok := d[strings.ToLower(shortForm)]
+	return longForm, ok
+}
+
+// Size returns the number of abbreviations in the dictionary.
+func (d Dictionary) Size() int {
+	return len(d)
+}
+
+// Merge returns a new dictionary that contains the abbreviations of d and e.
+// The abbreviations of e override those of d.
+func (d Dictionary) Merge(e Dictionary) Dictionary {
+	m := make(Dictionary, len(d)+len(e))
+	for k, v := range d {
+		m[k] = v
+	}
+	for k, v := range e {
+		m[k] = v
+	}
+	return m
+}
+
+// ShortForms returns the sorted short forms of the dictionary.
+func (d Dictionary) ShortForms() []string {
+	list := make([]string, 0, len(d))
+	for k := range d {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Expand replaces the short forms in s with their long forms. Short forms
+// are matched case-insensitively and only as whole words. A short form
+// is not expanded if its long form already precedes it in s, such as in
+// "non-small cell lung cancer nsclc".
+func (d Dictionary) Expand(s string) string {
+	if len(d) == 0 {
+		return s
+	}
+	locs := reWord.FindAllStringIndex(s, -1)
+	if len(locs) == 0 {
+		return s
+	}
+	var b strings.Builder
+	prev := 0
+	for _, loc := range locs {
+		word := s[loc[0]:loc[1]]
+		longForm, ok := d.Get(word)
+		if !ok || strings.HasSuffix(strings.ToLower(strings.TrimRight(s[:loc[0]], " ([")), longForm) {
+			continue
+		}
+		b.WriteString(s[prev:loc[0]])
+		b.WriteString(longForm)
+		prev = loc[1]
+	}
+	b.WriteString(s[prev:])
+	return b.String()
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package abbreviation
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLongFormFirst(t *testing.T) {
+	a := assert.New(t)
+
+	input := "Patients with histologically confirmed non-small cell lung cancer (NSCLC) are eligible."
+	expected := Dictionary{"nsclc": "non-small cell lung cancer"}
+	actual := Detect(input)
+	a.Equal(expected, actual)
+
+	input = "Eastern Cooperative Oncology Group (ECOG) performance status 0-1; chronic lymphocytic leukemia (CLL, any stage)"
+	expected = Dictionary{"ecog": "eastern cooperative oncology group", "cll": "chronic lymphocytic leukemia"}
+	actual = Detect(input)
+	a.Equal(expected, actual)
+}
+
+func TestDetectShortFormFirst(t *testing.T) {
+	a := assert.New(t)
+
+	input := "History of HCC (hepatocellular carcinoma)"
+	expected := Dictionary{"hcc": "hepatocellular carcinoma"}
+	actual := Detect(input)
+	a.Equal(expected, actual)
+}
+
+func TestDetectNoAbbreviation(t *testing.T) {
+	a := assert.New(t)
+
+	inputs := []string{
+		"autoimmune disease (e.g., rheumatoid arthritis)",
+		"weight > 50 kg (110 lb)",
+		"prior surgery (within 4 weeks)",
+		"diabetes (type 2)",
+		"oral contraceptives (progestogen-only hormonal contraception)",
+	}
+	for _, input := range inputs {
+		a.Empty(Detect(input), input)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	a := assert.New(t)
+
+	d := Dictionary{"nsclc": "non-small cell lung cancer", "mri": "magnetic resonance imaging"}
+	a.Equal("stage iv non-small cell lung cancer", d.Expand("stage iv nsclc"))
+	a.Equal("brain magnetic resonance imaging", d.Expand("brain MRI"))
+	a.Equal("non-small cell lung cancer (nsclc)", d.Expand("non-small cell lung cancer (nsclc)"))
+	a.Equal("nsclc-like disease", d.Expand("nsclc-like disease"))
+	a.Equal("cancer", NewDictionary().Expand("cancer"))
+}
+
+func TestMerge(t *testing.T) {
+	a := assert.New(t)
+
+	d := Dictionary{"mm": "multiple myeloma", "cll": "chronic lymphocytic leukemia"}
+	e := Dictionary{"mm": "malignant melanoma"}
+	m := d.Merge(e)
+	a.Equal("malignant melanoma", m["mm"])
+	a.Equal("multiple myeloma", d["mm"])
+	a.Equal([]string{"cll", "mm"}, m.ShortForms())
+}
+
+func TestLoad(t *testing.T) {
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "abbreviation")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fname := path.Join(dir, "abbreviations.tsv")
+	data := "# short form\tlong form\nNSCLC\tNon-Small Cell Lung Cancer\n\ncns\tcentral  nervous system\n"
+	a.NoError(ioutil.WriteFile(fname, []byte(data), 0644))
+
+	expected := Dictionary{"nsclc": "non-small cell lung cancer", "cns": "central nervous system"}
+	actual := Load(fname)
+	a.Equal(expected, actual)
+}
+
+func TestDetectNestedParentheses(t *testing.T) {
+	a := assert.New(t)
+
+	input := "left ventricular ejection fraction ((LVEF)) < 50%"
+	expected := Dictionary{"lvef": "left ventricular ejection fraction"}
+	actual := Detect(input)
+	a.Equal(expected, actual)
+
+	input = "glutamic oxaloacetic transaminase (GOT), glutamic pyruvic transaminase (GPT) < 2.5 x ULN"
+	expected = Dictionary{"got": "glutamic oxaloacetic transaminase", "gpt": "glutamic pyruvic transaminase"}
+	actual = Detect(input)
+	a.Equal(expected, actual)
+}
+
+func TestDictionaryFile(t *testing.T) {
+	a := assert.New(t)
+
+	d := Load("../../../data/mesh/abbreviations.tsv")
+	tests := map[string]string{
+		"chronic hbv infection":     "chronic hepatitis b infection",
+		"untreated nsclc":           "untreated non-small cell lung cancer",
+		"lesion > 10 mm":            "lesion > 10 mm",
+		"ct scan of the chest":      "ct scan of the chest",
+		"pe within 6 months":        "pe within 6 months",
+		"hepatitis b (hbv) carrier": "hepatitis b (hbv) carrier",
+	}
+	for input, expected := range tests {
+		a.Equal(expected, d.Expand(input), input)
+	}
+}
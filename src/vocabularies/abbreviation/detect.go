@@ -0,0 +1,164 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package abbreviation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+)
+
+var (
+	reParentheses = regexp.MustCompile(`\(([^()]*)\)`)
+	reClauseSep   = regexp.MustCompile(`[.;:!?\n]\s`)
+
+	// nonAbbreviations are parenthesized expressions that look like short forms but are not.
+	nonAbbreviations = set.New("e.g", "eg", "i.e", "ie", "etc", "vs", "ref", "see", "or", "and", "n", "yes", "no")
+)
+
+// Detect finds abbreviations that are defined locally in the text using
+// the algorithm of Schwartz and Hearst (2003). A definition is either of the form
+// "long form (short form)", e.g., "non-small cell lung cancer (NSCLC)",
+// or "short form (long form)", e.g., "NSCLC (non-small cell lung cancer)".
+// The latter form requires the short form to contain an uppercase letter.
+func Detect(s string) Dictionary {
+	d := NewDictionary()
+	for _, loc := range reParentheses.FindAllStringSubmatchIndex(s, -1) {
+		inner := s[loc[2]:loc[3]]
+		if i := strings.IndexAny(inner, ",;"); i >= 0 {
+			inner = inner[:i]
+		}
+		inner = strings.TrimSpace(inner)
+		before := clause(strings.TrimRight(s[:loc[0]], " ("))
+
+		if isShortForm(inner) {
+			// long form (short form)
+			if longForm := bestLongForm(inner, lastWords(before, maxWords(inner))); len(longForm) > 0 {
+				d.Add(inner, longForm)
+			}
+			continue
+		}
+
+		// short form (long form)
+		words := strings.Fields(before)
+		if len(words) == 0 {
+			continue
+		}
+		shortForm := strings.Trim(words[len(words)-1], `"'`)
+		if !isShortForm(shortForm) || !hasUpper(shortForm) || len(strings.Fields(inner)) > maxWords(shortForm) {
+			continue
+		}
+		// The long form must span the whole parenthesized text.
+		if longForm := bestLongForm(shortForm, inner); len(longForm) > 0 && strings.EqualFold(longForm, inner) {
+			d.Add(shortForm, longForm)
+		}
+	}
+	return d
+}
+
+// isShortForm tests whether s is a short form candidate: it has at most two words,
+// is 2-10 characters long, begins with a letter or digit, and contains a letter.
+func isShortForm(s string) bool {
+	n := len([]rune(s))
+	if n < 2 || n > 10 || len(strings.Fields(s)) > 2 {
+		return false
+	}
+	if nonAbbreviations.Contains(strings.Trim(strings.ToLower(s), ".")) {
+		return false
+	}
+	r := []rune(s)
+	if !isAlnum(r[0]) {
+		return false
+	}
+	for _, c := range r {
+		if unicode.IsLetter(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxWords returns the maximum number of words in the long form of the short form.
+func maxWords(shortForm string) int {
+	n := len([]rune(shortForm))
+	if n+5 < 2*n {
+		return n + 5
+	}
+	return 2 * n
+}
+
+// clause returns the last clause of s that does not cross a parenthesis.
+func clause(s string) string {
+	if i := strings.LastIndexAny(s, "()[]"); i >= 0 {
+		s = s[i+1:]
+	}
+	locs := reClauseSep.FindAllStringIndex(s, -1)
+	if len(locs) == 0 {
+		return s
+	}
+	return s[locs[len(locs)-1][1]:]
+}
+
+// hasUpper tests whether s contains an uppercase letter.
+func hasUpper(s string) bool {
+	for _, c := range s {
+		if unicode.IsUpper(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastWords returns the last n words of s.
+func lastWords(s string, n int) string {
+	words := strings.Fields(s)
+	if len(words) > n {
+		words = words[len(words)-n:]
+	}
+	return strings.Join(words, " ")
+}
+
+// bestLongForm finds the shortest suffix of the long form candidate whose
+// characters contain the characters of the short form in order, such that
+// the first character of the short form begins a word. An empty string is
+// returned if no such suffix exists.
+func bestLongForm(shortForm, longForm string) string {
+	sf := []rune(strings.ToLower(shortForm))
+	lf := []rune(strings.ToLower(longForm))
+	i := len(sf) - 1
+	j := len(lf) - 1
+	for ; i >= 0; i-- {
+		c := sf[i]
+		if !isAlnum(c) {
+			continue
+		}
+		for j >= 0 && (lf[j] != c || (i == 0 && j > 0 && isAlnum(lf[j-1]))) {
+			j--
+		}
+		if j < 0 {
+			return ""
+		}
+		j--
+	}
+	start := j + 1
+	for start > 0 && !unicode.IsSpace(lf[start-1]) {
+		start--
+	}
+	candidate := strings.TrimSpace(string(lf[start:]))
+	candidate = strings.Trim(candidate, `"'`)
+	if len([]rune(candidate)) <= len(sf) || strings.EqualFold(candidate, shortForm) {
+		return ""
+	}
+	for _, w := range strings.Fields(candidate) {
+		if strings.EqualFold(w, shortForm) {
+			return ""
+		}
+	}
+	return candidate
+}
+
+func isAlnum(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
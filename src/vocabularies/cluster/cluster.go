@@ -0,0 +1,251 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+// Package cluster groups similar terms that could not be linked to
+// vocabulary concepts so that they can be reviewed and promoted into
+// a custom vocabulary.
+package cluster
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/lsh"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/slice"
+)
+
+// Cluster defines a group of similar terms.
+type Cluster struct {
+	ID          int
+	Concept     string   // Concept name, initially the most frequent term
+	TreeNumbers []string // Tree numbers of the concept, if known
+	Count       int      // Total frequency of the terms
+	Terms       []string // Terms sorted by frequency in reverse order
+	Labels      []string // NER labels of the terms
+	Examples    []string // Example criteria the terms were extracted from
+}
+
+// Clusters defines a slice of clusters.
+type Clusters []*Cluster
+
+// Sort sorts clusters by count in reverse order. Ties are broken by concept name.
+// Cluster IDs are reassigned to follow the order.
+func (cs Clusters) Sort() {
+	sort.SliceStable(cs, func(i, j int) bool {
+		if cs[i].Count != cs[j].Count {
+			return cs[i].Count > cs[j].Count
+		}
+		return cs[i].Concept < cs[j].Concept
+	})
+	for i, c := range cs {
+		c.ID = i + 1
+	}
+}
+
+// Len returns the number of clusters.
+func (cs Clusters) Len() int {
+	return len(cs)
+}
+
+// entry defines a unique term and its occurrences.
+type entry struct {
+	term     string
+	count    int
+	labels   set.Set
+	examples []string
+	shingles set.Set
+}
+
+// Clusterer collects terms and groups them into clusters. Candidate pairs of
+// similar terms are found with MinHash buckets, and the candidates are merged
+// agglomeratively in the order of decreasing similarity as long as the average
+// similarity between the merged clusters is at least the threshold.
+type Clusterer struct {
+	minHash     lsh.MinHash
+	threshold   float64
+	maxExamples int
+	index       map[string]int
+	entries     []*entry
+}
+
+// New creates a new clusterer.
+func New(rows, bands int, threshold float64, maxExamples int) *Clusterer {
+	return &Clusterer{
+		minHash:     lsh.New(rows, bands),
+		threshold:   threshold,
+		maxExamples: maxExamples,
+		index:       make(map[string]int),
+	}
+}
+
+// Add adds a term with its NER label and the criterion it was extracted from.
+func (c *Clusterer) Add(term, label, example string) {
+	term = strings.TrimSpace(term)
+	if len(term) == 0 {
+		return
+	}
+	i, ok := c.index[term]
+	if !ok {
+		i = len(c.entries)
+		c.index[term] = i
+		c.entries = append(c.entries, &entry{term: term, labels: set.New()})
+	}
+	e := c.entries[i]
+	e.count++
+	if len(label) > 0 {
+		e.labels.Add(label)
+	}
+	if len(example) > 0 && len(e.examples) < c.maxExamples && !contains(e.examples, example) {
+		e.examples = append(e.examples, example)
+	}
+}
+
+// Size returns the number of unique terms.
+func (c *Clusterer) Size() int {
+	return len(c.entries)
+}
+
+// pair defines a candidate pair of similar terms.
+type pair struct {
+	i, j  int
+	score float64
+}
+
+// Cluster groups the collected terms into clusters.
+func (c *Clusterer) Cluster() Clusters {
+	n := len(c.entries)
+	for _, e := range c.entries {
+		e.shingles = c.minHash.Shingles(key(e.term))
+	}
+
+	// Find candidate pairs that share a bucket:
+	buckets := make(map[string][]int)
+	for i, e := range c.entries {
+		for code := range c.minHash.HashCodes(key(e.term)) {
+			buckets[code] = append(buckets[code], i)
+		}
+	}
+	seen := make(map[[2]int]bool)
+	var pairs []pair
+	for _, members := range buckets {
+		for a := 0; a < len(members); a++ {
+			for b := a + 1; b < len(members); b++ {
+				i, j := members[a], members[b]
+				if i > j {
+					i, j = j, i
+				}
+				if seen[[2]int{i, j}] {
+					continue
+				}
+				seen[[2]int{i, j}] = true
+				if s := c.similarity(i, j); s >= c.threshold {
+					pairs = append(pairs, pair{i: i, j: j, score: s})
+				}
+			}
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool {
+		if pairs[a].score != pairs[b].score {
+			return pairs[a].score > pairs[b].score
+		}
+		if pairs[a].i != pairs[b].i {
+			return pairs[a].i < pairs[b].i
+		}
+		return pairs[a].j < pairs[b].j
+	})
+
+	// Merge clusters agglomeratively:
+	parent := make([]int, n)
+	members := make([][]int, n)
+	for i := range parent {
+		parent[i] = i
+		members[i] = []int{i}
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, p := range pairs {
+		ri, rj := find(p.i), find(p.j)
+		if ri == rj || c.linkage(members[ri], members[rj]) < c.threshold {
+			continue
+		}
+		parent[rj] = ri
+		members[ri] = append(members[ri], members[rj]...)
+		members[rj] = nil
+	}
+
+	clusters := make(Clusters, 0)
+	for i := 0; i < n; i++ {
+		if find(i) == i {
+			clusters = append(clusters, c.newCluster(members[i]))
+		}
+	}
+	clusters.Sort()
+	return clusters
+}
+
+// similarity computes the similarity between the terms i and j.
+func (c *Clusterer) similarity(i, j int) float64 {
+	return c.minHash.ShingleSimilarity(c.entries[i].shingles, c.entries[j].shingles)
+}
+
+// linkage computes the average similarity between the terms of two clusters.
+func (c *Clusterer) linkage(members1, members2 []int) float64 {
+	sum := 0.0
+	for _, i := range members1 {
+		for _, j := range members2 {
+			sum += c.similarity(i, j)
+		}
+	}
+	return sum / float64(len(members1)*len(members2))
+}
+
+// newCluster creates a cluster from the terms.
+func (c *Clusterer) newCluster(members []int) *Cluster {
+	entries := make([]*entry, len(members))
+	for k, i := range members {
+		entries[k] = c.entries[i]
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].term < entries[j].term
+	})
+
+	cluster := &Cluster{Concept: entries[0].term}
+	labels := set.New()
+	for _, e := range entries {
+		cluster.Count += e.count
+		cluster.Terms = append(cluster.Terms, e.term)
+		labels.AddSet(e.labels)
+		for _, example := range e.examples {
+			if len(cluster.Examples) < c.maxExamples && !contains(cluster.Examples, example) {
+				cluster.Examples = append(cluster.Examples, example)
+			}
+		}
+	}
+	cluster.Labels = labels.Slice()
+	return cluster
+}
+
+// key converts the term to its clustering key by sorting and deduping its words
+// so that word order does not affect similarity.
+func key(term string) string {
+	words := strings.Fields(strings.ToLower(term))
+	sort.Strings(words)
+	return strings.Join(slice.Dedupe(words), " ")
+}
+
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
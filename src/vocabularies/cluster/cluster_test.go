@@ -0,0 +1,99 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package cluster
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClusterer() *Clusterer {
+	c := New(3, 16, 0.5, 2)
+	c.Add("covid-19 infection", "word_scores:chronic_disease", "confirmed covid-19 infection")
+	c.Add("covid-19 infection", "word_scores:chronic_disease", "active covid-19 infection")
+	c.Add("covid-19 infection", "word_scores:chronic_disease", "suspected covid-19 infection")
+	c.Add("infection covid-19", "word_scores:chronic_disease", "infection (covid-19)")
+	c.Add("covid-19 infections", "word_scores:chronic_disease", "prior covid-19 infections")
+	c.Add("chest ct scan", "word_scores:treatment", "chest ct scan within 7 days")
+	return c
+}
+
+func TestCluster(t *testing.T) {
+	a := assert.New(t)
+
+	c := newTestClusterer()
+	a.Equal(4, c.Size())
+
+	clusters := c.Cluster()
+	a.Equal(2, clusters.Len())
+
+	first := clusters[0]
+	a.Equal(1, first.ID)
+	a.Equal("covid-19 infection", first.Concept)
+	a.Equal(5, first.Count)
+	a.Equal([]string{"covid-19 infection", "covid-19 infections", "infection covid-19"}, first.Terms)
+	a.Equal([]string{"word_scores:chronic_disease"}, first.Labels)
+	a.Equal([]string{"confirmed covid-19 infection", "active covid-19 infection"}, first.Examples)
+
+	second := clusters[1]
+	a.Equal(2, second.ID)
+	a.Equal("chest ct scan", second.Concept)
+	a.Equal(1, second.Count)
+}
+
+func TestClusterThreshold(t *testing.T) {
+	a := assert.New(t)
+
+	c := New(3, 16, 1.0, 1)
+	c.Add("covid-19 infection", "", "")
+	c.Add("covid-19 infections", "", "")
+	c.Add("infection covid-19", "", "")
+
+	clusters := c.Cluster()
+	a.Equal(2, clusters.Len())
+	a.Equal([]string{"covid-19 infection", "infection covid-19"}, clusters[0].Terms)
+}
+
+func TestWriteLoad(t *testing.T) {
+	a := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cluster")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	clusters := newTestClusterer().Cluster()
+	clusters[0].Examples[0] = "a\tb|c"
+
+	var b bytes.Buffer
+	a.NoError(clusters.Write(&b))
+	fname := path.Join(dir, "clusters.tsv")
+	a.NoError(ioutil.WriteFile(fname, b.Bytes(), 0644))
+
+	loaded, err := Load(fname)
+	a.NoError(err)
+	a.Equal(clusters.Len(), loaded.Len())
+	a.Equal(clusters[0].Terms, loaded[0].Terms)
+	a.Equal("a b/c", loaded[0].Examples[0])
+}
+
+func TestWriteVocabulary(t *testing.T) {
+	a := assert.New(t)
+
+	clusters := newTestClusterer().Cluster()
+	clusters[0].Concept = "COVID-19"
+	clusters[0].TreeNumbers = []string{"C01.925"}
+
+	var b bytes.Buffer
+	cnt, err := clusters.WriteVocabulary(&b, 2)
+	a.NoError(err)
+	a.Equal(3, cnt)
+	expected := "COVID-19\tcovid-19 infection\tC01.925\n" +
+		"COVID-19\tcovid-19 infections\tC01.925\n" +
+		"COVID-19\tinfection covid-19\tC01.925\n"
+	a.Equal(expected, b.String())
+}
@@ -0,0 +1,128 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/param"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/slice"
+)
+
+// Header is the header of the cluster file.
+const Header = "#id\tconcept\ttree_numbers\tcount\tterms\tlabels\texamples"
+
+// Write writes the clusters to w as tab-separated rows. Clusters can be
+// reviewed by editing the concept name and tree numbers, and by removing
+// rows or terms, before they are promoted with WriteVocabulary.
+func (cs Clusters) Write(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, Header); err != nil {
+		return err
+	}
+	for _, c := range cs {
+		examples := make([]string, len(c.Examples))
+		for i, e := range c.Examples {
+			examples[i] = sanitize(e)
+		}
+		if _, err := fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\t%s\n", c.ID, c.Concept,
+			strings.Join(c.TreeNumbers, param.FieldSep), c.Count,
+			strings.Join(c.Terms, param.FieldSep), strings.Join(c.Labels, param.FieldSep),
+			strings.Join(examples, param.FieldSep)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteVocabulary writes the clusters whose count is at least minCount to w
+// in the custom vocabulary format read by taxonomy.LoadNodes:
+// concept, synonym, and optional tree numbers.
+func (cs Clusters) WriteVocabulary(w io.Writer, minCount int) (int, error) {
+	cnt := 0
+	for _, c := range cs {
+		if c.Count < minCount || len(c.Concept) == 0 {
+			continue
+		}
+		treeNumbers := strings.Join(c.TreeNumbers, param.FieldSep)
+		for _, term := range c.Terms {
+			line := c.Concept + "\t" + term
+			if len(treeNumbers) > 0 {
+				line += "\t" + treeNumbers
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return cnt, err
+			}
+			cnt++
+		}
+	}
+	return cnt, nil
+}
+
+// Load loads clusters from a cluster file written by Write.
+func Load(fname string) (Clusters, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	clusters := make(Clusters, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineCnt := 0
+	for scanner.Scan() {
+		lineCnt++
+		line := scanner.Text()
+		if len(strings.TrimSpace(line)) == 0 || line[0] == param.Comment {
+			continue
+		}
+		values := strings.Split(line, "\t")
+		if len(values) < 5 {
+			return nil, fmt.Errorf("%s: too few columns: line %d: '%s'", fname, lineCnt, line)
+		}
+		id, err := strconv.Atoi(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad cluster id: line %d: %v", fname, lineCnt, err)
+		}
+		count, err := strconv.Atoi(values[3])
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad count: line %d: %v", fname, lineCnt, err)
+		}
+		c := &Cluster{
+			ID:          id,
+			Concept:     strings.TrimSpace(values[1]),
+			TreeNumbers: split(values[2]),
+			Count:       count,
+			Terms:       split(values[4]),
+		}
+		if len(values) > 5 {
+			c.Labels = split(values[5])
+		}
+		if len(values) > 6 {
+			c.Examples = split(values[6])
+		}
+		clusters = append(clusters, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// split splits a multi-valued column.
+func split(s string) []string {
+	v := strings.Split(s, param.FieldSep)
+	slice.TrimSpace(v)
+	return slice.RemoveEmpty(v)
+}
+
+// sanitize removes column and field separators from s.
+func sanitize(s string) string {
+	s = strings.Replace(s, "\t", " ", -1)
+	return strings.Replace(s, param.FieldSep, "/", -1)
+}
@@ -0,0 +1,24 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+// Package custom loads a vocabulary, such as a drug list, from files
+// in the custom vocabulary format: concept, synonym, and optional tree numbers.
+package custom
+
+import (
+	"github.com/facebookresearch/clinical-trial-parser/src/vocabularies/taxonomy"
+
+	"github.com/golang/glog"
+)
+
+// Load loads a custom taxonomy from files.
+func Load(fnames ...string) *taxonomy.Taxonomy {
+	root := taxonomy.NewNode("root")
+	t := taxonomy.New(root)
+	nodes := taxonomy.LoadNodes(fnames...)
+	cnt := t.AddNodes(nodes)
+	glog.Infof("%v: Nodes read: %d, New nodes: %d\n", fnames, nodes.Len(), cnt)
+
+	t.SetBaseIndex()
+
+	return t
+}
@@ -21,6 +21,7 @@ type Descriptors struct {
 // Descriptor defines the xml struct for Descriptor.
 type Descriptor struct {
 	XMLName     xml.Name       `xml:"DescriptorRecord"`
+	UI          string         `xml:"DescriptorUI"`
 	Name        DescriptorName `xml:"DescriptorName"`
 	Concepts    Concepts       `xml:"ConceptList"`
 	TreeNumbers TreeNumbers    `xml:"TreeNumberList"`
@@ -117,9 +118,11 @@ func loadTaxonomy(fname string) *taxonomy.Taxonomy {
 			continue
 		}
 		de := taxonomy.NewNode(d.Name.Value)
+		de.SetID(d.UI)
 		for _, c := range d.Concepts.Concepts {
 			if !isAnimalConcept(c.Name.Value) {
 				ce := taxonomy.NewNode(c.Name.Value)
+				ce.SetID(d.UI)
 				for _, t := range c.Terms.Terms {
 					ce.AddSynonym(t.Name)
 				}
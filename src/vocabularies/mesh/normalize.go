@@ -20,16 +20,6 @@ var (
 	reE           = regexp.MustCompile(`\b e$`)
 	re1           = regexp.MustCompile(`\bi\b`)
 	re2           = regexp.MustCompile(`\bii\b`)
-	reHBV         = regexp.MustCompile(`\bhbv\b`)
-	reHCV         = regexp.MustCompile(`\bhcv\b`)
-	reCNS         = regexp.MustCompile(`\bcns\b`)
-	reAML         = regexp.MustCompile(`\baml\b`)
-	reNSCLC       = regexp.MustCompile(`\bnsclc\b`)
-	reCLL         = regexp.MustCompile(`\bcll\b`)
-	reHCC         = regexp.MustCompile(`\bhcc\b`)
-	reMM          = regexp.MustCompile(`\bmm\b`)
-	reGI          = regexp.MustCompile(`\bgi\b`)
-	reMRI         = regexp.MustCompile(`\bmri\b`)
 )
 
 // Normalize defines a normalizer function for MeSH terms.
@@ -40,19 +30,10 @@ var Normalize taxonomy.Normalizer = func(str string) (string, string) {
 	s = reEG.ReplaceAllString(s, "")
 	s = reE.ReplaceAllString(s, "")
 	s = strings.Replace(s, ",", " ", -1)
-	s = strings.Replace(s, "b hbsag", "hbv surface antigen", -1)
-	s = strings.Replace(s, "hbsag hbv", "hbv surface antigen", -1)
+	s = strings.Replace(s, "b hbsag", "hepatitis b surface antigen", -1)
+	s = strings.Replace(s, "hbsag hbv", "hepatitis b surface antigen", -1)
 	s = strings.Replace(s, "her2", "her-2", -1)
 
-	s = reCNS.ReplaceAllString(s, "central nervous system")
-	s = reAML.ReplaceAllString(s, "acute myeloid leukemia")
-	s = reNSCLC.ReplaceAllString(s, "non-small cell lung cancer")
-	s = reCLL.ReplaceAllString(s, "chronic lymphocytic leukemia")
-	s = reHCC.ReplaceAllString(s, "hepatocellular carcinoma")
-	s = reMM.ReplaceAllString(s, "multiple myeloma")
-	s = reGI.ReplaceAllString(s, "gastrointestinal")
-	s = reMRI.ReplaceAllString(s, "magnetic resonance imaging")
-
 	if strings.Contains(s, "diabetes") {
 		s = re1.ReplaceAllString(s, "1")
 		s = re2.ReplaceAllString(s, "2")
@@ -63,10 +44,6 @@ var Normalize taxonomy.Normalizer = func(str string) (string, string) {
 		s = strings.Replace(s, "c hcv", "c", -1)
 		s = strings.Replace(s, "active ", "", -1)
 		s = strings.Replace(s, " treatment", "", -1)
-	} else {
-		s = reHBV.ReplaceAllString(s, "b hepatitis")
-		s = reHCV.ReplaceAllString(s, "c hepatitis")
-		s = strings.Replace(s, "b c hep", "b c hepatitis", -1)
 	}
 	if len(s) == 0 {
 		s = str
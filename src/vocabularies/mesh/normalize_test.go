@@ -37,3 +37,14 @@ func TestNormalizeFilter(t *testing.T) {
 
 	a.Equal(expected, actual)
 }
+
+func TestNormalizeAbbreviations(t *testing.T) {
+	a := assert.New(t)
+
+	// Abbreviations are expanded by the abbreviation dictionary, not by the normalizer.
+	input := "lesion > 10 mm on mri"
+	expected := "lesion 10 mm mri"
+	_, actual := Normalize(input)
+
+	a.Equal(expected, actual)
+}
@@ -16,6 +16,8 @@ const (
 	MESH
 	// UMLS vocabulary source
 	UMLS
+	// CUSTOM vocabulary source in the custom vocabulary format
+	CUSTOM
 )
 
 // ParseSource converts the string to the vocabulary source.
@@ -25,6 +27,8 @@ func ParseSource(s string) Source {
 		return MESH
 	case "umls":
 		return UMLS
+	case "custom":
+		return CUSTOM
 	default:
 		return Unknown
 	}
@@ -37,6 +41,8 @@ func (s Source) String() string {
 		return "mesh"
 	case UMLS:
 		return "umls"
+	case CUSTOM:
+		return "custom"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,190 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package taxonomy
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/param"
+)
+
+// Reasons for selecting a concept among the candidate concepts.
+const (
+	ReasonSingle    = "single"    // Only one candidate concept
+	ReasonCondition = "condition" // Related to a condition of the study
+	ReasonStudy     = "study"     // Related to another concept linked in the same study
+	ReasonPrior     = "prior"     // In a preferred category of the NER label
+	ReasonScore     = "score"     // Highest match score
+)
+
+// Priors maps NER labels to their preferred categories.
+type Priors map[string]set.Set
+
+// ParsePriors parses label priors from a string of the form
+// "label1=C,label2=D|E", where categories of a label are separated by '|'.
+func ParsePriors(s string) Priors {
+	priors := make(Priors)
+	for _, v := range strings.Split(s, ",") {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		label := strings.TrimSpace(kv[0])
+		categories := set.New()
+		for _, c := range strings.Split(kv[1], param.FieldSep) {
+			if c = strings.TrimSpace(c); len(c) > 0 {
+				categories.Add(c)
+			}
+		}
+		if len(label) > 0 && !categories.Empty() {
+			priors[label] = categories
+		}
+	}
+	return priors
+}
+
+// Get returns the preferred categories of the label.
+func (p Priors) Get(label string) set.Set {
+	if c, ok := p[label]; ok {
+		return c
+	}
+	return set.New()
+}
+
+// Context defines the study context used to disambiguate candidate concepts:
+// concepts matched to the study conditions and concepts linked to other terms of the study.
+type Context struct {
+	conditions Terms
+	concepts   Terms
+	keys       set.Set
+}
+
+// NewContext creates a new empty context.
+func NewContext() *Context {
+	return &Context{conditions: NewTerms(0), concepts: NewTerms(0), keys: set.New()}
+}
+
+// AddConditions adds concepts matched to a study condition.
+func (c *Context) AddConditions(ts Terms) {
+	c.conditions = append(c.conditions, ts...)
+}
+
+// AddConcept adds a concept linked to a term of the study.
+func (c *Context) AddConcept(t Term) {
+	if !c.keys.Contains(t.Key) {
+		c.keys.Add(t.Key)
+		c.concepts = append(c.concepts, t)
+	}
+}
+
+// others returns the concepts linked in the study other than the concept of the key,
+// so that a candidate is not evidence for itself.
+func (c *Context) others(key string) Terms {
+	ts := NewTerms(0)
+	for _, t := range c.concepts {
+		if t.Key != key {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+// Disambiguate ranks the candidate concepts of a term with the NER label and
+// returns them with the selected concept first, together with the reason for the selection.
+// Candidates are ranked by evidence in the order of decreasing strength: relatedness to
+// the study conditions, relatedness to other concepts linked in the study, and the category prior
+// of the label. Candidates with equal evidence are ranked by their match scores.
+func (c *Context) Disambiguate(ts Terms, label string, priors Priors) (Terms, string) {
+	switch ts.Len() {
+	case 0:
+		return ts, ""
+	case 1:
+		return ts, ReasonSingle
+	}
+
+	type evidence struct {
+		term      Term
+		condition string
+		study     string
+		prior     bool
+	}
+	preferred := priors.Get(label)
+	candidates := make([]evidence, ts.Len())
+	for i, t := range ts {
+		e := evidence{term: t}
+		e.condition = related(t, c.conditions)
+		e.study = related(t, c.others(t.Key))
+		e.prior = !preferred.Empty() && t.Categories.Intersection(preferred) > 0
+		candidates[i] = e
+	}
+	weight := func(e evidence) int {
+		w := 0
+		if len(e.condition) > 0 {
+			w += 4
+		}
+		if len(e.study) > 0 {
+			w += 2
+		}
+		if e.prior {
+			w++
+		}
+		return w
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		wi, wj := weight(candidates[i]), weight(candidates[j])
+		if wi != wj {
+			return wi > wj
+		}
+		return candidates[i].term.Value > candidates[j].term.Value
+	})
+
+	ranked := make(Terms, len(candidates))
+	for i, e := range candidates {
+		ranked[i] = e.term
+	}
+
+	// The reason is the strongest evidence that the selected concept has but the runner-up does not:
+	best := candidates[0]
+	diff := weight(best) ^ weight(candidates[1])
+	switch {
+	case diff == 0:
+		return ranked, ReasonScore
+	case diff&4 > 0:
+		return ranked, ReasonCondition + ":" + best.condition
+	case diff&2 > 0:
+		return ranked, ReasonStudy + ":" + best.study
+	default:
+		categories := set.New()
+		for cat := range best.term.Categories {
+			if preferred.Contains(cat) {
+				categories.Add(cat)
+			}
+		}
+		return ranked, ReasonPrior + ":" + strings.Join(categories.Slice(), param.FieldSep)
+	}
+}
+
+// related returns the key of the first concept in ts that is the same as t
+// or its ancestor or descendant in the tree hierarchy.
+func related(t Term, ts Terms) string {
+	for _, u := range ts {
+		if t.Key == u.Key {
+			return u.Key
+		}
+		for tn1 := range t.TreeNumbers {
+			for tn2 := range u.TreeNumbers {
+				if isAncestor(tn1, tn2) || isAncestor(tn2, tn1) {
+					return u.Key
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// isAncestor tests whether the tree number tn1 is the same as or an ancestor of tn2.
+func isAncestor(tn1, tn2 string) bool {
+	return tn1 == tn2 || strings.HasPrefix(tn2, tn1+".")
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package taxonomy
+
+import (
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCandidates() Terms {
+	return Terms{
+		NewTerm("Depression", 0.9, set.New("F"), set.New("F01.145.126.350")),
+		NewTerm("Depressive Disorder", 0.89, set.New("F"), set.New("F03.600.300")),
+		NewTerm("Depression, Chemical", 0.88, set.New("G"), set.New("G02.111.223")),
+	}
+}
+
+func TestParsePriors(t *testing.T) {
+	a := assert.New(t)
+
+	priors := ParsePriors("word_scores:chronic_disease=C, word_scores:treatment=D|E,bad")
+	a.Equal(2, len(priors))
+	a.Equal(set.New("C"), priors.Get("word_scores:chronic_disease"))
+	a.Equal(set.New("D", "E"), priors.Get("word_scores:treatment"))
+	a.True(priors.Get("word_scores:gender").Empty())
+}
+
+func TestDisambiguateScore(t *testing.T) {
+	a := assert.New(t)
+
+	c := NewContext()
+	ranked, reason := c.Disambiguate(newTestCandidates(), "word_scores:chronic_disease", Priors{})
+	a.Equal("Depression", ranked.MaxKey())
+	a.Equal(ReasonScore, reason)
+
+	ranked, reason = c.Disambiguate(newTestCandidates()[:1], "word_scores:chronic_disease", Priors{})
+	a.Equal("Depression", ranked.MaxKey())
+	a.Equal(ReasonSingle, reason)
+}
+
+func TestDisambiguatePrior(t *testing.T) {
+	a := assert.New(t)
+
+	c := NewContext()
+	priors := ParsePriors("word_scores:treatment=D|G")
+	ranked, reason := c.Disambiguate(newTestCandidates(), "word_scores:treatment", priors)
+	a.Equal("Depression, Chemical", ranked.MaxKey())
+	a.Equal("prior:G", reason)
+	a.Equal(3, ranked.Len())
+}
+
+func TestDisambiguateCondition(t *testing.T) {
+	a := assert.New(t)
+
+	c := NewContext()
+	c.AddConditions(Terms{NewTerm("Depressive Disorder, Major", 1, set.New("F"), set.New("F03.600.300.375"))})
+	c.AddConcept(NewTerm("Depression", 1, set.New("F"), set.New("F01.145.126.350")))
+	priors := ParsePriors("word_scores:chronic_disease=G")
+	ranked, reason := c.Disambiguate(newTestCandidates(), "word_scores:chronic_disease", priors)
+	a.Equal("Depressive Disorder", ranked.MaxKey())
+	a.Equal("condition:Depressive Disorder, Major", reason)
+}
+
+func TestDisambiguateStudy(t *testing.T) {
+	a := assert.New(t)
+
+	c := NewContext()
+	c.AddConcept(NewTerm("Mood Disorders", 1, set.New("F"), set.New("F03.600")))
+	ranked, reason := c.Disambiguate(newTestCandidates(), "word_scores:chronic_disease", Priors{})
+	a.Equal("Depressive Disorder", ranked.MaxKey())
+	a.Equal("study:Mood Disorders", reason)
+}
+
+func TestDisambiguateStudyExcludesCandidate(t *testing.T) {
+	a := assert.New(t)
+
+	c := NewContext()
+	c.AddConcept(NewTerm("Depressive Disorder", 1, set.New("F"), set.New("F03.600.300")))
+	ranked, reason := c.Disambiguate(newTestCandidates(), "word_scores:chronic_disease", Priors{})
+	a.Equal("Depression", ranked.MaxKey())
+	a.Equal(ReasonScore, reason)
+}
@@ -6,7 +6,6 @@ import (
 	"bufio"
 	"os"
 	"strings"
-	"sync"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/lsh"
@@ -18,10 +17,12 @@ import (
 
 // Node defines a node in a taxonomy.
 type Node struct {
+	id          string
 	name        string
 	children    Nodes
 	synonyms    set.Set
 	treeNumbers set.Set
+	shingles    map[string]set.Set // precomputed synonym shingles for similarity scoring
 }
 
 // NewNode creates a new node.
@@ -34,6 +35,16 @@ func (n *Node) Name() string {
 	return n.name
 }
 
+// ID returns the node's identifier, such as a MeSH descriptor UI.
+func (n *Node) ID() string {
+	return n.id
+}
+
+// SetID sets the node's identifier.
+func (n *Node) SetID(id string) {
+	n.id = id
+}
+
 // Synonyms returns all synonyms of the node and its child nodes.
 func (n *Node) Synonyms() set.Set {
 	set := set.New()
@@ -115,6 +126,9 @@ func equals(s1, s2 string) bool {
 // Update finds and updates a node. If no matching node is found, false is returned.
 func (n *Node) Update(m *Node) bool {
 	if n.children == nil && equals(n.name, m.name) {
+		if len(n.id) == 0 {
+			n.id = m.id
+		}
 		n.synonyms.AddSet(m.synonyms)
 		n.treeNumbers.AddSet(m.treeNumbers)
 		return true
@@ -127,45 +141,52 @@ func (n *Node) Update(m *Node) bool {
 }
 
 // normalize normalizes the node's and its child nodes' synonyms.
+// Precomputed shingles are discarded because they refer to the old synonyms.
 func (n *Node) normalize(f Normalizer) {
 	synonyms := set.New()
 	for s := range n.synonyms {
 		synonyms.Add(f(s))
 	}
 	n.synonyms = synonyms
+	n.shingles = nil
 
 	for i := range n.children {
 		n.children[i].normalize(f)
 	}
 }
 
-// walk walks the node and its child nodes and returns terms with match score.
-func (n *Node) walk(s string, indices []int, q chan<- Term, minHash lsh.MinHash, minScore float64) {
-	wait := &sync.WaitGroup{}
-	for _, i := range indices {
-		wait.Add(1)
-		m := n.children[i]
-		go func(m *Node) {
-			defer wait.Done()
-			m.match(s, q, minHash, minScore)
-		}(m)
+// setShingles precomputes the shingle sets of the node's and its child nodes' synonyms.
+func (n *Node) setShingles(h lsh.MinHash) {
+	n.shingles = make(map[string]set.Set, len(n.synonyms))
+	for s := range n.synonyms {
+		n.shingles[s] = h.Shingles(s)
+	}
+	for _, m := range n.children {
+		m.setShingles(h)
 	}
-
-	go func() {
-		wait.Wait()
-		close(q)
-	}()
 }
 
-// match returns the node and its child nodes with the match scores.
-func (n *Node) match(s string, q chan<- Term, minHash lsh.MinHash, minScore float64) {
+// match adds the node and its child nodes with the match scores to the priority queue.
+// Terms that do not pass the category filter or do not have a tree number with any of the prefixes are skipped.
+func (n *Node) match(shingles set.Set, p *Priority, h lsh.MinHash, minScore float64, filter set.Set, prefixes []string) {
 	for syn := range n.synonyms {
-		if score := minHash.Similarity(s, syn); score >= minScore {
-			q <- NewTerm(n.name, score, n.Categories(), n.TreeNumbers().Copy())
+		synShingles, ok := n.shingles[syn]
+		if !ok {
+			synShingles = h.Shingles(syn)
+		}
+		if score := h.ShingleSimilarity(shingles, synShingles); score >= minScore {
+			t := NewTerm(n.name, score, n.Categories(), n.TreeNumbers().Copy())
+			t.ID = n.id
+			if !t.PassFilter(filter) {
+				continue
+			}
+			if ts := (Terms{t}).PassTreeNumbers(prefixes); len(ts) > 0 {
+				p.Insert(ts[0].TrimCategories(filter))
+			}
 		}
 	}
 	for _, m := range n.children {
-		m.match(s, q, minHash, minScore)
+		m.match(shingles, p, h, minScore, filter, prefixes)
 	}
 }
 
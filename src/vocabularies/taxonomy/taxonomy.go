@@ -3,12 +3,15 @@
 package taxonomy
 
 import (
+	"context"
 	"fmt"
+	"runtime"
 	"strings"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/lsh"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/fio"
+	"github.com/facebookresearch/clinical-trial-parser/src/common/util/intmath"
 
 	"github.com/golang/glog"
 )
@@ -30,12 +33,13 @@ type Taxonomy struct {
 
 	capacity int
 	buffSize int
+	workers  int
 	minScore float64
 }
 
 // New creates a new taxonomy.
 func New(r *Node) *Taxonomy {
-	return &Taxonomy{root: r, normalize: identity, capacity: capacity, buffSize: buffSize, minScore: minScore}
+	return &Taxonomy{root: r, normalize: identity, capacity: capacity, buffSize: buffSize, workers: runtime.NumCPU(), minScore: minScore}
 }
 
 // SetQueueCapacity sets the capacity of the search priority queue.
@@ -43,11 +47,16 @@ func (t *Taxonomy) SetQueueCapacity(c int) {
 	t.capacity = c
 }
 
-// SetBuffSize sets the buffer size of the search channel.
+// SetBuffSize sets the buffer size of the channel that feeds candidate nodes to the workers.
 func (t *Taxonomy) SetBuffSize(b int) {
 	t.buffSize = b
 }
 
+// SetWorkers sets the number of workers that score the candidate nodes of a query.
+func (t *Taxonomy) SetWorkers(w int) {
+	t.workers = intmath.Max(w, 1)
+}
+
 // SetMinScore sets the minimum score below which terms are disregarded.
 func (t *Taxonomy) SetMinScore(p float64) {
 	t.minScore = p
@@ -73,10 +82,18 @@ func (t *Taxonomy) AddNode(n *Node) bool {
 	return false
 }
 
+// Descriptors returns the top-level nodes of the taxonomy.
+func (t *Taxonomy) Descriptors() Nodes {
+	return t.root.children
+}
+
 // Normalize normalizes the node synonyms.
 func (t *Taxonomy) Normalize(f Normalizer) {
 	t.normalize = f
 	t.root.normalize(f)
+	if len(t.baseIndex) > 0 {
+		t.root.setShingles(t.minHash)
+	}
 }
 
 // Info prints basic information about the taxonomy.
@@ -131,6 +148,7 @@ func (t *Taxonomy) SetBaseIndex() {
 	t.baseIndex = baseIndex
 	t.minHash = lsh.New(3, 16) // For computing similarity scores.
 	t.hashIndex = nil
+	t.root.setShingles(t.minHash)
 }
 
 // SetHashIndex sets the indexing to LSH.
@@ -164,25 +182,42 @@ func (t *Taxonomy) getMatchIndices(s string) []int {
 
 // Match matches a string to terms in the taxonomy.
 func (t *Taxonomy) Match(s string, d float64, filter set.Set) Terms {
+	terms, _ := t.MatchContext(context.Background(), s, d, filter)
+	return terms
+}
+
+// MatchTreeNumbers matches a string to terms in the taxonomy that have a tree number with
+// any of the prefixes; see Terms.PassTreeNumbers. Terms are filtered before the terms within
+// d of the top term are selected, so that a term with a prefix is not cut by a term without one.
+func (t *Taxonomy) MatchTreeNumbers(s string, d float64, filter set.Set, prefixes []string) Terms {
+	terms, _ := t.matchContext(context.Background(), s, d, filter, prefixes)
+	return terms
+}
+
+// MatchContext matches a string to terms in the taxonomy. The candidate nodes are
+// scored by a bounded pool of workers. If ctx is done before all candidates are scored,
+// the default term and the context error are returned.
+func (t *Taxonomy) MatchContext(ctx context.Context, s string, d float64, filter set.Set) (Terms, error) {
+	return t.matchContext(ctx, s, d, filter, nil)
+}
+
+// matchContext matches a string to terms like MatchContext, keeping only terms that have
+// a tree number with any of the prefixes if prefixes are given.
+func (t *Taxonomy) matchContext(ctx context.Context, s string, d float64, filter set.Set, prefixes []string) (Terms, error) {
 	if len(t.baseIndex) == 0 && len(t.hashIndex) == 0 {
 		glog.Fatal("Search index not set.")
 	}
-	priority := NewPriority(t.capacity)
-	q := make(chan Term, t.buffSize)
 
 	nsorted, n := t.normalize(s)
 
 	if len(nsorted) == 0 {
-		return Default(s, n)
+		return Default(s, n), nil
 	}
 	indices := t.getMatchIndices(nsorted)
 
-	go t.root.walk(nsorted, indices, q, t.minHash, t.minScore)
-
-	for p := range q {
-		if p.PassFilter(filter) {
-			priority.Insert(p.TrimCategories(filter))
-		}
+	priority, err := t.score(ctx, t.minHash.Shingles(nsorted), indices, filter, prefixes)
+	if err != nil {
+		return Default(s, n), err
 	}
 
 	terms := priority.Terms().SortByKey().Dedupe().SortByValue().TopDelta(d)
@@ -191,7 +226,50 @@ func (t *Taxonomy) Match(s string, d float64, filter set.Set) Terms {
 	} else {
 		terms = Default(s, n)
 	}
-	return terms
+	return terms, nil
+}
+
+// score scores the candidate nodes against the query shingles. Each worker keeps
+// its own priority queue, and the queues are merged after the workers are done.
+func (t *Taxonomy) score(ctx context.Context, shingles set.Set, indices []int, filter set.Set, prefixes []string) (*Priority, error) {
+	workers := intmath.Min(t.workers, len(indices))
+	jobs := make(chan int, intmath.Min(t.buffSize, len(indices)))
+	results := make(chan *Priority, workers)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			p := NewPriority(t.capacity)
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue // Drain the remaining jobs.
+				}
+				t.root.children[i].match(shingles, p, t.minHash, t.minScore, filter, prefixes)
+			}
+			results <- p
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, i := range indices {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	priority := NewPriority(t.capacity)
+	for w := 0; w < workers; w++ {
+		for _, term := range (<-results).Terms() {
+			priority.Insert(term)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return priority, nil
 }
 
 // MatchNode matches a string to terms in the taxonomy.
@@ -0,0 +1,139 @@
+// Copyright (c) Facebook, Inc. and its affiliates. All Rights Reserved.
+
+package taxonomy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var words = []string{
+	"acute", "chronic", "renal", "hepatic", "cardiac", "pulmonary", "cerebral", "lymphoid", "myeloid",
+	"cell", "carcinoma", "leukemia", "lymphoma", "failure", "infection", "disease", "syndrome", "disorder",
+	"insufficiency", "neoplasm", "fibrosis", "sclerosis", "hypertension", "diabetes", "arthritis",
+}
+
+// newTestTaxonomy creates a taxonomy with size descriptors, each with a single concept
+// whose synonym is a unique combination of three words.
+func newTestTaxonomy(size int) *Taxonomy {
+	n := len(words)
+	root := NewNode("root")
+	for i := 0; i < size; i++ {
+		d := NewNode(fmt.Sprintf("descriptor %d", i))
+		c := NewNode(fmt.Sprintf("concept %d", i))
+		c.AddSynonym(synonym(i))
+		c.AddTreeNumber(fmt.Sprintf("C%02d.%03d", i%n, i))
+		d.AddChild(c)
+		root.AddChild(d)
+	}
+	t := New(root)
+	t.SetBaseIndex()
+	return t
+}
+
+func synonym(i int) string {
+	n := len(words)
+	return words[i%n] + " " + words[(i/n)%n] + " " + words[(i/n/n)%n]
+}
+
+func TestMatch(t *testing.T) {
+	a := assert.New(t)
+
+	tx := newTestTaxonomy(1000)
+	terms := tx.Match(synonym(42), 0, set.New())
+	a.Equal("concept 42", terms.MaxKey())
+	a.Equal(1.0, terms.MaxValue())
+	a.Equal([]string{"C"}, terms.Categories())
+}
+
+func TestMatchHashIndex(t *testing.T) {
+	a := assert.New(t)
+
+	tx := newTestTaxonomy(1000)
+	tx.SetHashIndex(3, 16)
+	terms := tx.Match(synonym(42), 0, set.New())
+	a.Equal("concept 42", terms.MaxKey())
+}
+
+func TestMatchFilter(t *testing.T) {
+	a := assert.New(t)
+
+	tx := newTestTaxonomy(100)
+	terms := tx.Match(synonym(42), 0, set.New("D"))
+	a.Equal(Default(synonym(42), synonym(42)), terms)
+}
+
+func TestMatchTreeNumbers(t *testing.T) {
+	a := assert.New(t)
+
+	tx := newTestTaxonomy(1000)
+	// Concept 42 is the top term, and concept 426, which is in C01, is cut by the margin:
+	a.Empty(tx.Match(synonym(42), 0, set.New()).PassTreeNumbers([]string{"C01"}))
+	terms := tx.MatchTreeNumbers(synonym(42), 0, set.New(), []string{"C01"})
+	a.Equal("concept 426", terms.MaxKey())
+	a.Equal([]string{"C01.426"}, terms[0].TreeNumbers.Slice())
+}
+
+func TestMatchWorkers(t *testing.T) {
+	a := assert.New(t)
+
+	tx := newTestTaxonomy(1000)
+	expected := tx.Match(synonym(7), 0.1, set.New())
+	tx.SetWorkers(1)
+	actual := tx.Match(synonym(7), 0.1, set.New())
+	a.Equal(expected, actual)
+}
+
+func TestMatchContextCanceled(t *testing.T) {
+	a := assert.New(t)
+
+	tx := newTestTaxonomy(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	terms, err := tx.MatchContext(ctx, synonym(42), 0, set.New())
+	a.Equal(context.Canceled, err)
+	a.Equal(Default(synonym(42), synonym(42)), terms)
+}
+
+func benchmarkMatch(b *testing.B, tx *Taxonomy) {
+	empty := set.New()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tx.Match(synonym(i), 0.02, empty)
+	}
+}
+
+func BenchmarkMatchBaseIndex(b *testing.B) {
+	benchmarkMatch(b, newTestTaxonomy(10000))
+}
+
+func BenchmarkMatchHashIndex(b *testing.B) {
+	tx := newTestTaxonomy(10000)
+	tx.SetHashIndex(3, 16)
+	benchmarkMatch(b, tx)
+}
+
+func BenchmarkMatchSingleWorker(b *testing.B) {
+	tx := newTestTaxonomy(10000)
+	tx.SetWorkers(1)
+	benchmarkMatch(b, tx)
+}
+
+func BenchmarkMatchParallel(b *testing.B) {
+	tx := newTestTaxonomy(10000)
+	tx.SetHashIndex(3, 16)
+	empty := set.New()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			tx.Match(synonym(i), 0.02, empty)
+			i++
+		}
+	})
+}
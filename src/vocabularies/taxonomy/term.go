@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strings"
 
 	"github.com/facebookresearch/clinical-trial-parser/src/common/col/set"
 	"github.com/facebookresearch/clinical-trial-parser/src/common/util/text"
@@ -14,6 +15,7 @@ import (
 // Term defines a vocabulary term or concept with a Key (e.g., name)
 // and Value (e.g., score).
 type Term struct {
+	ID          string
 	Key         string
 	Normalized  string
 	Value       float64
@@ -160,6 +162,9 @@ func (ts Terms) Dedupe() Terms {
 			ts[n] = ts[i]
 		} else {
 			ts[n].Value = math.Max(ts[n].Value, ts[i].Value)
+			if len(ts[n].ID) == 0 {
+				ts[n].ID = ts[i].ID
+			}
 			ts[n].Categories.AddSet(ts[i].Categories)
 			ts[n].TreeNumbers.AddSet(ts[i].TreeNumbers)
 		}
@@ -182,6 +187,45 @@ func (ts Terms) PassFilter(categories set.Set) Terms {
 	return ts[:n]
 }
 
+// PassTreeNumbers keeps terms that have a tree number with any of the prefixes.
+// Tree numbers without the prefixes are removed from the kept terms.
+func (ts Terms) PassTreeNumbers(prefixes []string) Terms {
+	if len(prefixes) == 0 {
+		return ts
+	}
+	hasPrefix := func(tn string) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(tn, p) {
+				return true
+			}
+		}
+		return false
+	}
+	n := 0
+	for _, t := range ts {
+		treeNumbers := set.New()
+		for tn := range t.TreeNumbers {
+			if hasPrefix(tn) {
+				treeNumbers.Add(tn)
+			}
+		}
+		if treeNumbers.Empty() {
+			continue
+		}
+		t.TreeNumbers = treeNumbers
+		categories := set.New()
+		for tn := range treeNumbers {
+			if c := text.LetterPrefix(tn); t.Categories.Contains(c) {
+				categories.Add(c)
+			}
+		}
+		t.Categories = categories
+		ts[n] = t
+		n++
+	}
+	return ts[:n]
+}
+
 // TopDelta keep terms whose terms are within d of the top term.
 func (ts Terms) TopDelta(d float64) Terms {
 	if ts.Len() < 2 {
@@ -81,3 +81,30 @@ func TestPassFilter(t *testing.T) {
 
 	a.Equal(expected, actual)
 }
+
+func TestIDDedupe(t *testing.T) {
+	initTerms()
+	a := assert.New(t)
+
+	MTerm.ID = "D000001"
+	input := Terms{ATerm, MTerm}
+	actual := input.Dedupe()
+
+	a.Len(actual, 1)
+	a.Equal("D000001", actual[0].ID)
+}
+
+func TestPassTreeNumbers(t *testing.T) {
+	a := assert.New(t)
+
+	input := Terms{
+		NewTerm("a", 1, set.New("C", "F"), set.New("C04.588", "F03.600")),
+		NewTerm("b", 1, set.New("C"), set.New("C10.228")),
+	}
+	expected := Terms{NewTerm("a", 1, set.New("C"), set.New("C04.588"))}
+	actual := input.PassTreeNumbers([]string{"C04", "D27"})
+	a.Equal(expected, actual)
+
+	input = Terms{NewTerm("b", 1, set.New("C"), set.New("C10.228"))}
+	a.Equal(input, input.PassTreeNumbers(nil))
+}
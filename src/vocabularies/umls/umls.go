@@ -53,6 +53,7 @@ func Load(fname string) *taxonomy.Taxonomy {
 		} else {
 			ids.Add(id)
 			de = taxonomy.NewNode(name)
+			de.SetID(id)
 			de.AddTreeNumber(id)
 			de.AddSynonym(name)
 			root.AddChild(de)